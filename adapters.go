@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdapterTargets 命令行 -adapter 参数，为指定WiFi网卡配置按优先级排列的目标网络，可重复指定
+type AdapterTargets struct {
+	// interfaces 按指定顺序排列的网卡名称
+	interfaces []string
+	targets    map[string][]string
+}
+
+// String 实现flag.Value接口
+func (a *AdapterTargets) String() string {
+	var items []string
+	for _, iface := range a.interfaces {
+		items = append(items, iface+"="+strings.Join(a.targets[iface], ","))
+	}
+	return strings.Join(items, " ")
+}
+
+// Set 实现flag.Value接口，格式为 网卡=网络1,网络2
+func (a *AdapterTargets) Set(value string) error {
+	iface, networks, ok := strings.Cut(value, "=")
+	iface = strings.TrimSpace(iface)
+	if !ok || iface == "" {
+		return fmt.Errorf("格式应为 网卡=网络1,网络2: %s", value)
+	}
+	var targets []string
+	for _, network := range strings.Split(networks, ",") {
+		if network = strings.TrimSpace(network); network != "" {
+			targets = append(targets, network)
+		}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("网卡 %s 未指定目标网络", iface)
+	}
+	if a.targets == nil {
+		a.targets = make(map[string][]string)
+	}
+	if _, exists := a.targets[iface]; !exists {
+		a.interfaces = append(a.interfaces, iface)
+	}
+	a.targets[iface] = targets
+	return nil
+}
+
+// Len 返回已配置的网卡数量
+func (a *AdapterTargets) Len() int {
+	return len(a.interfaces)
+}
+
+// Networks 返回全部网卡配置的目标网络（去重）
+func (a *AdapterTargets) Networks() []string {
+	var networks []string
+	for _, iface := range a.interfaces {
+		for _, network := range a.targets[iface] {
+			if !slices.Contains(networks, network) {
+				networks = append(networks, network)
+			}
+		}
+	}
+	return networks
+}
+
+// MonitorGroup 全部受管WiFi网卡的监控器，第一个为主监控器
+type MonitorGroup struct {
+	adapters *AdapterTargets
+	// all 是否管理全部WiFi网卡，为true时定期检测新插入的网卡
+	all      bool
+	monitors []*Monitor
+	// skipped 未配置目标网络、不予管理的网卡，避免重复输出日志
+	skipped map[string]bool
+	// trigger 用于请求立即检测新插入的网卡
+	trigger chan struct{}
+	mutex   sync.RWMutex
+}
+
+// newMonitorGroup 为每个受管的WiFi网卡创建独立的监控器。
+// 未指定 -adapter 和 -all-adapters 时只管理自动检测到的一个网卡，网卡不存在时等待插入并重新检测；
+// 指定 -all-adapters 时管理全部WiFi网卡，未通过 -adapter 配置的网卡使用 -w 指定的网络
+func newMonitorGroup(adapters *AdapterTargets, all bool) (*MonitorGroup, error) {
+	g := &MonitorGroup{adapters: adapters, all: all, skipped: make(map[string]bool), trigger: make(chan struct{}, 1)}
+	if !all && adapters.Len() == 0 {
+		connector, err := NewWiFiConnector()
+		if err != nil {
+			log.Printf("%v，等待WiFi网卡插入", err)
+			// 接口名称为空表示尚未检测到网卡，检查时会重新检测；通过 -iface 指定时等待该网卡插入
+			if connector, err = NewWiFiConnectorForInterface(wifiInterface); err != nil {
+				return nil, err
+			}
+		}
+		monitor := NewMonitor(connector, []string{targetWiFi}, true)
+		monitor.detect = NewWiFiConnector
+		g.monitors = append(g.monitors, monitor)
+		return g, nil
+	}
+
+	interfaces := slices.Clone(adapters.interfaces)
+	if all {
+		detected, err := ListWiFiInterfaces()
+		if err != nil {
+			log.Printf("%v，等待WiFi网卡插入", err)
+		}
+		// 通过 -adapter 指定但未检测到的网卡等待插入
+		for _, iface := range interfaces {
+			if !slices.Contains(detected, iface) {
+				detected = append(detected, iface)
+			}
+		}
+		interfaces = detected
+	}
+	for _, iface := range interfaces {
+		if _, err := g.addInterface(iface); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// addInterface 为指定网卡创建监控器，网卡未配置目标网络时不管理并返回nil
+func (g *MonitorGroup) addInterface(iface string) (*Monitor, error) {
+	targets := g.adapters.targets[iface]
+	if len(targets) == 0 {
+		if targetWiFi == "" {
+			if !g.skipped[iface] {
+				log.Printf("WiFi网卡 %s 未配置目标网络，不管理该网卡", iface)
+				g.skipped[iface] = true
+			}
+			return nil, nil
+		}
+		targets = []string{targetWiFi}
+	}
+	connector, err := NewWiFiConnectorForInterface(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	monitor := NewMonitor(connector, targets, len(g.monitors) == 0)
+	g.monitors = append(g.monitors, monitor)
+	return monitor, nil
+}
+
+// Monitors 返回全部监控器
+func (g *MonitorGroup) Monitors() []*Monitor {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return slices.Clone(g.monitors)
+}
+
+// Primary 返回主监控器，管理全部WiFi网卡且尚未检测到网卡时返回nil
+func (g *MonitorGroup) Primary() *Monitor {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	if len(g.monitors) == 0 {
+		return nil
+	}
+	return g.monitors[0]
+}
+
+// TriggerCheck 请求全部监控器立即执行一次检查，管理全部WiFi网卡时同时检测新插入的网卡
+func (g *MonitorGroup) TriggerCheck() {
+	for _, monitor := range g.Monitors() {
+		monitor.TriggerCheck()
+	}
+	select {
+	case g.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run 启动全部监控器，管理全部WiFi网卡时按间隔检测新插入的网卡并为其启动监控器
+func (g *MonitorGroup) Run(interval time.Duration) {
+	for _, monitor := range g.Monitors() {
+		go monitor.Run(interval)
+	}
+	if !g.all {
+		select {}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-g.trigger:
+		}
+		g.addNewAdapters(interval)
+	}
+}
+
+// addNewAdapters 为新插入的WiFi网卡启动监控器，已拔出的网卡由其监控器等待重新插入
+func (g *MonitorGroup) addNewAdapters(interval time.Duration) {
+	interfaces, err := ListWiFiInterfaces()
+	if err != nil {
+		return
+	}
+	managed := make(map[string]bool)
+	for _, monitor := range g.Monitors() {
+		iface, _ := monitor.connector.GetInterface()
+		managed[iface] = true
+	}
+	for _, iface := range interfaces {
+		if managed[iface] || g.skipped[iface] {
+			continue
+		}
+		monitor, err := g.addInterface(iface)
+		if err != nil || monitor == nil {
+			continue
+		}
+		log.Printf("检测到新的WiFi网卡: %s，目标WiFi网络: %s", iface, strings.Join(monitor.targets, "、"))
+		go monitor.Run(interval)
+	}
+}
+
+// interfacePresent 检查网络接口是否存在
+func interfacePresent(interfaceName string) bool {
+	if interfaceName == "" {
+		return false
+	}
+	_, err := net.InterfaceByName(interfaceName)
+	return err == nil
+}
+
+const (
+	// adapterDetectMin 网卡不存在时首次重新检测失败后的等待时间
+	adapterDetectMin = 10 * time.Second
+	// adapterDetectMax 连续重新检测失败后的最长等待时间，Windows上每次检测需要执行多个PowerShell命令
+	adapterDetectMax = 5 * time.Minute
+)
+
+// checkAdapter 检查WiFi网卡是否存在，不存在时按需重新检测（连续失败时退避）；网卡移除和恢复时记录历史并发送通知，返回网卡是否可用
+func (m *Monitor) checkAdapter() bool {
+	interfaceName, _ := m.connector.GetInterface()
+	present := interfacePresent(interfaceName)
+	if !present && m.detect != nil && !time.Now().Before(m.nextDetect) {
+		// 重新检测网卡，USB网卡重新插入后接口名称可能变化
+		if connector, err := m.detect(); err == nil {
+			m.connector = connector
+			interfaceName, _ = connector.GetInterface()
+			present = true
+		} else {
+			m.detectFailures++
+			delay := adapterDetectMax
+			if m.detectFailures <= 16 {
+				delay = min(adapterDetectMin<<(m.detectFailures-1), adapterDetectMax)
+			}
+			m.nextDetect = time.Now().Add(delay)
+		}
+	}
+	if present {
+		m.detectFailures, m.nextDetect = 0, time.Time{}
+	}
+
+	known, wasPresent := m.adapterKnown, m.adapterPresent
+	m.adapterKnown = true
+	switch {
+	case present && (!known || m.adapterPresent):
+		// 网卡一直存在
+	case present:
+		downtime := time.Since(m.adapterLostSince)
+		log.Printf("WiFi网卡已恢复: %s", interfaceName)
+		m.record(HistoryEvent{Type: HistoryEventAdapter, Interface: interfaceName, From: m.adapterName, To: interfaceName, DurationMs: downtime.Milliseconds()})
+		if enableNotification && feishuNotifier != nil {
+			feishuNotifier.SendAdapterRestoredNotificationAsync(interfaceName, downtime)
+		}
+	case known && m.adapterPresent:
+		log.Printf("WiFi网卡已移除: %s，等待重新插入", interfaceName)
+		m.adapterLostSince = time.Now()
+		m.record(HistoryEvent{Type: HistoryEventAdapter, Interface: interfaceName, From: interfaceName})
+		if enableNotification && feishuNotifier != nil {
+			feishuNotifier.SendAdapterLostNotificationAsync(interfaceName)
+		}
+	case !known:
+		// 启动时网卡不存在只等待，不发送通知
+		m.adapterLostSince = time.Now()
+	}
+	m.adapterPresent = present
+	if !present {
+		m.adapterName = interfaceName
+		err := fmt.Errorf("未检测到WiFi网卡，等待网卡插入")
+		if interfaceName != "" {
+			err = fmt.Errorf("未检测到WiFi网卡 %s，等待网卡插入", interfaceName)
+		}
+		// 等待网卡插入期间只在开始等待时记录一次日志
+		if !known || wasPresent {
+			log.Printf("%v", err)
+		}
+		m.status.UpdateNetwork(interfaceName, "")
+		m.status.SetError(err)
+	}
+	return present
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCheckAdapterDetectBackoff(t *testing.T) {
+	interfaces, err := net.Interfaces()
+	if err != nil || len(interfaces) == 0 {
+		t.Skip("没有可用的网络接口")
+	}
+	monitor := NewMonitor(&LinuxConnector{interfaceName: "connect-missing0"}, []string{"Office"}, true)
+	calls := 0
+	var detected WiFiConnector
+	monitor.detect = func() (WiFiConnector, error) {
+		calls++
+		if detected == nil {
+			return nil, errors.New("未找到WiFi网卡")
+		}
+		return detected, nil
+	}
+
+	// 首次检测失败后在等待时间内不再检测
+	for i := 0; i < 3; i++ {
+		if monitor.checkAdapter() {
+			t.Fatalf("网卡不存在时应返回false")
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("重新检测%d次，期望1次", calls)
+	}
+	if wait := time.Until(monitor.nextDetect); wait <= 0 || wait > adapterDetectMin {
+		t.Fatalf("下次检测等待 %v，期望不超过 %v", wait, adapterDetectMin)
+	}
+
+	// 连续失败时等待时间翻倍
+	monitor.nextDetect = time.Time{}
+	monitor.checkAdapter()
+	if wait := time.Until(monitor.nextDetect); calls != 2 || wait <= adapterDetectMin || wait > 2*adapterDetectMin {
+		t.Fatalf("重新检测%d次，下次检测等待 %v", calls, wait)
+	}
+
+	// 检测到网卡后清除退避
+	detected = &LinuxConnector{interfaceName: interfaces[0].Name}
+	monitor.nextDetect = time.Time{}
+	if !monitor.checkAdapter() {
+		t.Fatalf("检测到网卡后应返回true")
+	}
+	if monitor.detectFailures != 0 || !monitor.nextDetect.IsZero() {
+		t.Fatalf("检测到网卡后应清除退避: 失败%d次，下次检测 %v", monitor.detectFailures, monitor.nextDetect)
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultConnectivityChecks 默认的连通性检测项
+const defaultConnectivityChecks = "http:http://connect.rom.miui.com/generate_204,http:http://www.gstatic.com/generate_204,dns:www.baidu.com,gateway"
+
+// ProbeType 连通性检测类型
+type ProbeType string
+
+const (
+	// ProbeHTTP 请求URL并期望返回204
+	ProbeHTTP ProbeType = "http"
+	// ProbeDNS 解析域名
+	ProbeDNS ProbeType = "dns"
+	// ProbeTCP 建立TCP连接
+	ProbeTCP ProbeType = "tcp"
+	// ProbeGateway ping默认网关
+	ProbeGateway ProbeType = "gateway"
+)
+
+// ConnectivityProbe 一项连通性检测
+type ConnectivityProbe struct {
+	Type   ProbeType `json:"type"`
+	Target string    `json:"target,omitempty"`
+}
+
+// String 返回检测项的配置形式
+func (p ConnectivityProbe) String() string {
+	if p.Target == "" {
+		return string(p.Type)
+	}
+	return string(p.Type) + ":" + p.Target
+}
+
+// ProbeResult 单项检测结果
+type ProbeResult struct {
+	Probe      string `json:"probe"`
+	Success    bool   `json:"success"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Location   string `json:"location,omitempty"`
+}
+
+// ConnectivityReport 一次连通性检测的结果
+type ConnectivityReport struct {
+	Time    time.Time     `json:"time"`
+	Online  bool          `json:"online"`
+	Results []ProbeResult `json:"results"`
+	// CaptivePortal HTTP检测被重定向或返回了页面内容，说明被认证页面拦截
+	CaptivePortal bool `json:"captive_portal,omitempty"`
+	// PortalURL 检测到的认证页面地址
+	PortalURL string `json:"portal_url,omitempty"`
+}
+
+// FailureSummary 返回失败检测项的简要说明
+func (r *ConnectivityReport) FailureSummary() string {
+	var failures []string
+	for _, result := range r.Results {
+		if !result.Success {
+			failures = append(failures, fmt.Sprintf("%s（%s）", result.Probe, result.Error))
+		}
+	}
+	if r.CaptivePortal {
+		failures = append(failures, "检测到认证页面："+r.PortalURL)
+	}
+	return strings.Join(failures, "\n")
+}
+
+// ParseConnectivityProbes 解析检测项配置，格式: http:URL,dns:域名,tcp:主机:端口,gateway
+func ParseConnectivityProbes(spec string) ([]ConnectivityProbe, error) {
+	var probes []ConnectivityProbe
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		probeType, target, _ := strings.Cut(item, ":")
+		probe := ConnectivityProbe{Type: ProbeType(probeType), Target: target}
+		switch probe.Type {
+		case ProbeHTTP, ProbeDNS:
+			if target == "" {
+				return nil, fmt.Errorf("检测项缺少目标: %s", item)
+			}
+		case ProbeTCP:
+			if _, _, err := net.SplitHostPort(target); err != nil {
+				return nil, fmt.Errorf("TCP检测项格式应为 tcp:主机:端口: %s", item)
+			}
+		case ProbeGateway:
+		default:
+			return nil, fmt.Errorf("不支持的检测类型: %s", item)
+		}
+		probes = append(probes, probe)
+	}
+	if len(probes) == 0 {
+		return nil, fmt.Errorf("未配置任何检测项")
+	}
+	return probes, nil
+}
+
+// ConnectivityChecker 互联网连通性检测器
+type ConnectivityChecker struct {
+	probes     []ConnectivityProbe
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+// NewConnectivityChecker 创建新的连通性检测器
+func NewConnectivityChecker(probes []ConnectivityProbe, timeout time.Duration) *ConnectivityChecker {
+	return &ConnectivityChecker{
+		probes:  probes,
+		timeout: timeout,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			// 不跟随重定向，重定向通常意味着被认证页面拦截
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Check 并发执行所有检测项。除网关外的任一检测成功即认为互联网可用；只配置了网关检测时以网关检测为准
+func (c *ConnectivityChecker) Check(gateway string) *ConnectivityReport {
+	report := &ConnectivityReport{Time: time.Now(), Results: make([]ProbeResult, len(c.probes))}
+
+	var wg sync.WaitGroup
+	for i, probe := range c.probes {
+		wg.Add(1)
+		go func(i int, probe ConnectivityProbe) {
+			defer wg.Done()
+			report.Results[i] = c.runProbe(probe, gateway)
+		}(i, probe)
+	}
+	wg.Wait()
+
+	hasInternetProbe := false
+	for i, probe := range c.probes {
+		if probe.Type != ProbeGateway {
+			hasInternetProbe = true
+			if report.Results[i].Success {
+				report.Online = true
+			}
+		}
+	}
+	if !hasInternetProbe {
+		for _, result := range report.Results {
+			if result.Success {
+				report.Online = true
+			}
+		}
+	}
+
+	// HTTP检测被重定向或返回了页面内容时，说明被认证页面拦截
+	if !report.Online {
+		for i, probe := range c.probes {
+			if probe.Type == ProbeHTTP {
+				if portalURL := detectPortalURL(probe.Target, report.Results[i]); portalURL != "" {
+					report.CaptivePortal = true
+					report.PortalURL = portalURL
+					break
+				}
+			}
+		}
+	}
+	return report
+}
+
+// detectPortalURL 根据HTTP检测结果判断是否被认证页面拦截，返回认证页面地址
+func detectPortalURL(probeURL string, result ProbeResult) string {
+	switch {
+	case result.StatusCode >= 300 && result.StatusCode < 400 && result.Location != "":
+		base, err := url.Parse(probeURL)
+		if err != nil {
+			return result.Location
+		}
+		location, err := url.Parse(result.Location)
+		if err != nil {
+			return result.Location
+		}
+		return base.ResolveReference(location).String()
+	case result.StatusCode >= 200 && result.StatusCode < 300 && result.StatusCode != http.StatusNoContent:
+		// 认证页面直接劫持了请求并返回登录页
+		return probeURL
+	default:
+		return ""
+	}
+}
+
+// runProbe 执行单项检测
+func (c *ConnectivityChecker) runProbe(probe ConnectivityProbe, gateway string) ProbeResult {
+	result := ProbeResult{Probe: probe.String()}
+	start := time.Now()
+
+	var err error
+	switch probe.Type {
+	case ProbeHTTP:
+		result.StatusCode, result.Location, err = c.probeHTTP(probe.Target)
+	case ProbeDNS:
+		err = c.probeDNS(probe.Target)
+	case ProbeTCP:
+		err = c.probeTCP(probe.Target)
+	case ProbeGateway:
+		result.Probe = "gateway:" + gateway
+		err = c.probeGateway(gateway)
+	}
+
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Success = true
+	}
+	return result
+}
+
+// probeHTTP 请求URL，只有返回204才算成功
+func (c *ConnectivityChecker) probeHTTP(url string) (int, string, error) {
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return 0, "", fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	if resp.StatusCode != http.StatusNoContent {
+		return resp.StatusCode, location, fmt.Errorf("期望状态码204，实际为%d", resp.StatusCode)
+	}
+	return resp.StatusCode, location, nil
+}
+
+// probeDNS 解析域名
+func (c *ConnectivityChecker) probeDNS(host string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return fmt.Errorf("域名解析失败: %v", err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("域名解析无结果")
+	}
+	return nil
+}
+
+// probeTCP 建立TCP连接
+func (c *ConnectivityChecker) probeTCP(address string) error {
+	conn, err := net.DialTimeout("tcp", address, c.timeout)
+	if err != nil {
+		return fmt.Errorf("TCP连接失败: %v", err)
+	}
+	conn.Close()
+	return nil
+}
+
+// probeGateway ping默认网关
+func (c *ConnectivityChecker) probeGateway(gateway string) error {
+	if gateway == "" {
+		return fmt.Errorf("未获取到默认网关")
+	}
+	if _, err := pingHost(gateway, c.timeout); err != nil {
+		return fmt.Errorf("ping网关失败: %v", err)
+	}
+	return nil
+}
+
+// pingRTTPattern 匹配ping输出中的往返时间，兼容 time=1.23 ms、time<1ms 和中文系统的 时间=1ms
+var pingRTTPattern = regexp.MustCompile(`(?:time|时间)[=<]\s*([0-9.]+)\s*ms`)
+
+// pingHost 使用系统ping命令探测主机一次，返回往返时间
+func pingHost(host string, timeout time.Duration) (time.Duration, error) {
+	seconds := int(timeout.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("ping", "-n", "1", "-w", fmt.Sprint(seconds*1000), host)
+	case "darwin":
+		cmd = exec.Command("ping", "-c", "1", "-t", fmt.Sprint(seconds), host)
+	default:
+		cmd = exec.Command("ping", "-c", "1", "-W", fmt.Sprint(seconds), host)
+	}
+
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	elapsed := time.Since(start)
+	if err != nil {
+		return 0, fmt.Errorf("无响应: %v", err)
+	}
+	// Windows在目标不可达时也可能返回0，需要检查是否收到了回复
+	if runtime.GOOS == "windows" && !strings.Contains(strings.ToUpper(string(output)), "TTL=") {
+		return 0, fmt.Errorf("无响应")
+	}
+	// 无法解析往返时间时退化为命令执行耗时
+	if match := pingRTTPattern.FindStringSubmatch(string(output)); match != nil {
+		if ms, err := strconv.ParseFloat(match[1], 64); err == nil {
+			return time.Duration(ms * float64(time.Millisecond)), nil
+		}
+	}
+	return elapsed, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 )
@@ -12,25 +13,112 @@ type WiFiConnector interface {
 	// GetCurrentNetwork 获取当前连接的WiFi网络名称
 	GetCurrentNetwork() (string, error)
 	// Connect 连接到指定的WiFi网络
-	Connect(networkName, password string) error
+	Connect(networkName, password string, options ConnectOptions) error
 	// IsEnabled 检查WiFi是否已启用
 	IsEnabled() bool
 	// Enable 启用WiFi
 	Enable() error
 	// GetIPAddress 获取当前WiFi接口的IP地址
 	GetIPAddress() (string, error)
+	// GetIPv6Addresses 获取当前WiFi接口的IPv6全局地址和ULA地址
+	GetIPv6Addresses(options IPv6Options) ([]string, error)
+	// GetNetworkInfo 获取当前WiFi连接的详细网络信息（子网、网关、DNS、MAC、BSSID、信道、信号强度）
+	GetNetworkInfo() (*NetworkInfo, error)
+	// Scan 扫描附近可见的WiFi网络，每个接入点（BSSID）一条结果
+	Scan() ([]ScanResult, error)
+	// ConnectBSSID 连接到指定网络的指定接入点，平台不支持时返回ErrBSSIDUnsupported
+	ConnectBSSID(networkName, bssid, password string) error
+	// ApplyIPConfig 为当前连接的网络应用IP地址和DNS配置
+	ApplyIPConfig(config *IPConfig) error
+	// RenewDHCP 重新向DHCP服务器申请IPv4地址租约
+	RenewDHCP() error
+	// GetWiredInterfaces 获取已连接并获取到IPv4地址的有线网络接口
+	GetWiredInterfaces() ([]WiredInterface, error)
+	// GetDefaultRoute 获取承载IPv4默认路由的接口和网关
+	GetDefaultRoute() (string, string, error)
 }
 
+// ConnectOptions 连接WiFi网络时的附加选项
+type ConnectOptions struct {
+	// Hidden 网络不广播SSID，需要主动探测连接
+	Hidden bool
+	// Security 网络的安全类型，为SecurityAuto时由系统自动判断
+	Security SecurityType
+	// Enterprise 企业级（802.1X）认证凭据，为nil时按个人网络连接
+	Enterprise *EnterpriseCredentials
+	// Detected 根据扫描结果判断出的安全类型，只在需要新建配置时参考，不会改写系统中已保存的连接配置
+	Detected SecurityType
+}
+
+// ProfileSecurity 返回新建连接配置使用的安全类型：指定了安全类型时使用指定的类型，否则使用扫描结果判断出的类型
+func (o ConnectOptions) ProfileSecurity() SecurityType {
+	if o.Security != SecurityAuto {
+		return o.Security
+	}
+	return o.Detected
+}
+
+// ErrBSSIDUnsupported 当前平台不支持连接到指定接入点
+var ErrBSSIDUnsupported = errors.New("当前平台不支持连接到指定BSSID")
+
 // NewWiFiConnector 根据操作系统创建对应的WiFi连接器
 func NewWiFiConnector() (WiFiConnector, error) {
+	if wifiInterface != "" {
+		// 通过 -iface 指定了网卡时不自动检测
+		if !interfacePresent(wifiInterface) {
+			return nil, fmt.Errorf("未找到WiFi网络接口 %s", wifiInterface)
+		}
+		return NewWiFiConnectorForInterface(wifiInterface)
+	}
 	switch runtime.GOOS {
 	case "darwin": // macOS
 		return NewMacOSConnector()
 	case "windows":
 		return NewWindowsConnector()
 	case "linux":
-		return NewLinuxConnector()
+		connector, err := NewLinuxConnector()
+		if err != nil {
+			return nil, err
+		}
+		return newLinuxBackend(connector), nil
+	default:
+		return nil, fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+	}
+}
+
+// NewWiFiConnectorForInterface 根据操作系统为指定的WiFi接口创建连接器
+func NewWiFiConnectorForInterface(interfaceName string) (WiFiConnector, error) {
+	switch runtime.GOOS {
+	case "darwin": // macOS
+		return &MacOSConnector{interfaceName: interfaceName}, nil
+	case "windows":
+		return &WindowsConnector{interfaceName: interfaceName}, nil
+	case "linux":
+		return newLinuxBackend(&LinuxConnector{interfaceName: interfaceName}), nil
+	default:
+		return nil, fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+	}
+}
+
+// ListWiFiInterfaces 列出本机全部WiFi接口
+func ListWiFiInterfaces() ([]string, error) {
+	var interfaces []string
+	var err error
+	switch runtime.GOOS {
+	case "darwin": // macOS
+		interfaces, err = listMacOSWiFiInterfaces()
+	case "windows":
+		interfaces, err = listWindowsWiFiInterfaces()
+	case "linux":
+		interfaces, err = listLinuxWiFiInterfaces()
 	default:
 		return nil, fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
 	}
-}
\ No newline at end of file
+	if err != nil {
+		return nil, err
+	}
+	if len(interfaces) == 0 {
+		return nil, fmt.Errorf("未找到WiFi网络接口")
+	}
+	return interfaces, nil
+}
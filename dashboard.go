@@ -0,0 +1,159 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// DashboardStatus 状态面板接口返回的数据
+type DashboardStatus struct {
+	StatusSnapshot
+	Version     string             `json:"version"`
+	Now         time.Time          `json:"now"`
+	Notifiers   []NotifierHealth   `json:"notifiers"`
+	WAN         *WANStatus         `json:"wan,omitempty"`
+	LinkQuality *LinkQualityStatus `json:"link_quality,omitempty"`
+	// Adapters 管理多个WiFi网卡时其他网卡的运行状态
+	Adapters []StatusSnapshot `json:"adapters,omitempty"`
+}
+
+// DashboardServer 内置的Web状态面板
+type DashboardServer struct {
+	// monitors 全部WiFi网卡的监控器
+	monitors *MonitorGroup
+	mux      *http.ServeMux
+}
+
+// NewDashboardServer 创建新的状态面板
+func NewDashboardServer(monitors *MonitorGroup) *DashboardServer {
+	d := &DashboardServer{
+		monitors: monitors,
+		mux:      http.NewServeMux(),
+	}
+
+	static, _ := fs.Sub(dashboardFiles, "dashboard")
+	d.mux.Handle("/", http.FileServer(http.FS(static)))
+	d.mux.HandleFunc("/api/status", d.handleStatus)
+	d.mux.HandleFunc("/api/reconnect", d.handleReconnect)
+	d.mux.HandleFunc("/api/test-notification", d.handleTestNotification)
+	return d
+}
+
+// ServeHTTP 实现http.Handler接口
+func (d *DashboardServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe 在指定地址启动状态面板
+func (d *DashboardServer) ListenAndServe(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           d,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
+
+// handleStatus 返回当前运行状态
+func (d *DashboardServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	// 管理全部WiFi网卡且尚未检测到网卡时没有主监控器
+	monitors := d.monitors.Monitors()
+	var snapshot StatusSnapshot
+	if len(monitors) > 0 {
+		snapshot = monitors[0].Status().Snapshot()
+		monitors = monitors[1:]
+	}
+	status := DashboardStatus{
+		StatusSnapshot: snapshot,
+		Version:        version,
+		Now:            time.Now(),
+		Notifiers:      []NotifierHealth{},
+	}
+	if feishuNotifier != nil {
+		status.Notifiers = append(status.Notifiers, feishuNotifier.Health())
+	}
+	if wanDetector != nil {
+		wan := wanDetector.Status()
+		status.WAN = &wan
+	}
+	if linkQualityMonitor != nil {
+		linkQuality := linkQualityMonitor.Status()
+		status.LinkQuality = &linkQuality
+	}
+	for _, m := range monitors {
+		status.Adapters = append(status.Adapters, m.Status().Snapshot())
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// checkPostRequest 检查修改状态的请求：只接受POST，并拒绝来自其他网站的跨站请求，
+// 避免浏览器中打开的任意网页向本机面板发起重新连接或发送通知。
+// 不带Origin和Sec-Fetch-Site的请求（如curl）不是由浏览器中的网页发起的，允许访问
+func checkPostRequest(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "仅支持POST请求"})
+		return false
+	}
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		log.Printf("拒绝状态面板的跨站请求: %s %s (Sec-Fetch-Site: %s)", r.Method, r.URL.Path, site)
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "不允许跨站请求"})
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			log.Printf("拒绝状态面板的跨站请求: %s %s (Origin: %s)", r.Method, r.URL.Path, origin)
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "不允许跨站请求"})
+			return false
+		}
+	}
+	return true
+}
+
+// handleReconnect 触发一次重新连接
+func (d *DashboardServer) handleReconnect(w http.ResponseWriter, r *http.Request) {
+	if !checkPostRequest(w, r) {
+		return
+	}
+	log.Printf("状态面板请求重新连接")
+	for _, m := range d.monitors.Monitors() {
+		m.RequestReconnect()
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"message": "已请求重新连接"})
+}
+
+// handleTestNotification 发送一条测试通知
+func (d *DashboardServer) handleTestNotification(w http.ResponseWriter, r *http.Request) {
+	if !checkPostRequest(w, r) {
+		return
+	}
+	if feishuNotifier == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "通知功能未启用"})
+		return
+	}
+	var snapshot StatusSnapshot
+	if primary := d.monitors.Primary(); primary != nil {
+		snapshot = primary.Status().Snapshot()
+	}
+	if err := feishuNotifier.SendTestNotification(snapshot.CurrentNetwork, snapshot.IPAddress); err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "测试通知已发送"})
+}
+
+// writeJSON 以JSON格式写入响应
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("写入响应失败: %v", err)
+	}
+}
@@ -0,0 +1,951 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// D-Bus消息类型和头部字段
+const (
+	dbusMethodCall   = 1
+	dbusMethodReturn = 2
+	dbusError        = 3
+	dbusSignal       = 4
+
+	dbusFlagNoReplyExpected = 0x1
+
+	dbusFieldPath        = 1
+	dbusFieldInterface   = 2
+	dbusFieldMember      = 3
+	dbusFieldErrorName   = 4
+	dbusFieldReplySerial = 5
+	dbusFieldDestination = 6
+	dbusFieldSender      = 7
+	dbusFieldSignature   = 8
+
+	// dbusDefaultSystemBus 未设置 DBUS_SYSTEM_BUS_ADDRESS 时使用的系统总线地址
+	dbusDefaultSystemBus = "unix:path=/var/run/dbus/system_bus_socket"
+	// dbusCallTimeout 方法调用的默认超时时间
+	dbusCallTimeout = 10 * time.Second
+)
+
+// DBusObjectPath D-Bus对象路径
+type DBusObjectPath string
+
+// DBusVariant D-Bus变体值，编码时按Signature编码Value；解码时变体直接解码为其中的值
+type DBusVariant struct {
+	Signature string
+	Value     any
+}
+
+// DBusError D-Bus方法调用返回的错误
+type DBusError struct {
+	Name    string
+	Message string
+}
+
+// Error 实现error接口
+func (e *DBusError) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// DBusHandler 处理导出对象收到的方法调用，sender为调用方的唯一名称，返回回复的签名和参数，返回*DBusError时回复对应的错误
+type DBusHandler func(sender, member string, args []any) (string, []any, error)
+
+// dbusMessage 一条D-Bus消息
+type dbusMessage struct {
+	Type        byte
+	Flags       byte
+	Serial      uint32
+	Path        DBusObjectPath
+	Interface   string
+	Member      string
+	ErrorName   string
+	ReplySerial uint32
+	Destination string
+	Sender      string
+	Signature   string
+	Body        []any
+	// body 消息体的原始数据，order 为其字节序，用于按其他方式重新解码
+	body  []byte
+	order binary.ByteOrder
+}
+
+// DBusConn 一个D-Bus总线连接，支持调用方法和导出对象
+type DBusConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	// name 总线分配的唯一名称
+	name       string
+	writeMutex sync.Mutex
+	mutex      sync.Mutex
+	serial     uint32
+	pending    map[uint32]chan *dbusMessage
+	handlers   map[DBusObjectPath]DBusHandler
+	closed     bool
+}
+
+// systemBus 共享的系统总线连接，断开后重新连接
+var systemBus struct {
+	sync.Mutex
+	conn *DBusConn
+}
+
+// SystemBus 返回系统总线连接，地址可通过 DBUS_SYSTEM_BUS_ADDRESS 环境变量指定
+func SystemBus() (*DBusConn, error) {
+	systemBus.Lock()
+	defer systemBus.Unlock()
+	if systemBus.conn != nil && !systemBus.conn.Closed() {
+		return systemBus.conn, nil
+	}
+	address := os.Getenv("DBUS_SYSTEM_BUS_ADDRESS")
+	if address == "" {
+		address = dbusDefaultSystemBus
+	}
+	conn, err := DialDBus(address)
+	if err != nil {
+		return nil, err
+	}
+	systemBus.conn = conn
+	return conn, nil
+}
+
+// DialDBus 连接到指定地址的总线，完成认证并注册唯一名称
+func DialDBus(address string) (*DBusConn, error) {
+	var lastErr error
+	for _, addr := range strings.Split(address, ";") {
+		network, target, err := parseDBusAddress(addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn, err := net.DialTimeout(network, target, dbusCallTimeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		c := &DBusConn{
+			conn:     conn,
+			reader:   bufio.NewReader(conn),
+			pending:  make(map[uint32]chan *dbusMessage),
+			handlers: make(map[DBusObjectPath]DBusHandler),
+		}
+		if err := c.auth(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("D-Bus认证失败: %v", err)
+		}
+		go c.readLoop()
+		reply, err := c.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus.Hello", "")
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("注册D-Bus名称失败: %v", err)
+		}
+		if len(reply) > 0 {
+			c.name, _ = reply[0].(string)
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("连接D-Bus失败: %v", lastErr)
+}
+
+// parseDBusAddress 解析 unix:path=... 或 unix:abstract=... 形式的总线地址
+func parseDBusAddress(address string) (string, string, error) {
+	transport, params, ok := strings.Cut(address, ":")
+	if !ok || transport != "unix" {
+		return "", "", fmt.Errorf("不支持的D-Bus地址: %s", address)
+	}
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(param, "=")
+		value = dbusUnescape(value)
+		switch key {
+		case "path":
+			return "unix", value, nil
+		case "abstract":
+			// Go使用@前缀表示抽象命名空间的Unix套接字
+			return "unix", "@" + value, nil
+		}
+	}
+	return "", "", fmt.Errorf("不支持的D-Bus地址: %s", address)
+}
+
+// dbusUnescape 还原地址中%xx形式转义的字符
+func dbusUnescape(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '%' && i+2 < len(value) {
+			if n, err := strconv.ParseUint(value[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+// auth 使用EXTERNAL机制（按进程的用户ID）认证
+func (c *DBusConn) auth() error {
+	c.conn.SetDeadline(time.Now().Add(dbusCallTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := c.conn.Write([]byte("\x00AUTH EXTERNAL " + uid + "\r\n")); err != nil {
+		return err
+	}
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("总线拒绝认证: %s", strings.TrimSpace(line))
+	}
+	_, err = c.conn.Write([]byte("BEGIN\r\n"))
+	return err
+}
+
+// Closed 返回连接是否已断开
+func (c *DBusConn) Closed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.closed
+}
+
+// Close 关闭连接
+func (c *DBusConn) Close() error {
+	return c.conn.Close()
+}
+
+// Export 导出对象，总线上对该路径的方法调用交给handler处理
+func (c *DBusConn) Export(path DBusObjectPath, handler DBusHandler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handlers[path] = handler
+}
+
+// Call 调用方法，method为 接口.方法 形式，signature为参数的类型签名
+func (c *DBusConn) Call(destination string, path DBusObjectPath, method, signature string, args ...any) ([]any, error) {
+	return c.CallWithTimeout(dbusCallTimeout, destination, path, method, signature, args...)
+}
+
+// CallWithTimeout 按指定超时时间调用方法，用于连接网络等耗时较长的调用
+func (c *DBusConn) CallWithTimeout(timeout time.Duration, destination string, path DBusObjectPath, method, signature string, args ...any) ([]any, error) {
+	reply, err := c.call(timeout, destination, path, method, signature, args...)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Body, nil
+}
+
+// CallVariants 调用方法，回复中的变体保留签名解码为DBusVariant，便于修改后原样发回
+func (c *DBusConn) CallVariants(destination string, path DBusObjectPath, method, signature string, args ...any) ([]any, error) {
+	reply, err := c.call(dbusCallTimeout, destination, path, method, signature, args...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDBusBody(&dbusDecoder{buf: reply.body, order: reply.order, variants: true}, reply.Signature)
+}
+
+// call 发送方法调用并等待回复，错误回复转换为*DBusError
+func (c *DBusConn) call(timeout time.Duration, destination string, path DBusObjectPath, method, signature string, args ...any) (*dbusMessage, error) {
+	dot := strings.LastIndex(method, ".")
+	if dot < 0 {
+		return nil, fmt.Errorf("方法名称应为 接口.方法: %s", method)
+	}
+	msg := &dbusMessage{
+		Type:        dbusMethodCall,
+		Path:        path,
+		Interface:   method[:dot],
+		Member:      method[dot+1:],
+		Destination: destination,
+		Signature:   signature,
+		Body:        args,
+	}
+
+	reply := make(chan *dbusMessage, 1)
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return nil, fmt.Errorf("D-Bus连接已断开")
+	}
+	c.serial++
+	msg.Serial = c.serial
+	c.pending[msg.Serial] = reply
+	c.mutex.Unlock()
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, msg.Serial)
+		c.mutex.Unlock()
+	}()
+
+	if err := c.send(msg); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result, ok := <-reply:
+		if !ok {
+			return nil, fmt.Errorf("D-Bus连接已断开")
+		}
+		if result.Type == dbusError {
+			err := &DBusError{Name: result.ErrorName}
+			if len(result.Body) > 0 {
+				err.Message, _ = result.Body[0].(string)
+			}
+			return nil, err
+		}
+		return result, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("调用%s超时", method)
+	}
+}
+
+// GetProperty 读取对象的属性
+func (c *DBusConn) GetProperty(destination string, path DBusObjectPath, iface, name string) (any, error) {
+	reply, err := c.Call(destination, path, "org.freedesktop.DBus.Properties.Get", "ss", iface, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) == 0 {
+		return nil, fmt.Errorf("属性%s为空", name)
+	}
+	return reply[0], nil
+}
+
+// GetAllProperties 读取对象在指定接口上的全部属性
+func (c *DBusConn) GetAllProperties(destination string, path DBusObjectPath, iface string) (map[string]any, error) {
+	reply, err := c.Call(destination, path, "org.freedesktop.DBus.Properties.GetAll", "s", iface)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) == 0 {
+		return nil, fmt.Errorf("读取%s属性失败", iface)
+	}
+	properties, _ := reply[0].(map[string]any)
+	return properties, nil
+}
+
+// NameHasOwner 检查总线上是否有服务占用指定名称
+func (c *DBusConn) NameHasOwner(name string) bool {
+	reply, err := c.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus.NameHasOwner", "s", name)
+	if err != nil || len(reply) == 0 {
+		return false
+	}
+	owned, _ := reply[0].(bool)
+	return owned
+}
+
+// GetNameOwner 查询占用指定名称的服务的唯一名称
+func (c *DBusConn) GetNameOwner(name string) (string, error) {
+	reply, err := c.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus.GetNameOwner", "s", name)
+	if err != nil {
+		return "", err
+	}
+	if len(reply) == 0 {
+		return "", fmt.Errorf("%s没有所有者", name)
+	}
+	owner, _ := reply[0].(string)
+	return owner, nil
+}
+
+// send 编码并发送一条消息
+func (c *DBusConn) send(msg *dbusMessage) error {
+	if msg.Sender == "" {
+		// 经过总线时由总线填写发送者，两个连接直接通信时由本连接填写
+		msg.Sender = c.name
+	}
+	data, err := encodeDBusMessage(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if _, err := c.conn.Write(data); err != nil {
+		return fmt.Errorf("发送D-Bus消息失败: %v", err)
+	}
+	return nil
+}
+
+// readLoop 读取消息，方法回复交给等待的调用，方法调用交给导出对象处理，连接断开时退出
+func (c *DBusConn) readLoop() {
+	defer func() {
+		c.mutex.Lock()
+		c.closed = true
+		for serial, reply := range c.pending {
+			close(reply)
+			delete(c.pending, serial)
+		}
+		c.mutex.Unlock()
+		c.conn.Close()
+	}()
+	for {
+		msg, err := readDBusMessage(c.reader)
+		if err != nil {
+			return
+		}
+		switch msg.Type {
+		case dbusMethodReturn, dbusError:
+			c.mutex.Lock()
+			if reply, ok := c.pending[msg.ReplySerial]; ok {
+				select {
+				case reply <- msg:
+				default:
+				}
+			}
+			c.mutex.Unlock()
+		case dbusMethodCall:
+			go c.handleCall(msg)
+		}
+	}
+}
+
+// handleCall 处理总线上其他程序对导出对象的方法调用
+func (c *DBusConn) handleCall(msg *dbusMessage) {
+	c.mutex.Lock()
+	handler := c.handlers[msg.Path]
+	c.serial++
+	reply := &dbusMessage{Type: dbusMethodReturn, Serial: c.serial, ReplySerial: msg.Serial, Destination: msg.Sender}
+	c.mutex.Unlock()
+
+	var err error
+	if handler == nil {
+		err = &DBusError{Name: "org.freedesktop.DBus.Error.UnknownObject", Message: string(msg.Path)}
+	} else {
+		reply.Signature, reply.Body, err = handler(msg.Sender, msg.Member, msg.Body)
+	}
+	if err != nil {
+		dbusErr, ok := err.(*DBusError)
+		if !ok {
+			dbusErr = &DBusError{Name: "org.freedesktop.DBus.Error.Failed", Message: err.Error()}
+		}
+		reply.Type = dbusError
+		reply.ErrorName = dbusErr.Name
+		reply.Signature = "s"
+		reply.Body = []any{dbusErr.Message}
+	}
+	if msg.Flags&dbusFlagNoReplyExpected == 0 {
+		c.send(reply)
+	}
+}
+
+// encodeDBusMessage 按小端字节序编码消息
+func encodeDBusMessage(msg *dbusMessage) ([]byte, error) {
+	body := &dbusEncoder{}
+	if err := body.encodeAll(msg.Signature, msg.Body); err != nil {
+		return nil, err
+	}
+
+	var fields []any
+	addField := func(code byte, signature string, value any) {
+		fields = append(fields, []any{code, DBusVariant{Signature: signature, Value: value}})
+	}
+	if msg.Path != "" {
+		addField(dbusFieldPath, "o", msg.Path)
+	}
+	if msg.Interface != "" {
+		addField(dbusFieldInterface, "s", msg.Interface)
+	}
+	if msg.Member != "" {
+		addField(dbusFieldMember, "s", msg.Member)
+	}
+	if msg.ErrorName != "" {
+		addField(dbusFieldErrorName, "s", msg.ErrorName)
+	}
+	if msg.ReplySerial != 0 {
+		addField(dbusFieldReplySerial, "u", msg.ReplySerial)
+	}
+	if msg.Destination != "" {
+		addField(dbusFieldDestination, "s", msg.Destination)
+	}
+	if msg.Sender != "" {
+		addField(dbusFieldSender, "s", msg.Sender)
+	}
+	if msg.Signature != "" {
+		addField(dbusFieldSignature, "g", msg.Signature)
+	}
+
+	header := &dbusEncoder{buf: []byte{'l', msg.Type, msg.Flags, 1}}
+	header.encodeAll("uua(yv)", []any{uint32(len(body.buf)), msg.Serial, fields})
+	header.align(8)
+	return append(header.buf, body.buf...), nil
+}
+
+// readDBusMessage 读取并解码一条消息
+func readDBusMessage(r io.Reader) (*dbusMessage, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	switch fixed[0] {
+	case 'l':
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("无效的D-Bus消息字节序: %q", fixed[0])
+	}
+	bodyLen := int(order.Uint32(fixed[4:8]))
+	fieldsLen := int(order.Uint32(fixed[12:16]))
+	headerLen := (16 + fieldsLen + 7) &^ 7
+	if bodyLen > 1<<27 || fieldsLen > 1<<26 {
+		return nil, fmt.Errorf("D-Bus消息过长")
+	}
+	data := make([]byte, headerLen+bodyLen)
+	copy(data, fixed)
+	if _, err := io.ReadFull(r, data[16:]); err != nil {
+		return nil, err
+	}
+
+	msg := &dbusMessage{Type: fixed[1], Flags: fixed[2], Serial: order.Uint32(fixed[8:12])}
+	header := &dbusDecoder{buf: data[:16+fieldsLen], pos: 12, order: order}
+	fields, err := header.decode("a(yv)")
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields.([]any) {
+		pair := field.([]any)
+		code, _ := pair[0].(byte)
+		switch value := pair[1].(type) {
+		case string:
+			switch code {
+			case dbusFieldInterface:
+				msg.Interface = value
+			case dbusFieldMember:
+				msg.Member = value
+			case dbusFieldErrorName:
+				msg.ErrorName = value
+			case dbusFieldDestination:
+				msg.Destination = value
+			case dbusFieldSender:
+				msg.Sender = value
+			case dbusFieldSignature:
+				msg.Signature = value
+			}
+		case DBusObjectPath:
+			if code == dbusFieldPath {
+				msg.Path = value
+			}
+		case uint32:
+			if code == dbusFieldReplySerial {
+				msg.ReplySerial = value
+			}
+		}
+	}
+
+	msg.body, msg.order = data[headerLen:], order
+	if msg.Body, err = decodeDBusBody(&dbusDecoder{buf: msg.body, order: order}, msg.Signature); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// decodeDBusBody 按签名依次解码消息体中的参数
+func decodeDBusBody(d *dbusDecoder, signature string) ([]any, error) {
+	var values []any
+	for signature != "" {
+		single, rest, err := dbusNextType(signature)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decode(single)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		signature = rest
+	}
+	return values, nil
+}
+
+// dbusNextType 拆分出签名中的第一个完整类型
+func dbusNextType(signature string) (string, string, error) {
+	if signature == "" {
+		return "", "", fmt.Errorf("D-Bus签名为空")
+	}
+	switch signature[0] {
+	case 'a':
+		elem, rest, err := dbusNextType(signature[1:])
+		if err != nil {
+			return "", "", err
+		}
+		return "a" + elem, rest, nil
+	case '(', '{':
+		closing := byte(')')
+		if signature[0] == '{' {
+			closing = '}'
+		}
+		for rest := signature[1:]; rest != ""; {
+			if rest[0] == closing {
+				n := len(signature) - len(rest) + 1
+				return signature[:n], signature[n:], nil
+			}
+			var err error
+			if _, rest, err = dbusNextType(rest); err != nil {
+				return "", "", err
+			}
+		}
+		return "", "", fmt.Errorf("D-Bus签名不完整: %s", signature)
+	case 'y', 'b', 'n', 'q', 'i', 'u', 'x', 't', 'd', 's', 'o', 'g', 'v', 'h':
+		return signature[:1], signature[1:], nil
+	default:
+		return "", "", fmt.Errorf("不支持的D-Bus类型: %c", signature[0])
+	}
+}
+
+// dbusAlignment 返回类型的对齐字节数
+func dbusAlignment(t byte) int {
+	switch t {
+	case 'n', 'q':
+		return 2
+	case 'b', 'i', 'u', 's', 'o', 'a', 'h':
+		return 4
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	default:
+		return 1
+	}
+}
+
+// dbusEncoder 按小端字节序编码D-Bus值
+type dbusEncoder struct {
+	buf []byte
+}
+
+// align 填充到指定对齐
+func (e *dbusEncoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+// encodeAll 按签名依次编码多个值
+func (e *dbusEncoder) encodeAll(signature string, values []any) error {
+	for i := 0; signature != ""; i++ {
+		single, rest, err := dbusNextType(signature)
+		if err != nil {
+			return err
+		}
+		if i >= len(values) {
+			return fmt.Errorf("D-Bus参数数量与签名不符")
+		}
+		if err := e.encode(single, values[i]); err != nil {
+			return err
+		}
+		signature = rest
+	}
+	return nil
+}
+
+// encode 按单个完整类型编码一个值，数组接受切片，字典接受map，结构体接受[]any
+func (e *dbusEncoder) encode(signature string, value any) error {
+	e.align(dbusAlignment(signature[0]))
+	rv := reflect.ValueOf(value)
+	// 基本类型先检查参数的类型，避免写入错误的值或panic
+	n, isInt := dbusUint(rv)
+	var kindOK bool
+	switch signature[0] {
+	case 'y', 'n', 'q', 'i', 'u', 'h', 'x', 't':
+		kindOK = isInt
+	case 'b':
+		kindOK = rv.Kind() == reflect.Bool
+	case 'd':
+		kindOK = rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64
+	case 's', 'o', 'g':
+		kindOK = rv.Kind() == reflect.String
+	default:
+		kindOK = true
+	}
+	if !kindOK {
+		return fmt.Errorf("D-Bus参数类型与签名%c不符: %T", signature[0], value)
+	}
+	switch signature[0] {
+	case 'y':
+		e.buf = append(e.buf, byte(n))
+	case 'b':
+		var b uint32
+		if rv.Bool() {
+			b = 1
+		}
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, b)
+	case 'n', 'q':
+		e.buf = binary.LittleEndian.AppendUint16(e.buf, uint16(n))
+	case 'i', 'u', 'h':
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(n))
+	case 'x', 't':
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, n)
+	case 'd':
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(rv.Float()))
+	case 's', 'o':
+		s := rv.String()
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(len(s)))
+		e.buf = append(append(e.buf, s...), 0)
+	case 'g':
+		s := rv.String()
+		e.buf = append(append(append(e.buf, byte(len(s))), s...), 0)
+	case 'v':
+		variant, ok := value.(DBusVariant)
+		if !ok {
+			return fmt.Errorf("D-Bus变体参数应为DBusVariant: %T", value)
+		}
+		if err := e.encode("g", variant.Signature); err != nil {
+			return err
+		}
+		return e.encode(variant.Signature, variant.Value)
+	case 'a':
+		lengthPos := len(e.buf)
+		e.buf = append(e.buf, 0, 0, 0, 0)
+		elem := signature[1:]
+		// 即使数组为空也要填充到元素的对齐位置，填充不计入长度
+		e.align(dbusAlignment(elem[0]))
+		start := len(e.buf)
+		switch {
+		case elem[0] == '{':
+			if rv.Kind() != reflect.Map {
+				if value == nil {
+					break
+				}
+				return fmt.Errorf("D-Bus字典参数应为map: %T", value)
+			}
+			keySig, valueSig, err := dbusNextType(elem[1 : len(elem)-1])
+			if err != nil {
+				return err
+			}
+			keys := rv.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+			for _, key := range keys {
+				e.align(8)
+				if err := e.encode(keySig, key.Interface()); err != nil {
+					return err
+				}
+				if err := e.encode(valueSig, rv.MapIndex(key).Interface()); err != nil {
+					return err
+				}
+			}
+		case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				if err := e.encode(elem, rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+		case value != nil:
+			return fmt.Errorf("D-Bus数组参数应为切片: %T", value)
+		}
+		binary.LittleEndian.PutUint32(e.buf[lengthPos:], uint32(len(e.buf)-start))
+	case '(':
+		fields, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("D-Bus结构体参数应为[]any: %T", value)
+		}
+		return e.encodeAll(signature[1:len(signature)-1], fields)
+	}
+	return nil
+}
+
+// dbusUint 将任意整数值转换为uint64，不是整数时返回false
+func dbusUint(rv reflect.Value) (uint64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), true
+	default:
+		return 0, false
+	}
+}
+
+// dbusDecoder 解码D-Bus值。数组解码为[]any（字节数组为[]byte），字典解码为map[string]any，
+// 结构体解码为[]any，变体直接解码为其中的值（variants为true时解码为DBusVariant）
+type dbusDecoder struct {
+	buf      []byte
+	pos      int
+	order    binary.ByteOrder
+	variants bool
+}
+
+// read 对齐后读取n个字节
+func (d *dbusDecoder) read(alignment, n int) ([]byte, error) {
+	d.pos = (d.pos + alignment - 1) &^ (alignment - 1)
+	if d.pos+n > len(d.buf) || n < 0 {
+		return nil, fmt.Errorf("D-Bus消息不完整")
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// decode 按单个完整类型解码一个值
+func (d *dbusDecoder) decode(signature string) (any, error) {
+	switch signature[0] {
+	case 'y':
+		b, err := d.read(1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'b':
+		b, err := d.read(4, 4)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint32(b) != 0, nil
+	case 'n', 'q':
+		b, err := d.read(2, 2)
+		if err != nil {
+			return nil, err
+		}
+		if signature[0] == 'n' {
+			return int16(d.order.Uint16(b)), nil
+		}
+		return d.order.Uint16(b), nil
+	case 'i', 'u', 'h':
+		b, err := d.read(4, 4)
+		if err != nil {
+			return nil, err
+		}
+		if signature[0] == 'i' {
+			return int32(d.order.Uint32(b)), nil
+		}
+		return d.order.Uint32(b), nil
+	case 'x', 't', 'd':
+		b, err := d.read(8, 8)
+		if err != nil {
+			return nil, err
+		}
+		v := d.order.Uint64(b)
+		switch signature[0] {
+		case 'x':
+			return int64(v), nil
+		case 'd':
+			return math.Float64frombits(v), nil
+		}
+		return v, nil
+	case 's', 'o':
+		b, err := d.read(4, 4)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.read(1, int(d.order.Uint32(b))+1)
+		if err != nil {
+			return nil, err
+		}
+		if signature[0] == 'o' {
+			return DBusObjectPath(s[:len(s)-1]), nil
+		}
+		return string(s[:len(s)-1]), nil
+	case 'g':
+		b, err := d.read(1, 1)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.read(1, int(b[0])+1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'v':
+		sig, err := d.decode("g")
+		if err != nil {
+			return nil, err
+		}
+		single, rest, err := dbusNextType(sig.(string))
+		if err != nil || rest != "" {
+			return nil, fmt.Errorf("无效的D-Bus变体签名: %s", sig)
+		}
+		value, err := d.decode(single)
+		if err != nil || !d.variants {
+			return value, err
+		}
+		return DBusVariant{Signature: single, Value: value}, nil
+	case 'a':
+		b, err := d.read(4, 4)
+		if err != nil {
+			return nil, err
+		}
+		length := int(d.order.Uint32(b))
+		elem := signature[1:]
+		if _, err := d.read(dbusAlignment(elem[0]), 0); err != nil {
+			return nil, err
+		}
+		end := d.pos + length
+		if end > len(d.buf) {
+			return nil, fmt.Errorf("D-Bus消息不完整")
+		}
+		if elem == "y" {
+			return append([]byte(nil), d.buf[d.pos:end]...), d.skipTo(end)
+		}
+		if elem[0] == '{' {
+			keySig, valueSig, err := dbusNextType(elem[1 : len(elem)-1])
+			if err != nil {
+				return nil, err
+			}
+			dict := make(map[string]any)
+			for d.pos < end {
+				if _, err := d.read(8, 0); err != nil {
+					return nil, err
+				}
+				key, err := d.decode(keySig)
+				if err != nil {
+					return nil, err
+				}
+				value, err := d.decode(valueSig)
+				if err != nil {
+					return nil, err
+				}
+				dict[fmt.Sprint(key)] = value
+			}
+			return dict, nil
+		}
+		items := []any{}
+		for d.pos < end {
+			item, err := d.decode(elem)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case '(':
+		if _, err := d.read(8, 0); err != nil {
+			return nil, err
+		}
+		var fields []any
+		for inner := signature[1 : len(signature)-1]; inner != ""; {
+			single, rest, err := dbusNextType(inner)
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.decode(single)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, value)
+			inner = rest
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("不支持的D-Bus类型: %s", signature)
+}
+
+// skipTo 跳到指定位置
+func (d *dbusDecoder) skipTo(pos int) error {
+	if pos > len(d.buf) {
+		return fmt.Errorf("D-Bus消息不完整")
+	}
+	d.pos = pos
+	return nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// newTestDBusConn 在已建立的连接上创建D-Bus连接，不经过总线认证，用于两个连接直接通信
+func newTestDBusConn(t *testing.T, conn net.Conn) *DBusConn {
+	t.Helper()
+	c := &DBusConn{
+		conn:     conn,
+		reader:   bufio.NewReader(conn),
+		pending:  make(map[uint32]chan *dbusMessage),
+		handlers: make(map[DBusObjectPath]DBusHandler),
+	}
+	go c.readLoop()
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestDBusMessageRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		body      []any
+		want      []any
+	}{
+		{
+			name:      "基本类型",
+			signature: "ybnqiuxtd",
+			body:      []any{byte(1), true, int16(-2), uint16(3), int32(-4), uint32(5), int64(-6), uint64(7), 1.5},
+			want:      []any{byte(1), true, int16(-2), uint16(3), int32(-4), uint32(5), int64(-6), uint64(7), 1.5},
+		},
+		{
+			name:      "字符串、对象路径和签名",
+			signature: "sog",
+			body:      []any{"无线网络", DBusObjectPath("/net/connman/iwd/0/3"), "a{sv}"},
+			want:      []any{"无线网络", DBusObjectPath("/net/connman/iwd/0/3"), "a{sv}"},
+		},
+		{
+			name:      "变体和嵌套变体",
+			signature: "vvv",
+			body: []any{
+				DBusVariant{Signature: "s", Value: "connected"},
+				DBusVariant{Signature: "v", Value: DBusVariant{Signature: "u", Value: uint32(42)}},
+				DBusVariant{Signature: "ay", Value: []byte("Office")},
+			},
+			want: []any{"connected", uint32(42), []byte("Office")},
+		},
+		{
+			name:      "数组",
+			signature: "asaiay",
+			body:      []any{[]string{"a", "bc"}, []int32{1, -1}, []byte{}},
+			want:      []any{[]any{"a", "bc"}, []any{int32(1), int32(-1)}, []byte(nil)},
+		},
+		{
+			name:      "字节之后的8字节对齐数组和结构体",
+			signature: "yat(yt)a(ii)y",
+			body:      []any{byte(9), []uint64{1, 2}, []any{byte(3), uint64(4)}, []any{}, byte(5)},
+			want:      []any{byte(9), []any{uint64(1), uint64(2)}, []any{byte(3), uint64(4)}, []any{}, byte(5)},
+		},
+		{
+			name:      "a{sv}",
+			signature: "a{sv}",
+			body: []any{map[string]DBusVariant{
+				"SSID":     {Signature: "ay", Value: []byte("Office")},
+				"Strength": {Signature: "y", Value: byte(80)},
+				"Hidden":   {Signature: "b", Value: false},
+			}},
+			want: []any{map[string]any{"SSID": []byte("Office"), "Strength": byte(80), "Hidden": false}},
+		},
+		{
+			name:      "a{oa{sa{sv}}}",
+			signature: "a{oa{sa{sv}}}",
+			body: []any{map[DBusObjectPath]map[string]map[string]DBusVariant{
+				"/net/connman/iwd/0/3": {
+					"net.connman.iwd.Station": {
+						"State":            {Signature: "s", Value: "connected"},
+						"ConnectedNetwork": {Signature: "o", Value: DBusObjectPath("/net/connman/iwd/0/3/4f6666696365_psk")},
+					},
+					"net.connman.iwd.Device": {},
+				},
+				"/net/connman/iwd/0/3/4f6666696365_psk": {
+					"net.connman.iwd.Network": {
+						"Name":      {Signature: "s", Value: "Office"},
+						"Connected": {Signature: "b", Value: true},
+					},
+				},
+			}},
+			want: []any{map[string]any{
+				"/net/connman/iwd/0/3": map[string]any{
+					"net.connman.iwd.Station": map[string]any{
+						"State":            "connected",
+						"ConnectedNetwork": DBusObjectPath("/net/connman/iwd/0/3/4f6666696365_psk"),
+					},
+					"net.connman.iwd.Device": map[string]any{},
+				},
+				"/net/connman/iwd/0/3/4f6666696365_psk": map[string]any{
+					"net.connman.iwd.Network": map[string]any{"Name": "Office", "Connected": true},
+				},
+			}},
+		},
+		{
+			name:      "a{sa{sv}}和空字典",
+			signature: "a{sa{sv}}a{sv}",
+			body: []any{
+				map[string]map[string]DBusVariant{"ipv4": {"method": {Signature: "s", Value: "auto"}}},
+				nil,
+			},
+			want: []any{map[string]any{"ipv4": map[string]any{"method": "auto"}}, map[string]any{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &dbusMessage{
+				Type:        dbusMethodCall,
+				Serial:      7,
+				Path:        "/org/freedesktop/NetworkManager",
+				Interface:   "org.freedesktop.NetworkManager",
+				Member:      "Test",
+				Destination: "org.freedesktop.NetworkManager",
+				Signature:   tt.signature,
+				Body:        tt.body,
+			}
+			data, err := encodeDBusMessage(msg)
+			if err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+			got, err := readDBusMessage(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("解码失败: %v", err)
+			}
+			if got.Serial != msg.Serial || got.Path != msg.Path || got.Interface != msg.Interface ||
+				got.Member != msg.Member || got.Destination != msg.Destination || got.Signature != msg.Signature {
+				t.Fatalf("消息头 = %+v\n期望 %+v", got, msg)
+			}
+			if !reflect.DeepEqual(got.Body, tt.want) {
+				t.Fatalf("消息体 = %#v\n期望 %#v", got.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestDBusEncodeAlignment(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		values    []any
+		want      string
+	}{
+		{"16位整数对齐到2字节", "yq", []any{byte(1), uint16(2)}, "01000200"},
+		{"64位整数对齐到8字节", "yt", []any{byte(1), uint64(2)}, "01000000000000000200000000000000"},
+		{"空数组也填充到元素对齐位置", "a(y)", []any{[]any{}}, "0000000000000000"},
+		{"字典项对齐到8字节且填充不计入长度", "ya{yy}", []any{byte(1), map[byte]byte{2: 3}},
+			"01000000" + "02000000" + "0203"},
+		{"变体中的值按自身类型对齐", "yv", []any{byte(1), DBusVariant{Signature: "u", Value: uint32(5)}},
+			"01" + "0175" + "00" + "05000000"},
+		{"字符串以空字符结尾", "s", []any{"ab"}, "02000000" + "616200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &dbusEncoder{}
+			if err := e.encodeAll(tt.signature, tt.values); err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+			if got := hex.EncodeToString(e.buf); got != tt.want {
+				t.Fatalf("编码结果 = %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDBusEncodeErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		values    []any
+	}{
+		{"参数数量不足", "ss", []any{"a"}},
+		{"变体不是DBusVariant", "v", []any{"a"}},
+		{"字典不是map", "a{sv}", []any{[]string{"a"}}},
+		{"结构体不是[]any", "(ss)", []any{"a"}},
+		{"签名不完整", "a{sv", []any{map[string]DBusVariant{}}},
+		{"整数参数不是整数", "u", []any{"1"}},
+		{"布尔参数不是bool", "b", []any{1}},
+		{"浮点参数不是浮点数", "d", []any{int32(1)}},
+		{"字符串参数不是string", "s", []any{[]byte("a")}},
+		{"对象路径参数不是string", "o", []any{nil}},
+		{"字典值类型与签名不符", "a{sv}", []any{map[string]string{"a": "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &dbusEncoder{}
+			if err := e.encodeAll(tt.signature, tt.values); err == nil {
+				t.Fatalf("期望编码失败")
+			}
+		})
+	}
+}
+
+func TestDBusCallExportedObject(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	client := newTestDBusConn(t, clientSide)
+	server := newTestDBusConn(t, serverSide)
+	client.name = ":1.7"
+	server.Export("/test", func(sender, member string, args []any) (string, []any, error) {
+		if sender != client.name {
+			return "", nil, &DBusError{Name: "org.freedesktop.DBus.Error.AccessDenied", Message: sender}
+		}
+		if member != "Echo" {
+			return "", nil, &DBusError{Name: "org.freedesktop.DBus.Error.UnknownMethod", Message: member}
+		}
+		return "a{sv}", []any{map[string]DBusVariant{"value": {Signature: "s", Value: args[0]}}}, nil
+	})
+
+	reply, err := client.Call("", "/test", "com.example.Test.Echo", "s", "hello")
+	if err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+	if want := []any{map[string]any{"value": "hello"}}; !reflect.DeepEqual(reply, want) {
+		t.Fatalf("回复 = %#v，期望 %#v", reply, want)
+	}
+
+	_, err = client.Call("", "/test", "com.example.Test.Other", "")
+	if dbusErr, ok := err.(*DBusError); !ok || dbusErr.Name != "org.freedesktop.DBus.Error.UnknownMethod" || dbusErr.Message != "Other" {
+		t.Fatalf("错误 = %v，期望UnknownMethod", err)
+	}
+	_, err = client.Call("", "/missing", "com.example.Test.Echo", "s", "hello")
+	if dbusErr, ok := err.(*DBusError); !ok || dbusErr.Name != "org.freedesktop.DBus.Error.UnknownObject" {
+		t.Fatalf("错误 = %v，期望UnknownObject", err)
+	}
+}
+
+// newTestSystemBus 创建直接相连的两个D-Bus连接，程序一端作为系统总线连接（SystemBus），返回服务一端。
+// 服务一端占用service名称并回答总线的名称查询
+func newTestSystemBus(t *testing.T, service string) *DBusConn {
+	t.Helper()
+	clientSide, serviceSide := net.Pipe()
+	client := newTestDBusConn(t, clientSide)
+	server := newTestDBusConn(t, serviceSide)
+	client.name, server.name = ":1.2", ":1.5"
+	server.Export("/org/freedesktop/DBus", func(sender, member string, args []any) (string, []any, error) {
+		name, _ := args[0].(string)
+		switch {
+		case member == "NameHasOwner":
+			return "b", []any{name == service}, nil
+		case member == "GetNameOwner" && name == service:
+			return "s", []any{server.name}, nil
+		}
+		return "", nil, &DBusError{Name: "org.freedesktop.DBus.Error.NameHasNoOwner", Message: name}
+	})
+
+	systemBus.Lock()
+	previous := systemBus.conn
+	systemBus.conn = client
+	systemBus.Unlock()
+	t.Cleanup(func() {
+		systemBus.Lock()
+		systemBus.conn = previous
+		systemBus.Unlock()
+	})
+	return server
+}
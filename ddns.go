@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DDNSSource 动态DNS记录使用的地址来源
+type DDNSSource string
+
+const (
+	// DDNSSourceLAN 当前WiFi接口的局域网IP地址（默认）
+	DDNSSourceLAN DDNSSource = "lan"
+	// DDNSSourceWAN 公网IP检测得到的地址，需要启用 -wan
+	DDNSSourceWAN DDNSSource = "wan"
+)
+
+// DDNSRecord 一条需要自动更新的DNS记录，凭据字段支持凭据引用（如 ${env:CF_TOKEN}）
+type DDNSRecord struct {
+	// Name 完整域名，如 home.example.com
+	Name string `json:"name"`
+	// Zone 域名所在的区域（主域名），如 example.com
+	Zone string `json:"zone"`
+	// Provider 服务商: rfc2136、cloudflare、dnspod、alidns
+	Provider string `json:"provider"`
+	// Source 地址来源: lan（默认）或 wan
+	Source DDNSSource `json:"source,omitempty"`
+	// TTL 记录的TTL（秒），为0时使用服务商默认值
+	TTL int `json:"ttl,omitempty"`
+	// Endpoint 服务地址，rfc2136为DNS服务器（主机:端口），其他服务商为API地址，为空时使用官方地址
+	Endpoint string `json:"endpoint,omitempty"`
+	// TSIGKey TSIG密钥名称（rfc2136）
+	TSIGKey string `json:"tsig_key,omitempty"`
+	// TSIGSecret Base64编码的TSIG密钥（rfc2136）
+	TSIGSecret string `json:"tsig_secret,omitempty"`
+	// TSIGAlgorithm TSIG算法: hmac-sha256（默认）、hmac-sha512、hmac-sha1
+	TSIGAlgorithm string `json:"tsig_algorithm,omitempty"`
+	// Token API令牌，cloudflare为API Token，dnspod为 "ID,Token" 格式的登录令牌
+	Token string `json:"token,omitempty"`
+	// ZoneID Cloudflare区域ID，为空时按zone查询
+	ZoneID string `json:"zone_id,omitempty"`
+	// AccessKeyID 阿里云AccessKey ID（alidns）
+	AccessKeyID string `json:"access_key_id,omitempty"`
+	// AccessKeySecret 阿里云AccessKey Secret（alidns）
+	AccessKeySecret string `json:"access_key_secret,omitempty"`
+}
+
+// DDNSConfig 动态DNS配置文件结构
+type DDNSConfig struct {
+	Records []DDNSRecord `json:"records"`
+}
+
+// DDNSProvider DNS服务商，负责把记录更新为指定地址
+type DDNSProvider interface {
+	// Update 将记录更新为指定地址，返回更新前的地址；记录已是该地址时changed为false
+	Update(record *DDNSRecord, recordType, ip string) (previous string, changed bool, err error)
+}
+
+// newDDNSProvider 根据记录配置创建对应的服务商
+func newDDNSProvider(record *DDNSRecord) (DDNSProvider, error) {
+	switch record.Provider {
+	case "rfc2136":
+		return newRFC2136Provider(record)
+	case "cloudflare":
+		return newCloudflareProvider(), nil
+	case "dnspod":
+		return newDNSPodProvider(), nil
+	case "alidns":
+		return newAliDNSProvider(), nil
+	default:
+		return nil, fmt.Errorf("不支持的动态DNS服务商: %s（可选 rfc2136、cloudflare、dnspod、alidns）", record.Provider)
+	}
+}
+
+// Validate 检查记录配置是否完整
+func (r *DDNSRecord) Validate() error {
+	if r.Name == "" || r.Zone == "" {
+		return fmt.Errorf("需要配置name和zone")
+	}
+	name, zone := strings.TrimSuffix(r.Name, "."), strings.TrimSuffix(r.Zone, ".")
+	if name != zone && !strings.HasSuffix(name, "."+zone) {
+		return fmt.Errorf("域名 %s 不属于区域 %s", r.Name, r.Zone)
+	}
+	switch r.Source {
+	case "", DDNSSourceLAN, DDNSSourceWAN:
+	default:
+		return fmt.Errorf("不支持的地址来源: %s（可选 lan、wan）", r.Source)
+	}
+	switch r.Provider {
+	case "rfc2136":
+		if r.Endpoint == "" {
+			return fmt.Errorf("rfc2136需要在endpoint中配置DNS服务器地址")
+		}
+		if (r.TSIGKey == "") != (r.TSIGSecret == "") {
+			return fmt.Errorf("tsig_key和tsig_secret需要同时配置")
+		}
+	case "cloudflare", "dnspod":
+		if r.Token == "" {
+			return fmt.Errorf("%s需要配置token", r.Provider)
+		}
+	case "alidns":
+		if r.AccessKeyID == "" || r.AccessKeySecret == "" {
+			return fmt.Errorf("alidns需要配置access_key_id和access_key_secret")
+		}
+	}
+	return nil
+}
+
+// SubDomain 返回域名相对于区域的主机记录，域名与区域相同时为 @
+func (r *DDNSRecord) SubDomain() string {
+	name, zone := strings.TrimSuffix(r.Name, "."), strings.TrimSuffix(r.Zone, ".")
+	if name == zone {
+		return "@"
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
+
+// loadDDNSRecords 从JSON文件加载动态DNS配置，并展开凭据引用
+func loadDDNSRecords(path string) ([]DDNSRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取动态DNS配置文件失败: %v", err)
+	}
+
+	var config DDNSConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析动态DNS配置文件失败: %v", err)
+	}
+
+	for i := range config.Records {
+		record := &config.Records[i]
+		if record.Source == "" {
+			record.Source = DDNSSourceLAN
+		}
+		for _, field := range []*string{&record.TSIGSecret, &record.Token, &record.AccessKeyID, &record.AccessKeySecret} {
+			value, err := resolveCredential(*field)
+			if err != nil {
+				return nil, fmt.Errorf("动态DNS记录 %s 的凭据错误: %v", record.Name, err)
+			}
+			*field = value
+		}
+		if err := record.Validate(); err != nil {
+			return nil, fmt.Errorf("动态DNS记录第%d项配置错误: %v", i+1, err)
+		}
+	}
+	return config.Records, nil
+}
+
+// ddnsEntry 单条记录的更新状态
+type ddnsEntry struct {
+	record   DDNSRecord
+	provider DDNSProvider
+	// desired 最近一次请求更新的地址
+	desired string
+	// applied 最近一次确认已生效的地址
+	applied string
+	mutex   sync.Mutex
+}
+
+// DDNSUpdater 地址变化时更新动态DNS记录，失败时重试
+type DDNSUpdater struct {
+	entries    []*ddnsEntry
+	retries    int
+	retryDelay time.Duration
+	mutex      sync.Mutex
+}
+
+// NewDDNSUpdater 创建动态DNS更新器
+func NewDDNSUpdater(records []DDNSRecord) (*DDNSUpdater, error) {
+	updater := &DDNSUpdater{retries: 3, retryDelay: 5 * time.Second}
+	for _, record := range records {
+		provider, err := newDDNSProvider(&record)
+		if err != nil {
+			return nil, err
+		}
+		updater.entries = append(updater.entries, &ddnsEntry{record: record, provider: provider})
+	}
+	return updater, nil
+}
+
+// UpdateAsync 将指定来源的全部记录异步更新为新地址，已生效的地址不会重复提交
+func (u *DDNSUpdater) UpdateAsync(source DDNSSource, ip string) {
+	if ip == "" {
+		return
+	}
+	for _, entry := range u.entries {
+		if entry.record.Source != source {
+			continue
+		}
+		u.mutex.Lock()
+		if entry.desired == ip {
+			u.mutex.Unlock()
+			continue
+		}
+		entry.desired = ip
+		u.mutex.Unlock()
+		go u.sync(entry)
+	}
+}
+
+// sync 将记录更新为最近请求的地址，同一记录的更新串行执行
+func (u *DDNSUpdater) sync(entry *ddnsEntry) {
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+
+	u.mutex.Lock()
+	ip := entry.desired
+	u.mutex.Unlock()
+	if ip == entry.applied {
+		return
+	}
+
+	recordType := "A"
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		recordType = "AAAA"
+	}
+	name := entry.record.Name
+
+	startTime := time.Now()
+	var previous string
+	var changed bool
+	var err error
+	for attempt := 1; attempt <= u.retries; attempt++ {
+		previous, changed, err = entry.provider.Update(&entry.record, recordType, ip)
+		if err == nil {
+			break
+		}
+		log.Printf("更新动态DNS记录 %s 失败（第%d次）: %v", name, attempt, err)
+		// 等待重试期间有更新的地址时放弃本次更新
+		u.mutex.Lock()
+		superseded := entry.desired != ip
+		u.mutex.Unlock()
+		if superseded || attempt == u.retries {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * u.retryDelay)
+	}
+
+	if err == nil {
+		entry.applied = ip
+		if !changed {
+			log.Printf("动态DNS记录 %s 已是 %s，无需更新", name, ip)
+			return
+		}
+		log.Printf("动态DNS记录已更新: %s %s %s -> %s", name, recordType, previous, ip)
+	} else {
+		// 允许下次检查时重新提交
+		u.mutex.Lock()
+		if entry.desired == ip {
+			entry.desired = ""
+		}
+		u.mutex.Unlock()
+	}
+
+	event := HistoryEvent{
+		Type:       HistoryEventDDNS,
+		From:       name,
+		To:         ip,
+		Success:    err == nil,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	recordHistory(event)
+}
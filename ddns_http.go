@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ddnsHTTPClient 动态DNS服务商API使用的HTTP客户端
+var ddnsHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// doDDNSRequest 发送请求并解析JSON响应，HTTP状态码非2xx时返回响应内容
+func doDDNSRequest(req *http.Request, result interface{}) error {
+	resp, err := ddnsHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("请求失败，状态码%d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// CloudflareProvider 通过Cloudflare API v4更新记录
+type CloudflareProvider struct{}
+
+// newCloudflareProvider 创建Cloudflare服务商
+func newCloudflareProvider() *CloudflareProvider {
+	return &CloudflareProvider{}
+}
+
+// cloudflareResponse Cloudflare API响应
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+// cloudflareRecord Cloudflare DNS记录
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+// Update 实现DDNSProvider接口
+func (p *CloudflareProvider) Update(record *DDNSRecord, recordType, ip string) (string, bool, error) {
+	zoneID := record.ZoneID
+	if zoneID == "" {
+		var zones []struct {
+			ID string `json:"id"`
+		}
+		if err := p.call(record, http.MethodGet, "/zones?name="+url.QueryEscape(record.Zone), nil, &zones); err != nil {
+			return "", false, err
+		}
+		if len(zones) == 0 {
+			return "", false, fmt.Errorf("Cloudflare中未找到区域: %s", record.Zone)
+		}
+		zoneID = zones[0].ID
+	}
+
+	var existing []cloudflareRecord
+	query := url.Values{"type": {recordType}, "name": {record.Name}}
+	if err := p.call(record, http.MethodGet, "/zones/"+zoneID+"/dns_records?"+query.Encode(), nil, &existing); err != nil {
+		return "", false, err
+	}
+
+	// TTL为1表示由Cloudflare自动设置
+	desired := cloudflareRecord{Type: recordType, Name: record.Name, Content: ip, TTL: 1}
+	if record.TTL > 0 {
+		desired.TTL = record.TTL
+	}
+	if len(existing) == 0 {
+		return "", true, p.call(record, http.MethodPost, "/zones/"+zoneID+"/dns_records", desired, nil)
+	}
+	previous := existing[0].Content
+	if previous == ip {
+		return previous, false, nil
+	}
+	return previous, true, p.call(record, http.MethodPatch, "/zones/"+zoneID+"/dns_records/"+existing[0].ID, desired, nil)
+}
+
+// call 调用Cloudflare API，result为响应中result字段的解析目标
+func (p *CloudflareProvider) call(record *DDNSRecord, method, path string, body, result interface{}) error {
+	endpoint := record.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.cloudflare.com/client/v4"
+	}
+	var reader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(endpoint, "/")+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+record.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	var response cloudflareResponse
+	if err := doDDNSRequest(req, &response); err != nil {
+		return fmt.Errorf("Cloudflare %v", err)
+	}
+	if !response.Success {
+		var messages []string
+		for _, e := range response.Errors {
+			messages = append(messages, fmt.Sprintf("%d %s", e.Code, e.Message))
+		}
+		return fmt.Errorf("Cloudflare返回错误: %s", strings.Join(messages, "; "))
+	}
+	if result != nil {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return fmt.Errorf("解析Cloudflare响应失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// DNSPodProvider 通过DNSPod（腾讯云）API更新记录，使用 "ID,Token" 格式的登录令牌
+type DNSPodProvider struct{}
+
+// newDNSPodProvider 创建DNSPod服务商
+func newDNSPodProvider() *DNSPodProvider {
+	return &DNSPodProvider{}
+}
+
+// dnspodStatus DNSPod API响应中的状态
+type dnspodStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Update 实现DDNSProvider接口
+func (p *DNSPodProvider) Update(record *DDNSRecord, recordType, ip string) (string, bool, error) {
+	var list struct {
+		Status  dnspodStatus `json:"status"`
+		Records []struct {
+			ID    string `json:"id"`
+			Value string `json:"value"`
+			Line  string `json:"line"`
+		} `json:"records"`
+	}
+	params := url.Values{"domain": {record.Zone}, "sub_domain": {record.SubDomain()}, "record_type": {recordType}}
+	if err := p.call(record, "Record.List", params, &list, &list.Status); err != nil {
+		// 状态码10表示记录列表为空
+		if list.Status.Code != "10" {
+			return "", false, err
+		}
+	}
+
+	params = url.Values{
+		"domain":      {record.Zone},
+		"sub_domain":  {record.SubDomain()},
+		"record_type": {recordType},
+		"record_line": {"默认"},
+		"value":       {ip},
+	}
+	if record.TTL > 0 {
+		params.Set("ttl", fmt.Sprint(record.TTL))
+	}
+	var result struct {
+		Status dnspodStatus `json:"status"`
+	}
+	if len(list.Records) == 0 {
+		return "", true, p.call(record, "Record.Create", params, &result, &result.Status)
+	}
+	previous := list.Records[0].Value
+	if previous == ip {
+		return previous, false, nil
+	}
+	params.Set("record_id", list.Records[0].ID)
+	if list.Records[0].Line != "" {
+		params.Set("record_line", list.Records[0].Line)
+	}
+	return previous, true, p.call(record, "Record.Modify", params, &result, &result.Status)
+}
+
+// call 调用DNSPod API，status指向result中的状态字段
+func (p *DNSPodProvider) call(record *DDNSRecord, action string, params url.Values, result interface{}, status *dnspodStatus) error {
+	endpoint := record.Endpoint
+	if endpoint == "" {
+		endpoint = "https://dnsapi.cn"
+	}
+	params.Set("login_token", record.Token)
+	params.Set("format", "json")
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/"+action, strings.NewReader(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// DNSPod要求设置包含联系方式的User-Agent
+	req.Header.Set("User-Agent", "connect-ddns/"+version+" (https://github.com/weibaohui/connect)")
+
+	if err := doDDNSRequest(req, result); err != nil {
+		return fmt.Errorf("DNSPod %v", err)
+	}
+	if status.Code != "1" {
+		return fmt.Errorf("DNSPod %s返回错误: %s %s", action, status.Code, status.Message)
+	}
+	return nil
+}
+
+// AliDNSProvider 通过阿里云云解析DNS API更新记录
+type AliDNSProvider struct{}
+
+// newAliDNSProvider 创建阿里云DNS服务商
+func newAliDNSProvider() *AliDNSProvider {
+	return &AliDNSProvider{}
+}
+
+// Update 实现DDNSProvider接口
+func (p *AliDNSProvider) Update(record *DDNSRecord, recordType, ip string) (string, bool, error) {
+	var list struct {
+		DomainRecords struct {
+			Record []struct {
+				RecordID string `json:"RecordId"`
+				Value    string `json:"Value"`
+			} `json:"Record"`
+		} `json:"DomainRecords"`
+	}
+	params := map[string]string{"Action": "DescribeSubDomainRecords", "SubDomain": strings.TrimSuffix(record.Name, "."), "Type": recordType}
+	if err := p.call(record, params, &list); err != nil {
+		return "", false, err
+	}
+
+	params = map[string]string{"RR": record.SubDomain(), "Type": recordType, "Value": ip}
+	if record.TTL > 0 {
+		params["TTL"] = fmt.Sprint(record.TTL)
+	}
+	records := list.DomainRecords.Record
+	if len(records) == 0 {
+		params["Action"] = "AddDomainRecord"
+		params["DomainName"] = strings.TrimSuffix(record.Zone, ".")
+		return "", true, p.call(record, params, &struct{}{})
+	}
+	previous := records[0].Value
+	if previous == ip {
+		return previous, false, nil
+	}
+	params["Action"] = "UpdateDomainRecord"
+	params["RecordId"] = records[0].RecordID
+	return previous, true, p.call(record, params, &struct{}{})
+}
+
+// call 调用阿里云RPC风格API，按签名算法v1对请求签名
+func (p *AliDNSProvider) call(record *DDNSRecord, params map[string]string, result interface{}) error {
+	endpoint := record.Endpoint
+	if endpoint == "" {
+		endpoint = "https://alidns.aliyuncs.com/"
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	query := map[string]string{
+		"Format":           "JSON",
+		"Version":          "2015-01-09",
+		"AccessKeyId":      record.AccessKeyID,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureVersion": "1.0",
+		"SignatureNonce":   hex.EncodeToString(nonce),
+		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	for key, value := range params {
+		query[key] = value
+	}
+	canonical, signature := aliyunSign(record.AccessKeySecret, query)
+
+	req, err := http.NewRequest(http.MethodGet, endpoint+"?"+canonical+"&Signature="+aliyunPercentEncode(signature), nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	var response struct {
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+	}
+	resp, err := ddnsHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("阿里云DNS请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("读取阿里云DNS响应失败: %v", err)
+	}
+	// 出错时响应中包含Code和Message
+	if resp.StatusCode/100 != 2 {
+		if json.Unmarshal(body, &response) == nil && response.Code != "" {
+			return fmt.Errorf("阿里云DNS %s返回错误: %s %s", params["Action"], response.Code, response.Message)
+		}
+		return fmt.Errorf("阿里云DNS请求失败，状态码%d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("解析阿里云DNS响应失败: %v", err)
+	}
+	return nil
+}
+
+// aliyunSign 按阿里云签名算法v1对GET请求的参数签名，返回规范化的查询字符串和签名
+func aliyunSign(accessKeySecret string, query map[string]string) (string, string) {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = aliyunPercentEncode(key) + "=" + aliyunPercentEncode(query[key])
+	}
+	canonical := strings.Join(pairs, "&")
+	mac := hmac.New(sha1.New, []byte(accessKeySecret+"&"))
+	mac.Write([]byte("GET&" + aliyunPercentEncode("/") + "&" + aliyunPercentEncode(canonical)))
+	return canonical, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// aliyunPercentEncode 按阿里云签名要求进行URL编码（RFC 3986）
+func aliyunPercentEncode(value string) string {
+	encoded := url.QueryEscape(value)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+	return strings.ReplaceAll(encoded, "%7E", "~")
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net"
+	"strings"
+	"time"
+)
+
+// DNS报文中用到的常量
+const (
+	dnsTypeA     = 1
+	dnsTypeSOA   = 6
+	dnsTypeAAAA  = 28
+	dnsTypeTSIG  = 250
+	dnsClassIN   = 1
+	dnsClassANY  = 255
+	dnsOpUpdate  = 5
+	dnsTSIGFudge = 300
+)
+
+// dnsRcodeNames 常见响应码的名称
+var dnsRcodeNames = map[int]string{
+	1: "FORMERR", 2: "SERVFAIL", 3: "NXDOMAIN", 4: "NOTIMP", 5: "REFUSED",
+	6: "YXDOMAIN", 7: "YXRRSET", 8: "NXRRSET", 9: "NOTAUTH", 10: "NOTZONE",
+}
+
+// tsigAlgorithms 支持的TSIG算法
+var tsigAlgorithms = map[string]func() hash.Hash{
+	"hmac-sha1":   sha1.New,
+	"hmac-sha256": sha256.New,
+	"hmac-sha512": sha512.New,
+}
+
+// RFC2136Provider 通过RFC 2136动态更新报文修改记录，可使用TSIG签名
+type RFC2136Provider struct {
+	server    string
+	keyName   string
+	secret    []byte
+	algorithm string
+	timeout   time.Duration
+}
+
+// newRFC2136Provider 根据记录配置创建RFC 2136服务商
+func newRFC2136Provider(record *DDNSRecord) (*RFC2136Provider, error) {
+	server := record.Endpoint
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	provider := &RFC2136Provider{server: server, timeout: 5 * time.Second}
+	if record.TSIGKey == "" {
+		return provider, nil
+	}
+
+	algorithm := strings.ToLower(record.TSIGAlgorithm)
+	if algorithm == "" {
+		algorithm = "hmac-sha256"
+	}
+	if _, ok := tsigAlgorithms[algorithm]; !ok {
+		return nil, fmt.Errorf("不支持的TSIG算法: %s（可选 hmac-sha256、hmac-sha512、hmac-sha1）", record.TSIGAlgorithm)
+	}
+	secret, err := base64.StdEncoding.DecodeString(record.TSIGSecret)
+	if err != nil {
+		return nil, fmt.Errorf("TSIG密钥不是有效的Base64: %v", err)
+	}
+	provider.keyName = record.TSIGKey
+	provider.secret = secret
+	provider.algorithm = algorithm
+	return provider, nil
+}
+
+// Update 实现DDNSProvider接口 - 先查询服务器上的当前记录，不一致时发送更新报文
+func (p *RFC2136Provider) Update(record *DDNSRecord, recordType, ip string) (string, bool, error) {
+	rrType := uint16(dnsTypeA)
+	rdata := net.ParseIP(ip).To4()
+	if recordType == "AAAA" {
+		rrType = dnsTypeAAAA
+		rdata = net.ParseIP(ip).To16()
+	}
+
+	current, err := p.query(record.Name, rrType)
+	if err != nil {
+		return "", false, err
+	}
+	previous := strings.Join(current, ",")
+	if len(current) == 1 && current[0] == ip {
+		return previous, false, nil
+	}
+
+	ttl := record.TTL
+	if ttl <= 0 {
+		ttl = 300
+	}
+	// 更新报文: 区域段为目标区域的SOA，更新段先删除整个记录集再添加新记录
+	msg := dnsHeader(dnsRandomID(), dnsOpUpdate<<11, 1, 0, 2, 0)
+	msg = appendDNSQuestion(msg, record.Zone, dnsTypeSOA, dnsClassIN)
+	msg = appendDNSRecord(msg, record.Name, rrType, dnsClassANY, 0, nil)
+	msg = appendDNSRecord(msg, record.Name, rrType, dnsClassIN, uint32(ttl), rdata)
+	var requestMAC []byte
+	if p.keyName != "" {
+		msg, requestMAC = p.sign(msg, time.Now())
+	}
+
+	response, err := p.exchange(msg)
+	if err != nil {
+		return previous, false, err
+	}
+	if rcode := int(binary.BigEndian.Uint16(response[2:4]) & 0x0f); rcode != 0 {
+		return previous, false, fmt.Errorf("DNS服务器拒绝更新: %s", dnsRcodeName(rcode))
+	}
+	if p.keyName != "" {
+		// 未校验签名的成功响应可能是伪造的
+		if err := p.verify(response, requestMAC, time.Now()); err != nil {
+			return previous, false, err
+		}
+	}
+	return previous, true, nil
+}
+
+// query 向服务器查询记录的当前地址
+func (p *RFC2136Provider) query(name string, rrType uint16) ([]string, error) {
+	msg := dnsHeader(dnsRandomID(), 0, 1, 0, 0, 0)
+	msg = appendDNSQuestion(msg, name, rrType, dnsClassIN)
+	response, err := p.exchange(msg)
+	if err != nil {
+		return nil, err
+	}
+	rcode := int(binary.BigEndian.Uint16(response[2:4]) & 0x0f)
+	if rcode == 3 {
+		return nil, nil
+	}
+	if rcode != 0 {
+		return nil, fmt.Errorf("查询DNS记录失败: %s", dnsRcodeName(rcode))
+	}
+	return parseDNSAnswers(response, rrType)
+}
+
+// exchange 通过UDP发送DNS报文并等待ID匹配的响应
+func (p *RFC2136Provider) exchange(msg []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", p.server, p.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("连接DNS服务器失败: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(p.timeout))
+	if _, err := conn.Write(msg); err != nil {
+		return nil, fmt.Errorf("发送DNS报文失败: %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("等待DNS响应失败: %v", err)
+		}
+		if n >= 12 && buf[0] == msg[0] && buf[1] == msg[1] {
+			return buf[:n], nil
+		}
+	}
+}
+
+// tsigRecord TSIG记录的RDATA
+type tsigRecord struct {
+	algorithm  []byte
+	timeSigned uint64
+	fudge      uint16
+	mac        []byte
+	originalID uint16
+	err        uint16
+	other      []byte
+}
+
+// tsigErrorNames TSIG错误码的名称
+var tsigErrorNames = map[uint16]string{16: "BADSIG", 17: "BADKEY", 18: "BADTIME", 22: "BADTRUNC"}
+
+// sign 为报文追加TSIG签名记录（RFC 8945），返回签名后的报文和MAC，MAC用于校验响应的签名
+func (p *RFC2136Provider) sign(msg []byte, now time.Time) ([]byte, []byte) {
+	timeSigned := uint64(now.Unix())
+	mac := p.tsigMAC(nil, msg, timeSigned, dnsTSIGFudge, 0, nil)
+	return p.appendTSIG(msg, tsigRecord{
+		algorithm:  appendDNSName(nil, p.algorithm),
+		timeSigned: timeSigned,
+		fudge:      dnsTSIGFudge,
+		mac:        mac,
+		originalID: binary.BigEndian.Uint16(msg[0:2]),
+	}), mac
+}
+
+// tsigMAC 计算TSIG的MAC。签名响应时先覆盖请求的MAC，再覆盖不含TSIG记录的报文和TSIG变量:
+// 密钥名、CLASS、TTL、算法名、签名时间、fudge、error、other；校验响应时fudge使用服务器发送的值
+func (p *RFC2136Provider) tsigMAC(requestMAC, msg []byte, timeSigned uint64, fudge, tsigError uint16, other []byte) []byte {
+	mac := hmac.New(tsigAlgorithms[p.algorithm], p.secret)
+	if requestMAC != nil {
+		mac.Write(binary.BigEndian.AppendUint16(nil, uint16(len(requestMAC))))
+		mac.Write(requestMAC)
+	}
+	mac.Write(msg)
+	mac.Write(appendDNSName(nil, strings.ToLower(p.keyName)))
+	mac.Write([]byte{0, dnsClassANY, 0, 0, 0, 0})
+	mac.Write(appendDNSName(nil, p.algorithm))
+	mac.Write(binary.BigEndian.AppendUint64(nil, timeSigned)[2:])
+	mac.Write(binary.BigEndian.AppendUint16(nil, fudge))
+	mac.Write(binary.BigEndian.AppendUint16(nil, tsigError))
+	mac.Write(binary.BigEndian.AppendUint16(nil, uint16(len(other))))
+	mac.Write(other)
+	return mac.Sum(nil)
+}
+
+// appendTSIG 在报文的附加段末尾追加TSIG记录
+func (p *RFC2136Provider) appendTSIG(msg []byte, record tsigRecord) []byte {
+	var rdata []byte
+	rdata = append(rdata, record.algorithm...)
+	rdata = append(rdata, binary.BigEndian.AppendUint64(nil, record.timeSigned)[2:]...)
+	rdata = binary.BigEndian.AppendUint16(rdata, record.fudge)
+	rdata = binary.BigEndian.AppendUint16(rdata, uint16(len(record.mac)))
+	rdata = append(rdata, record.mac...)
+	rdata = binary.BigEndian.AppendUint16(rdata, record.originalID)
+	rdata = binary.BigEndian.AppendUint16(rdata, record.err)
+	rdata = binary.BigEndian.AppendUint16(rdata, uint16(len(record.other)))
+	rdata = append(rdata, record.other...)
+
+	signed := append([]byte{}, msg...)
+	signed = appendDNSRecord(signed, strings.ToLower(p.keyName), dnsTypeTSIG, dnsClassANY, 0, rdata)
+	// 附加段计数加1
+	binary.BigEndian.PutUint16(signed[10:12], binary.BigEndian.Uint16(signed[10:12])+1)
+	return signed
+}
+
+// verify 校验响应的TSIG签名（RFC 8945 5.3），requestMAC为请求的MAC
+func (p *RFC2136Provider) verify(response, requestMAC []byte, now time.Time) error {
+	start, record, err := findTSIG(response)
+	if err != nil {
+		return err
+	}
+	if record.err != 0 {
+		name, ok := tsigErrorNames[record.err]
+		if !ok {
+			name = fmt.Sprint(record.err)
+		}
+		return fmt.Errorf("DNS服务器TSIG校验失败: %s", name)
+	}
+	if !strings.EqualFold(string(record.algorithm), string(appendDNSName(nil, p.algorithm))) {
+		return fmt.Errorf("DNS服务器响应的TSIG算法与请求不一致")
+	}
+
+	// 去掉TSIG记录、恢复原始ID和附加段计数后计算MAC
+	msg := append([]byte{}, response[:start]...)
+	binary.BigEndian.PutUint16(msg[0:2], record.originalID)
+	binary.BigEndian.PutUint16(msg[10:12], binary.BigEndian.Uint16(msg[10:12])-1)
+	if !hmac.Equal(record.mac, p.tsigMAC(requestMAC, msg, record.timeSigned, record.fudge, record.err, record.other)) {
+		return fmt.Errorf("DNS服务器响应的TSIG签名校验失败，响应可能是伪造的")
+	}
+	if diff := now.Unix() - int64(record.timeSigned); diff > int64(record.fudge) || -diff > int64(record.fudge) {
+		return fmt.Errorf("DNS服务器响应的TSIG签名时间超出允许范围")
+	}
+	return nil
+}
+
+// findTSIG 查找报文附加段最后一条TSIG记录，返回记录在报文中的起始位置和解析后的RDATA
+func findTSIG(msg []byte) (int, tsigRecord, error) {
+	var record tsigRecord
+	if len(msg) < 12 {
+		return 0, record, fmt.Errorf("DNS响应格式错误")
+	}
+	counts := make([]int, 4)
+	for i := range counts {
+		counts[i] = int(binary.BigEndian.Uint16(msg[4+i*2:]))
+	}
+	if counts[3] == 0 {
+		return 0, record, fmt.Errorf("DNS服务器的响应未签名")
+	}
+	offset := 12
+	for i := 0; i < counts[0]; i++ {
+		next, err := skipDNSName(msg, offset)
+		if err != nil {
+			return 0, record, err
+		}
+		offset = next + 4
+	}
+	total := counts[1] + counts[2] + counts[3]
+	for i := 0; i < total; i++ {
+		start := offset
+		next, err := skipDNSName(msg, offset)
+		if err != nil || next+10 > len(msg) {
+			return 0, record, fmt.Errorf("DNS响应格式错误")
+		}
+		rrType := binary.BigEndian.Uint16(msg[next : next+2])
+		length := int(binary.BigEndian.Uint16(msg[next+8 : next+10]))
+		offset = next + 10 + length
+		if offset > len(msg) {
+			return 0, record, fmt.Errorf("DNS响应格式错误")
+		}
+		if i == total-1 && rrType == dnsTypeTSIG {
+			record, err := parseTSIG(msg[next+10 : offset])
+			return start, record, err
+		}
+	}
+	return 0, record, fmt.Errorf("DNS服务器的响应未签名")
+}
+
+// parseTSIG 解析TSIG记录的RDATA
+func parseTSIG(rdata []byte) (tsigRecord, error) {
+	var record tsigRecord
+	end, err := skipDNSName(rdata, 0)
+	if err != nil || end+10 > len(rdata) {
+		return record, fmt.Errorf("TSIG记录格式错误")
+	}
+	record.algorithm = rdata[:end]
+	record.timeSigned = uint64(binary.BigEndian.Uint16(rdata[end:]))<<32 | uint64(binary.BigEndian.Uint32(rdata[end+2:]))
+	record.fudge = binary.BigEndian.Uint16(rdata[end+6:])
+	macEnd := end + 10 + int(binary.BigEndian.Uint16(rdata[end+8:]))
+	if macEnd+6 > len(rdata) {
+		return record, fmt.Errorf("TSIG记录格式错误")
+	}
+	record.mac = rdata[end+10 : macEnd]
+	record.originalID = binary.BigEndian.Uint16(rdata[macEnd:])
+	record.err = binary.BigEndian.Uint16(rdata[macEnd+2:])
+	otherEnd := macEnd + 6 + int(binary.BigEndian.Uint16(rdata[macEnd+4:]))
+	if otherEnd > len(rdata) {
+		return record, fmt.Errorf("TSIG记录格式错误")
+	}
+	record.other = rdata[macEnd+6 : otherEnd]
+	return record, nil
+}
+
+// dnsRandomID 生成随机的报文ID
+func dnsRandomID() uint16 {
+	var b [2]byte
+	rand.Read(b[:])
+	return binary.BigEndian.Uint16(b[:])
+}
+
+// dnsRcodeName 返回响应码的名称
+func dnsRcodeName(rcode int) string {
+	if name, ok := dnsRcodeNames[rcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RCODE %d", rcode)
+}
+
+// dnsHeader 生成DNS报文头，更新报文中四个计数依次为区域、先决条件、更新和附加段
+func dnsHeader(id, flags, qdCount, anCount, nsCount, arCount uint16) []byte {
+	header := make([]byte, 12)
+	for i, value := range []uint16{id, flags, qdCount, anCount, nsCount, arCount} {
+		binary.BigEndian.PutUint16(header[i*2:], value)
+	}
+	return header
+}
+
+// appendDNSName 以不压缩的格式追加域名
+func appendDNSName(msg []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0)
+}
+
+// appendDNSQuestion 追加问题（或区域）段
+func appendDNSQuestion(msg []byte, name string, rrType, class uint16) []byte {
+	msg = appendDNSName(msg, name)
+	msg = binary.BigEndian.AppendUint16(msg, rrType)
+	return binary.BigEndian.AppendUint16(msg, class)
+}
+
+// appendDNSRecord 追加一条资源记录
+func appendDNSRecord(msg []byte, name string, rrType, class uint16, ttl uint32, rdata []byte) []byte {
+	msg = appendDNSQuestion(msg, name, rrType, class)
+	msg = binary.BigEndian.AppendUint32(msg, ttl)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(rdata)))
+	return append(msg, rdata...)
+}
+
+// skipDNSName 跳过报文中offset处的域名（支持压缩指针），返回域名之后的位置
+func skipDNSName(msg []byte, offset int) (int, error) {
+	for offset < len(msg) {
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			return offset + 1, nil
+		case length&0xc0 == 0xc0:
+			return offset + 2, nil
+		}
+		offset += length + 1
+	}
+	return 0, fmt.Errorf("DNS响应格式错误")
+}
+
+// parseDNSAnswers 解析响应回答段中指定类型记录的地址
+func parseDNSAnswers(msg []byte, rrType uint16) ([]string, error) {
+	if len(msg) < 12 {
+		return nil, fmt.Errorf("DNS响应格式错误")
+	}
+	qdCount := int(binary.BigEndian.Uint16(msg[4:6]))
+	anCount := int(binary.BigEndian.Uint16(msg[6:8]))
+	offset := 12
+	for i := 0; i < qdCount; i++ {
+		next, err := skipDNSName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + 4
+	}
+
+	var addresses []string
+	for i := 0; i < anCount; i++ {
+		next, err := skipDNSName(msg, offset)
+		if err != nil || next+10 > len(msg) {
+			return nil, fmt.Errorf("DNS响应格式错误")
+		}
+		answerType := binary.BigEndian.Uint16(msg[next : next+2])
+		length := int(binary.BigEndian.Uint16(msg[next+8 : next+10]))
+		offset = next + 10 + length
+		if offset > len(msg) {
+			return nil, fmt.Errorf("DNS响应格式错误")
+		}
+		if answerType == rrType && (length == net.IPv4len || length == net.IPv6len) {
+			addresses = append(addresses, net.IP(msg[next+10:offset]).String())
+		}
+	}
+	return addresses, nil
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCloudflareProviderUpdate(t *testing.T) {
+	var patched cloudflareRecord
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer cf-token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"success":false,"errors":[{"code":9109,"message":"Invalid access token"}]}`)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones":
+			fmt.Fprint(w, `{"success":true,"result":[{"id":"zone-1"}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone-1/dns_records":
+			if r.URL.Query().Get("name") != "home.example.com" || r.URL.Query().Get("type") != "A" {
+				t.Errorf("查询记录的参数错误: %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"success":true,"result":[{"id":"rec-1","type":"A","name":"home.example.com","content":"192.0.2.1","ttl":1}]}`)
+		case r.Method == http.MethodPatch && r.URL.Path == "/zones/zone-1/dns_records/rec-1":
+			json.NewDecoder(r.Body).Decode(&patched)
+			fmt.Fprint(w, `{"success":true,"result":{}}`)
+		default:
+			t.Errorf("未预期的请求: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	record := &DDNSRecord{Name: "home.example.com", Zone: "example.com", Provider: "cloudflare", Token: "cf-token", TTL: 120, Endpoint: server.URL}
+	previous, changed, err := newCloudflareProvider().Update(record, "A", "192.0.2.2")
+	if err != nil || !changed || previous != "192.0.2.1" {
+		t.Fatalf("Update() = %q, %v, %v", previous, changed, err)
+	}
+	if patched.Content != "192.0.2.2" || patched.TTL != 120 {
+		t.Fatalf("更新的记录 = %+v", patched)
+	}
+
+	if _, changed, err := newCloudflareProvider().Update(record, "A", "192.0.2.1"); err != nil || changed {
+		t.Fatalf("记录已是目标地址时 Update() = %v, %v，期望不更新", changed, err)
+	}
+
+	record.Token = "wrong"
+	if _, _, err := newCloudflareProvider().Update(record, "A", "192.0.2.2"); err == nil || !strings.Contains(err.Error(), "9109") {
+		t.Fatalf("令牌错误时 Update() 错误 = %v", err)
+	}
+}
+
+func TestDNSPodProviderUpdate(t *testing.T) {
+	var modified map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("login_token") != "123,token" || r.PostForm.Get("format") != "json" {
+			fmt.Fprint(w, `{"status":{"code":"-1","message":"登录失败"}}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/Record.List":
+			if r.PostForm.Get("sub_domain") != "home" {
+				t.Errorf("sub_domain = %s", r.PostForm.Get("sub_domain"))
+			}
+			fmt.Fprint(w, `{"status":{"code":"1"},"records":[{"id":"42","value":"192.0.2.1","line":"电信"}]}`)
+		case "/Record.Modify":
+			modified = map[string]string{}
+			for key := range r.PostForm {
+				modified[key] = r.PostForm.Get(key)
+			}
+			fmt.Fprint(w, `{"status":{"code":"1"}}`)
+		default:
+			t.Errorf("未预期的请求: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	record := &DDNSRecord{Name: "home.example.com", Zone: "example.com", Provider: "dnspod", Token: "123,token", Endpoint: server.URL}
+	previous, changed, err := newDNSPodProvider().Update(record, "A", "192.0.2.2")
+	if err != nil || !changed || previous != "192.0.2.1" {
+		t.Fatalf("Update() = %q, %v, %v", previous, changed, err)
+	}
+	if modified["record_id"] != "42" || modified["value"] != "192.0.2.2" || modified["record_line"] != "电信" {
+		t.Fatalf("修改记录的参数 = %v", modified)
+	}
+
+	record.Token = "wrong"
+	if _, _, err := newDNSPodProvider().Update(record, "A", "192.0.2.2"); err == nil || !strings.Contains(err.Error(), "登录失败") {
+		t.Fatalf("令牌错误时 Update() 错误 = %v", err)
+	}
+}
+
+func TestAliyunSignKnownVector(t *testing.T) {
+	// 阿里云RPC签名文档中的示例
+	query := map[string]string{
+		"AccessKeyId":      "testid",
+		"Action":           "DescribeRegions",
+		"Format":           "XML",
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   "3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf",
+		"SignatureVersion": "1.0",
+		"Timestamp":        "2016-02-23T12:46:24Z",
+		"Version":          "2014-05-26",
+	}
+	canonical, signature := aliyunSign("testsecret", query)
+	if want := "AccessKeyId=testid&Action=DescribeRegions&Format=XML&SignatureMethod=HMAC-SHA1&SignatureNonce=3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf&SignatureVersion=1.0&Timestamp=2016-02-23T12%3A46%3A24Z&Version=2014-05-26"; canonical != want {
+		t.Fatalf("规范化查询字符串 = %s\n期望 %s", canonical, want)
+	}
+	if signature != "OLeaidS1JvxuMvnyHOwuJ+uX5qY=" {
+		t.Fatalf("签名 = %s", signature)
+	}
+}
+
+func TestAliDNSProviderUpdate(t *testing.T) {
+	var updated map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 按收到的参数重新计算签名
+		query := map[string]string{}
+		for key := range r.URL.Query() {
+			if key != "Signature" {
+				query[key] = r.URL.Query().Get(key)
+			}
+		}
+		if _, signature := aliyunSign("ali-secret", query); signature != r.URL.Query().Get("Signature") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"Code":"SignatureDoesNotMatch","Message":"Specified signature is not matched with our calculation."}`)
+			return
+		}
+		switch query["Action"] {
+		case "DescribeSubDomainRecords":
+			if query["SubDomain"] != "home.example.com" {
+				t.Errorf("SubDomain = %s", query["SubDomain"])
+			}
+			fmt.Fprint(w, `{"DomainRecords":{"Record":[{"RecordId":"9","Value":"192.0.2.1"}]}}`)
+		case "UpdateDomainRecord":
+			updated = query
+			fmt.Fprint(w, `{"RecordId":"9"}`)
+		default:
+			t.Errorf("未预期的请求: %s", query["Action"])
+		}
+	}))
+	defer server.Close()
+
+	record := &DDNSRecord{Name: "home.example.com", Zone: "example.com", Provider: "alidns",
+		AccessKeyID: "ali-id", AccessKeySecret: "ali-secret", Endpoint: server.URL + "/"}
+	previous, changed, err := newAliDNSProvider().Update(record, "A", "192.0.2.2")
+	if err != nil || !changed || previous != "192.0.2.1" {
+		t.Fatalf("Update() = %q, %v, %v", previous, changed, err)
+	}
+	if updated["RecordId"] != "9" || updated["RR"] != "home" || updated["Value"] != "192.0.2.2" || updated["AccessKeyId"] != "ali-id" {
+		t.Fatalf("更新记录的参数 = %v", updated)
+	}
+
+	record.AccessKeySecret = "wrong"
+	if _, _, err := newAliDNSProvider().Update(record, "A", "192.0.2.2"); err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("密钥错误时 Update() 错误 = %v", err)
+	}
+}
+
+// fakeDNSServer 本地RFC 2136服务器：查询时返回当前地址，收到更新时校验TSIG签名并按mode回复
+type fakeDNSServer struct {
+	conn    net.PacketConn
+	key     *RFC2136Provider
+	current string
+	// mode 更新的响应方式: signed（正确签名）、unsigned（不签名）、forged（使用其他密钥签名）
+	mode    string
+	updates atomic.Int32
+}
+
+func startFakeDNSServer(t *testing.T, key *RFC2136Provider, current, mode string) *fakeDNSServer {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动DNS服务失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	server := &fakeDNSServer{conn: conn, key: key, current: current, mode: mode}
+	go server.serve(t)
+	return server
+}
+
+func (s *fakeDNSServer) serve(t *testing.T) {
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		request := append([]byte{}, buf[:n]...)
+		// 响应只包含报文头和区域（问题）段
+		end, _ := skipDNSName(request, 12)
+		response := append([]byte{}, request[:end+4]...)
+		binary.BigEndian.PutUint16(response[2:4], binary.BigEndian.Uint16(request[2:4])|0x8000)
+		binary.BigEndian.PutUint16(response[4:], 1)
+		binary.BigEndian.PutUint16(response[6:], 0)
+		binary.BigEndian.PutUint16(response[8:], 0)
+		binary.BigEndian.PutUint16(response[10:], 0)
+
+		if opcode := binary.BigEndian.Uint16(request[2:4]) >> 11 & 0x0f; opcode != dnsOpUpdate {
+			binary.BigEndian.PutUint16(response[6:], 1)
+			response = appendDNSRecord(response, "home.example.com", dnsTypeA, dnsClassIN, 300, net.ParseIP(s.current).To4())
+			s.conn.WriteTo(response, addr)
+			continue
+		}
+
+		s.updates.Add(1)
+		start, record, err := findTSIG(request)
+		if err != nil {
+			t.Errorf("更新请求未签名: %v", err)
+			continue
+		}
+		unsigned := append([]byte{}, request[:start]...)
+		binary.BigEndian.PutUint16(unsigned[10:12], binary.BigEndian.Uint16(unsigned[10:12])-1)
+		if string(record.mac) != string(s.key.tsigMAC(nil, unsigned, record.timeSigned, record.fudge, 0, nil)) {
+			t.Errorf("更新请求的TSIG签名错误")
+		}
+
+		signer := s.key
+		fudge := uint16(dnsTSIGFudge)
+		switch s.mode {
+		case "unsigned":
+			s.conn.WriteTo(response, addr)
+			continue
+		case "forged":
+			signer = &RFC2136Provider{keyName: s.key.keyName, algorithm: s.key.algorithm, secret: []byte("attacker")}
+		case "fudge":
+			// 服务器使用与客户端不同的fudge
+			fudge = 600
+		}
+		now := uint64(time.Now().Unix())
+		mac := signer.tsigMAC(record.mac, response, now, fudge, 0, nil)
+		response = signer.appendTSIG(response, tsigRecord{
+			algorithm: record.algorithm, timeSigned: now, fudge: fudge, mac: mac, originalID: record.originalID,
+		})
+		s.conn.WriteTo(response, addr)
+	}
+}
+
+func TestRFC2136ProviderUpdate(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	newProvider := func(t *testing.T, endpoint string) *RFC2136Provider {
+		provider, err := newRFC2136Provider(&DDNSRecord{Endpoint: endpoint, TSIGKey: "ddns-key.", TSIGSecret: secret})
+		if err != nil {
+			t.Fatalf("newRFC2136Provider() 失败: %v", err)
+		}
+		provider.timeout = 2 * time.Second
+		return provider
+	}
+	key := newProvider(t, "127.0.0.1:53")
+	record := &DDNSRecord{Name: "home.example.com", Zone: "example.com", Provider: "rfc2136"}
+
+	tests := []struct {
+		mode    string
+		wantErr string
+	}{
+		{"signed", ""},
+		{"fudge", ""},
+		{"unsigned", "响应未签名"},
+		{"forged", "签名校验失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			server := startFakeDNSServer(t, key, "192.0.2.1", tt.mode)
+			provider := newProvider(t, server.conn.LocalAddr().String())
+			previous, changed, err := provider.Update(record, "A", "192.0.2.2")
+			if server.updates.Load() != 1 {
+				t.Fatalf("服务器收到%d个更新请求，期望1个", server.updates.Load())
+			}
+			if tt.wantErr != "" {
+				if err == nil || changed || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Update() = %v, %v，期望错误包含 %q", changed, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !changed || previous != "192.0.2.1" {
+				t.Fatalf("Update() = %q, %v, %v", previous, changed, err)
+			}
+		})
+	}
+
+	t.Run("记录已是目标地址", func(t *testing.T) {
+		server := startFakeDNSServer(t, key, "192.0.2.2", "signed")
+		provider := newProvider(t, server.conn.LocalAddr().String())
+		if _, changed, err := provider.Update(record, "A", "192.0.2.2"); err != nil || changed || server.updates.Load() != 0 {
+			t.Fatalf("Update() = %v, %v，更新请求%d个", changed, err, server.updates.Load())
+		}
+	})
+}
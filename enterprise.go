@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// EAPMethod 802.1X外层认证方式
+type EAPMethod string
+
+const (
+	// EAPPEAP PEAP，通常配合MSCHAPv2
+	EAPPEAP EAPMethod = "peap"
+	// EAPTTLS EAP-TTLS，通常配合PAP或MSCHAPv2
+	EAPTTLS EAPMethod = "ttls"
+	// EAPTLS EAP-TLS，使用客户端证书认证
+	EAPTLS EAPMethod = "tls"
+)
+
+// EnterpriseCredentials WPA2/WPA3企业级（802.1X）认证凭据，字段值支持凭据引用（如 ${env:CORP_PASSWORD}）
+type EnterpriseCredentials struct {
+	// EAPMethod 外层认证方式: peap、ttls 或 tls
+	EAPMethod EAPMethod `json:"eap_method"`
+	// Identity 用户名
+	Identity string `json:"identity"`
+	// AnonymousIdentity 外层匿名身份，为空时使用Identity
+	AnonymousIdentity string `json:"anonymous_identity,omitempty"`
+	// Password 用户密码（peap/ttls），为空时使用WiFi密码
+	Password string `json:"password,omitempty"`
+	// Phase2 内层认证方式（peap/ttls），如 mschapv2、pap、gtc，默认mschapv2
+	Phase2 string `json:"phase2,omitempty"`
+	// CACert 校验认证服务器的CA证书文件（PEM或DER）
+	CACert string `json:"ca_cert,omitempty"`
+	// ServerName 认证服务器证书中的域名
+	ServerName string `json:"server_name,omitempty"`
+	// ClientCert 客户端证书文件（tls）；Windows和macOS需要包含私钥的PKCS#12（.pfx/.p12）文件
+	ClientCert string `json:"client_cert,omitempty"`
+	// PrivateKey 客户端私钥文件（tls，仅Linux使用）
+	PrivateKey string `json:"private_key,omitempty"`
+	// PrivateKeyPassword 客户端私钥或PKCS#12文件的密码
+	PrivateKeyPassword string `json:"private_key_password,omitempty"`
+}
+
+// Validate 检查凭据配置是否完整
+func (c *EnterpriseCredentials) Validate() error {
+	switch c.EAPMethod {
+	case EAPPEAP, EAPTTLS:
+	case EAPTLS:
+		if c.ClientCert == "" {
+			return fmt.Errorf("EAP-TLS需要配置client_cert")
+		}
+	default:
+		return fmt.Errorf("不支持的EAP认证方式: %s", c.EAPMethod)
+	}
+	if c.Identity == "" {
+		return fmt.Errorf("企业级认证需要配置identity")
+	}
+	if runtime.GOOS == "windows" {
+		return validateWindowsEAP(c)
+	}
+	return nil
+}
+
+// Resolve 展开各字段中的凭据引用，返回新的凭据
+func (c *EnterpriseCredentials) Resolve() (*EnterpriseCredentials, error) {
+	resolved := *c
+	for _, field := range []*string{
+		&resolved.Identity, &resolved.AnonymousIdentity, &resolved.Password,
+		&resolved.PrivateKeyPassword,
+	} {
+		value, err := resolveCredential(*field)
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+	return &resolved, nil
+}
+
+// InnerMethod 返回内层认证方式，未配置时默认为mschapv2
+func (c *EnterpriseCredentials) InnerMethod() string {
+	if c.Phase2 == "" {
+		return "mschapv2"
+	}
+	return strings.ToLower(c.Phase2)
+}
+
+// OuterIdentity 返回外层身份，未配置匿名身份时使用用户名
+func (c *EnterpriseCredentials) OuterIdentity() string {
+	if c.AnonymousIdentity != "" {
+		return c.AnonymousIdentity
+	}
+	return c.Identity
+}
+
+// readCertificateDER 读取PEM或DER格式的证书文件，返回DER编码
+func readCertificateDER(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取证书文件失败: %v", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("证书文件格式错误: %s", block.Type)
+		}
+		return block.Bytes, nil
+	}
+	return data, nil
+}
+
+// certificateThumbprint 计算证书的SHA-1指纹，格式与Windows证书存储一致（空格分隔的小写十六进制）
+func certificateThumbprint(der []byte) string {
+	sum := sha1.Sum(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// defaultHistoryFile 返回默认的连接历史记录文件（用户配置目录下的 connect/history.jsonl），
+// 不随启动目录变化；无法确定用户配置目录时返回空字符串，不记录历史
+func defaultHistoryFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "connect", "history.jsonl")
+}
+
+// HistoryEventType 历史事件类型
+type HistoryEventType string
+
+const (
+	// HistoryEventState WiFi连接状态变化（From/To为变化前后的网络名称）
+	HistoryEventState HistoryEventType = "state"
+	// HistoryEventConnect 连接尝试（包含结果和耗时）
+	HistoryEventConnect HistoryEventType = "connect"
+	// HistoryEventIP IP地址变化（From/To为变化前后的IP地址）
+	HistoryEventIP HistoryEventType = "ip"
+	// HistoryEventIPv6 IPv6地址变化（From/To为变化前后逗号分隔的地址列表）
+	HistoryEventIPv6 HistoryEventType = "ipv6"
+	// HistoryEventWAN 公网IP变化（From/To为变化前后的公网IP）
+	HistoryEventWAN HistoryEventType = "wan"
+	// HistoryEventConnectivity 互联网连通性变化（From/To为connected或degraded，恢复时包含中断时长）
+	HistoryEventConnectivity HistoryEventType = "connectivity"
+	// HistoryEventPortal 认证页面自动登录（To为认证页面地址，包含结果和耗时）
+	HistoryEventPortal HistoryEventType = "portal"
+	// HistoryEventRoam 切换接入点（From/To为切换前后的BSSID，包含结果和耗时）
+	HistoryEventRoam HistoryEventType = "roam"
+	// HistoryEventDHCP 续租DHCP（From为续租前的地址，To为获取到的地址，包含结果和耗时）
+	HistoryEventDHCP HistoryEventType = "dhcp"
+	// HistoryEventDDNS 更新动态DNS记录（From为记录域名，To为更新后的地址，包含结果和耗时）
+	HistoryEventDDNS HistoryEventType = "ddns"
+	// HistoryEventWired 有线网络连接变化（From/To为变化前后使用的有线接口，断开时为空）
+	HistoryEventWired HistoryEventType = "wired"
+	// HistoryEventAdapter WiFi网卡拔出或插入（From/To为变化前后的接口，拔出时To为空，插入时From为空，恢复时包含离线时长）
+	HistoryEventAdapter HistoryEventType = "adapter"
+	// HistoryEventLinkQuality 链路质量变化（From/To为good或degraded，下降时包含原因，恢复时包含持续时长）
+	HistoryEventLinkQuality HistoryEventType = "link_quality"
+)
+
+// HistoryEvent 一条连接历史记录
+type HistoryEvent struct {
+	Time       time.Time        `json:"time"`
+	Type       HistoryEventType `json:"type"`
+	SSID       string           `json:"ssid,omitempty"`
+	Interface  string           `json:"interface,omitempty"`
+	From       string           `json:"from,omitempty"`
+	To         string           `json:"to,omitempty"`
+	Success    bool             `json:"success,omitempty"`
+	DurationMs int64            `json:"duration_ms,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// HistoryFilter 历史记录查询条件，零值字段表示不限制
+type HistoryFilter struct {
+	Since time.Time
+	Until time.Time
+	SSID  string
+	Types []HistoryEventType
+}
+
+// Match 判断事件是否满足查询条件
+func (f HistoryFilter) Match(event HistoryEvent) bool {
+	if !f.Since.IsZero() && event.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Time.After(f.Until) {
+		return false
+	}
+	// 状态变化事件中，离开或进入该网络都算匹配
+	if f.SSID != "" && event.SSID != f.SSID &&
+		!(event.Type == HistoryEventState && (event.From == f.SSID || event.To == f.SSID)) {
+		return false
+	}
+	if len(f.Types) > 0 {
+		for _, t := range f.Types {
+			if event.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// HistoryStore 仅追加的连接历史存储（JSON Lines格式）
+type HistoryStore struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewHistoryStore 创建新的历史存储
+func NewHistoryStore(path string) *HistoryStore {
+	return &HistoryStore{path: path}
+}
+
+// Append 追加一条历史记录
+func (h *HistoryStore) Append(event HistoryEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化历史记录失败: %v", err)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("创建历史文件目录失败: %v", err)
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开历史文件失败: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入历史记录失败: %v", err)
+	}
+	return nil
+}
+
+// Query 按条件查询历史记录，按写入顺序返回
+func (h *HistoryStore) Query(filter HistoryFilter) ([]HistoryEvent, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	file, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("打开历史文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var events []HistoryEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var event HistoryEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			// 跳过损坏的行（例如程序被强制终止时写了一半）
+			continue
+		}
+		if filter.Match(event) {
+			events = append(events, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史文件失败: %v", err)
+	}
+	return events, nil
+}
+
+// recordHistory 记录一条历史事件，未启用历史记录时忽略
+func recordHistory(event HistoryEvent) {
+	if historyStore == nil {
+		return
+	}
+	if err := historyStore.Append(event); err != nil {
+		log.Printf("记录连接历史失败: %v", err)
+	}
+}
+
+// parseHistoryTime 解析查询时间，支持绝对时间或相对时长（如 24h 表示24小时前）。
+// endOfDay为true时只有日期的时间解析为当天结束，使 -until 2024-01-15 包含当天的记录
+func parseHistoryTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	layouts := []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			if endOfDay && layout == "2006-01-02" {
+				return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间: %s", value)
+}
+
+// runHistoryCommand 执行 history 子命令，查询并输出连接历史
+func runHistoryCommand(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	file := fs.String("file", defaultHistoryFile(), "历史记录文件路径")
+	since := fs.String("since", "", "起始时间（如 2024-01-15、2024-01-15 08:00 或 168h 表示7天前）")
+	until := fs.String("until", "", "结束时间，格式同 -since，只有日期时包含当天")
+	ssid := fs.String("ssid", "", "按WiFi网络名称过滤")
+	types := fs.String("type", "", "按事件类型过滤，多个用逗号分隔（state,connect,ip,ipv6,wan,connectivity,portal,roam,dhcp,ddns,wired,adapter,link_quality）")
+	format := fs.String("format", "table", "输出格式: table、json 或 csv")
+	fs.Parse(args)
+
+	filter := HistoryFilter{SSID: *ssid}
+	var err error
+	if filter.Since, err = parseHistoryTime(*since, false); err != nil {
+		return err
+	}
+	if filter.Until, err = parseHistoryTime(*until, true); err != nil {
+		return err
+	}
+	if *types != "" {
+		for _, t := range strings.Split(*types, ",") {
+			filter.Types = append(filter.Types, HistoryEventType(strings.TrimSpace(t)))
+		}
+	}
+
+	events, err := NewHistoryStore(*file).Query(filter)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "table":
+		return writeHistoryTable(os.Stdout, events)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if events == nil {
+			events = []HistoryEvent{}
+		}
+		return encoder.Encode(events)
+	case "csv":
+		return writeHistoryCSV(os.Stdout, events)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", *format)
+	}
+}
+
+// historyOutcome 返回连接尝试结果的展示文本
+func historyOutcome(event HistoryEvent) string {
+	if event.Type != HistoryEventConnect && event.Type != HistoryEventPortal && event.Type != HistoryEventRoam &&
+		event.Type != HistoryEventDHCP && event.Type != HistoryEventDDNS {
+		return ""
+	}
+	if event.Success {
+		return "成功"
+	}
+	return "失败"
+}
+
+// writeHistoryTable 以表格形式输出历史记录
+func writeHistoryTable(w io.Writer, events []HistoryEvent) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "时间\t类型\t网络\t接口\t从\t到\t结果\t耗时\t错误")
+	for _, event := range events {
+		duration := ""
+		if event.DurationMs > 0 {
+			duration = (time.Duration(event.DurationMs) * time.Millisecond).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			event.Time.Local().Format("2006-01-02 15:04:05"), event.Type, event.SSID, event.Interface,
+			event.From, event.To, historyOutcome(event), duration, event.Error)
+	}
+	return tw.Flush()
+}
+
+// writeHistoryCSV 以CSV形式输出历史记录
+func writeHistoryCSV(w io.Writer, events []HistoryEvent) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"time", "type", "ssid", "interface", "from", "to", "success", "duration_ms", "error"})
+	for _, event := range events {
+		writer.Write([]string{
+			event.Time.Format(time.RFC3339),
+			string(event.Type),
+			event.SSID,
+			event.Interface,
+			event.From,
+			event.To,
+			strconv.FormatBool(event.Success),
+			strconv.FormatInt(event.DurationMs, 10),
+			event.Error,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// IPConfigMethod IP地址获取方式
+type IPConfigMethod string
+
+const (
+	// IPConfigDHCP 通过DHCP自动获取（默认）
+	IPConfigDHCP IPConfigMethod = "dhcp"
+	// IPConfigStatic 使用固定IP地址
+	IPConfigStatic IPConfigMethod = "static"
+)
+
+// IPConfig 受管网络的IPv4地址和DNS配置
+type IPConfig struct {
+	// Method 获取方式: dhcp（默认）或 static
+	Method IPConfigMethod `json:"method,omitempty"`
+	// Address 固定IP地址和前缀长度，如 192.168.1.50/24
+	Address string `json:"address,omitempty"`
+	// Gateway 默认网关
+	Gateway string `json:"gateway,omitempty"`
+	// DNS 自定义DNS服务器，为空时使用DHCP下发的DNS
+	DNS []string `json:"dns,omitempty"`
+	// SearchDomains DNS搜索域
+	SearchDomains []string `json:"search_domains,omitempty"`
+}
+
+// Validate 检查IP配置是否完整有效
+func (c *IPConfig) Validate() error {
+	switch c.Method {
+	case "", IPConfigDHCP:
+		if c.Address != "" || c.Gateway != "" {
+			return fmt.Errorf("DHCP方式不能配置address和gateway，固定IP请将method设置为static")
+		}
+	case IPConfigStatic:
+		ip, _, err := net.ParseCIDR(c.Address)
+		if err != nil || ip.To4() == nil {
+			return fmt.Errorf("固定IP地址格式应为 IPv4地址/前缀长度（如 192.168.1.50/24）: %s", c.Address)
+		}
+		if c.Gateway != "" {
+			if gateway := net.ParseIP(c.Gateway); gateway == nil || gateway.To4() == nil {
+				return fmt.Errorf("网关地址无效: %s", c.Gateway)
+			}
+		}
+	default:
+		return fmt.Errorf("不支持的IP获取方式: %s（可选 dhcp、static）", c.Method)
+	}
+	for _, server := range c.DNS {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("DNS服务器地址无效: %s", server)
+		}
+	}
+	return nil
+}
+
+// IsStatic 是否使用固定IP地址
+func (c *IPConfig) IsStatic() bool {
+	return c.Method == IPConfigStatic
+}
+
+// IP 返回固定IP地址（不含前缀长度）
+func (c *IPConfig) IP() string {
+	ip, _, err := net.ParseCIDR(c.Address)
+	if err != nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// PrefixLength 返回固定IP地址的前缀长度
+func (c *IPConfig) PrefixLength() int {
+	_, ipNet, err := net.ParseCIDR(c.Address)
+	if err != nil {
+		return 0
+	}
+	ones, _ := ipNet.Mask.Size()
+	return ones
+}
+
+// SubnetMask 返回固定IP地址的子网掩码
+func (c *IPConfig) SubnetMask() string {
+	_, ipNet, err := net.ParseCIDR(c.Address)
+	if err != nil {
+		return ""
+	}
+	return net.IP(ipNet.Mask).String()
+}
+
+// String 返回用于日志展示的配置说明
+func (c *IPConfig) String() string {
+	var parts []string
+	if c.IsStatic() {
+		parts = append(parts, "固定IP "+c.Address)
+		if c.Gateway != "" {
+			parts = append(parts, "网关 "+c.Gateway)
+		}
+	} else {
+		parts = append(parts, "DHCP")
+	}
+	if len(c.DNS) > 0 {
+		parts = append(parts, "DNS "+strings.Join(c.DNS, ","))
+	}
+	if len(c.SearchDomains) > 0 {
+		parts = append(parts, "搜索域 "+strings.Join(c.SearchDomains, ","))
+	}
+	return strings.Join(parts, "，")
+}
+
+// isLinkLocalIPv4 是否为链路本地地址（169.254.0.0/16），通常表示未从DHCP获取到地址
+func isLinkLocalIPv4(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && ip.To4() != nil && ip.IsLinkLocalUnicast()
+}
+
+// selectIPv4Address 从接口的多个IPv4地址中选出可用地址，优先返回非链路本地地址
+func selectIPv4Address(addresses []string) string {
+	var linkLocal string
+	for _, address := range addresses {
+		if address == "" || address == "127.0.0.1" {
+			continue
+		}
+		if !isLinkLocalIPv4(address) {
+			return address
+		}
+		if linkLocal == "" {
+			linkLocal = address
+		}
+	}
+	return linkLocal
+}
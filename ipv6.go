@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// IPv6Options IPv6地址过滤选项
+type IPv6Options struct {
+	// IncludeLinkLocal 是否包含链路本地地址（fe80::/10）
+	IncludeLinkLocal bool
+	// IncludeTemporary 是否包含临时隐私地址（RFC 4941）
+	IncludeTemporary bool
+}
+
+// ipv6Candidate 从系统命令输出中解析出的IPv6地址
+type ipv6Candidate struct {
+	address   string
+	temporary bool
+}
+
+// selectIPv6Addresses 按选项过滤IPv6地址，保留全局地址和ULA地址，返回排序后的结果
+func selectIPv6Addresses(candidates []ipv6Candidate, options IPv6Options) []string {
+	seen := make(map[string]bool)
+	var addresses []string
+	for _, candidate := range candidates {
+		// 去掉区域标识和前缀长度 (例如: fe80::1%en0 或 2001:db8::1/64)
+		address := candidate.address
+		if i := strings.IndexAny(address, "%/"); i != -1 {
+			address = address[:i]
+		}
+
+		ip := net.ParseIP(address)
+		if ip == nil || ip.To4() != nil || ip.IsLoopback() || ip.IsMulticast() || ip.IsUnspecified() {
+			continue
+		}
+		if ip.IsLinkLocalUnicast() && !options.IncludeLinkLocal {
+			continue
+		}
+		if candidate.temporary && !options.IncludeTemporary {
+			continue
+		}
+
+		address = ip.String()
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// iwd的D-Bus服务名称、接口和对象路径
+const (
+	iwdService             = "net.connman.iwd"
+	iwdDeviceInterface     = "net.connman.iwd.Device"
+	iwdStationInterface    = "net.connman.iwd.Station"
+	iwdNetworkInterface    = "net.connman.iwd.Network"
+	iwdKnownNetworkIface   = "net.connman.iwd.KnownNetwork"
+	iwdAgentManagerPath    = "/net/connman/iwd"
+	iwdAgentPath           = "/connect/agent"
+	iwdAgentCanceledError  = "net.connman.iwd.Agent.Error.Canceled"
+	iwdAlreadyExistsError  = "net.connman.iwd.AlreadyExists"
+	iwdConnectTimeout      = 60 * time.Second
+	iwdObjectManagerGetAll = "org.freedesktop.DBus.ObjectManager.GetManagedObjects"
+)
+
+// iwdStorageDir iwd保存网络配置的目录，企业级网络的配置文件（.8021x）写入该目录
+var iwdStorageDir = "/var/lib/iwd"
+
+// iwdAgent 为iwd提供WiFi密码的代理，连接前按网卡登记密码，iwd请求密码时按网络所属的网卡返回
+var iwdAgent = struct {
+	sync.Mutex
+	// passphrases 网卡对应的Station对象路径到密码的映射
+	passphrases map[DBusObjectPath]string
+}{passphrases: make(map[DBusObjectPath]string)}
+
+// IWDConnector 通过iwd的D-Bus接口（net.connman.iwd）连接WiFi的Linux连接器。
+// 连接、扫描使用D-Bus接口，地址、网络信息和有线网络检测与nmcli后端相同
+type IWDConnector struct {
+	*LinuxConnector
+}
+
+// NewIWDConnector 创建使用iwd的Linux连接器
+func NewIWDConnector(connector *LinuxConnector) *IWDConnector {
+	return &IWDConnector{LinuxConnector: connector}
+}
+
+// iwdRunning 检查系统总线上是否有iwd服务
+func iwdRunning() bool {
+	bus, err := SystemBus()
+	if err != nil {
+		return false
+	}
+	return bus.NameHasOwner(iwdService)
+}
+
+// iwdObjects 返回iwd的全部对象及其各接口的属性
+func iwdObjects(bus *DBusConn) (map[string]any, error) {
+	reply, err := bus.Call(iwdService, "/", iwdObjectManagerGetAll, "")
+	if err != nil {
+		return nil, fmt.Errorf("读取iwd对象失败: %v", err)
+	}
+	if len(reply) == 0 {
+		return nil, fmt.Errorf("读取iwd对象失败")
+	}
+	objects, _ := reply[0].(map[string]any)
+	return objects, nil
+}
+
+// iwdProperties 返回对象在指定接口上的属性，对象没有该接口时返回nil
+func iwdProperties(object any, iface string) map[string]any {
+	interfaces, _ := object.(map[string]any)
+	properties, _ := interfaces[iface].(map[string]any)
+	return properties
+}
+
+// station 返回网卡对应的Station对象路径
+func (c *IWDConnector) station(bus *DBusConn) (DBusObjectPath, error) {
+	objects, err := iwdObjects(bus)
+	if err != nil {
+		return "", err
+	}
+	for path, object := range objects {
+		device := iwdProperties(object, iwdDeviceInterface)
+		if device == nil || device["Name"] != c.interfaceName {
+			continue
+		}
+		if iwdProperties(object, iwdStationInterface) == nil {
+			return "", fmt.Errorf("iwd网卡 %s 不处于station模式", c.interfaceName)
+		}
+		return DBusObjectPath(path), nil
+	}
+	return "", fmt.Errorf("iwd未管理网卡 %s", c.interfaceName)
+}
+
+// GetCurrentNetwork 实现WiFiConnector接口 - Station状态为connected时返回所连接网络的名称
+func (c *IWDConnector) GetCurrentNetwork() (string, error) {
+	bus, err := SystemBus()
+	if err != nil {
+		return "", err
+	}
+	station, err := c.station(bus)
+	if err != nil {
+		return "", err
+	}
+	properties, err := bus.GetAllProperties(iwdService, station, iwdStationInterface)
+	if err != nil {
+		return "", fmt.Errorf("读取iwd连接状态失败: %v", err)
+	}
+	network, _ := properties["ConnectedNetwork"].(DBusObjectPath)
+	if properties["State"] != "connected" || network == "" {
+		return "", nil
+	}
+	name, err := bus.GetProperty(iwdService, network, iwdNetworkInterface, "Name")
+	if err != nil {
+		return "", fmt.Errorf("读取iwd网络名称失败: %v", err)
+	}
+	ssid, _ := name.(string)
+	return ssid, nil
+}
+
+// GetNetworkInfo 实现WiFiConnector接口 - 网络名称从iwd获取，不依赖iwgetid和nmcli
+func (c *IWDConnector) GetNetworkInfo() (*NetworkInfo, error) {
+	ssid, err := c.GetCurrentNetwork()
+	if err != nil {
+		return nil, err
+	}
+	return c.networkInfo(ssid), nil
+}
+
+// Connect 实现WiFiConnector接口 - 已保存的网络（KnownNetwork）直接连接，需要密码时由代理提供；
+// 已保存的网络连接失败且提供了密码时删除保存的配置后重新连接
+func (c *IWDConnector) Connect(networkName, password string, options ConnectOptions) error {
+	bus, err := SystemBus()
+	if err != nil {
+		return err
+	}
+	station, err := c.station(bus)
+	if err != nil {
+		return err
+	}
+	if options.Enterprise != nil {
+		// iwd不通过代理获取证书等企业级认证配置，需要写入配置文件
+		if err := writeIWDEnterpriseConfig(networkName, password, options); err != nil {
+			return err
+		}
+	}
+	if err := registerIWDAgent(bus); err != nil {
+		return err
+	}
+	iwdAgent.Lock()
+	iwdAgent.passphrases[station] = password
+	iwdAgent.Unlock()
+	defer func() {
+		iwdAgent.Lock()
+		delete(iwdAgent.passphrases, station)
+		iwdAgent.Unlock()
+	}()
+
+	network, known, err := c.findNetwork(bus, station, networkName)
+	if err != nil {
+		return err
+	}
+	if network == "" {
+		if !options.Hidden {
+			return fmt.Errorf("连接WiFi失败: 未找到网络 %s", networkName)
+		}
+		// 隐藏网络不在扫描结果中，需要主动探测
+		_, err = bus.CallWithTimeout(iwdConnectTimeout, iwdService, station, iwdStationInterface+".ConnectHiddenNetwork", "s", networkName)
+	} else {
+		_, err = bus.CallWithTimeout(iwdConnectTimeout, iwdService, network, iwdNetworkInterface+".Connect", "")
+		if err != nil && known != "" && password != "" && options.Enterprise == nil {
+			// 保存的密码可能已过期，删除后使用提供的密码重新连接
+			bus.Call(iwdService, known, iwdKnownNetworkIface+".Forget", "")
+			_, err = bus.CallWithTimeout(iwdConnectTimeout, iwdService, network, iwdNetworkInterface+".Connect", "")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("连接WiFi失败: %v", err)
+	}
+
+	// 等待连接完成并验证连接结果
+	for i := 0; i < 10; i++ { // 最多等待10秒
+		currentNetwork, err := c.GetCurrentNetwork()
+		if err == nil && currentNetwork == networkName {
+			return nil // 连接成功
+		}
+		time.Sleep(1 * time.Second)
+	}
+	return fmt.Errorf("连接超时：无法连接到WiFi网络 '%s'，可能网络不存在或密码错误", networkName)
+}
+
+// findNetwork 查找网卡扫描到的指定网络，返回网络对象路径和已保存网络的对象路径；
+// 未扫描到时先扫描一次，仍未找到时返回空路径
+func (c *IWDConnector) findNetwork(bus *DBusConn, station DBusObjectPath, networkName string) (DBusObjectPath, DBusObjectPath, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt > 0 {
+			c.scan(bus, station)
+		}
+		objects, err := iwdObjects(bus)
+		if err != nil {
+			return "", "", err
+		}
+		for path, object := range objects {
+			network := iwdProperties(object, iwdNetworkInterface)
+			if network == nil || network["Device"] != station || network["Name"] != networkName {
+				continue
+			}
+			known, _ := network["KnownNetwork"].(DBusObjectPath)
+			return DBusObjectPath(path), known, nil
+		}
+	}
+	return "", "", nil
+}
+
+// scan 请求扫描并等待扫描完成，已在扫描时只等待
+func (c *IWDConnector) scan(bus *DBusConn, station DBusObjectPath) {
+	bus.Call(iwdService, station, iwdStationInterface+".Scan", "")
+	for i := 0; i < 15; i++ { // 最多等待15秒
+		time.Sleep(1 * time.Second)
+		scanning, err := bus.GetProperty(iwdService, station, iwdStationInterface, "Scanning")
+		if err != nil || scanning == false {
+			return
+		}
+	}
+}
+
+// registerIWDAgent 向iwd注册密码代理，iwd重启后需要重新注册，已注册时忽略错误
+func registerIWDAgent(bus *DBusConn) error {
+	bus.Export(iwdAgentPath, iwdAgentHandler(bus))
+	_, err := bus.Call(iwdService, iwdAgentManagerPath, "net.connman.iwd.AgentManager.RegisterAgent", "o", DBusObjectPath(iwdAgentPath))
+	if dbusErr, ok := err.(*DBusError); ok && dbusErr.Name == iwdAlreadyExistsError {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("注册iwd密码代理失败: %v", err)
+	}
+	return nil
+}
+
+// iwdAgentHandler 返回密码代理（net.connman.iwd.Agent）的处理函数，只回复iwd服务的调用，
+// 避免总线上的其他程序冒充iwd读取密码
+func iwdAgentHandler(bus *DBusConn) DBusHandler {
+	return func(sender, member string, args []any) (string, []any, error) {
+		if owner, err := bus.GetNameOwner(iwdService); err != nil || sender != owner {
+			return "", nil, &DBusError{Name: "org.freedesktop.DBus.Error.AccessDenied", Message: "调用方不是iwd服务: " + sender}
+		}
+		return handleIWDAgent(member, args)
+	}
+}
+
+// handleIWDAgent 处理iwd对密码代理的调用（net.connman.iwd.Agent），没有登记密码时取消请求
+func handleIWDAgent(member string, args []any) (string, []any, error) {
+	switch member {
+	case "Release", "Cancel":
+		return "", nil, nil
+	case "RequestPassphrase", "RequestUserPassword":
+		if len(args) > 0 {
+			network, _ := args[0].(DBusObjectPath)
+			iwdAgent.Lock()
+			defer iwdAgent.Unlock()
+			for station, password := range iwdAgent.passphrases {
+				// 网络对象是所属Station对象的子路径
+				if password != "" && strings.HasPrefix(string(network), string(station)+"/") {
+					return "s", []any{password}, nil
+				}
+			}
+		}
+	}
+	return "", nil, &DBusError{Name: iwdAgentCanceledError, Message: "未设置WiFi密码"}
+}
+
+// writeIWDEnterpriseConfig 写入企业级（802.1X）网络的iwd配置文件，iwd会自动加载新的配置文件
+func writeIWDEnterpriseConfig(networkName, password string, options ConnectOptions) error {
+	creds := options.Enterprise
+	method := strings.ToUpper(string(creds.EAPMethod))
+	lines := []string{"[Security]", "EAP-Method=" + method}
+	identity := creds.Identity
+	if creds.EAPMethod != EAPTLS && creds.AnonymousIdentity != "" {
+		identity = creds.AnonymousIdentity
+	}
+	lines = append(lines, "EAP-Identity="+identity)
+	prefix := "EAP-" + method + "-"
+	if creds.CACert != "" {
+		lines = append(lines, prefix+"CACert="+creds.CACert)
+	}
+	if creds.ServerName != "" {
+		lines = append(lines, prefix+"ServerDomainMask="+creds.ServerName)
+	}
+
+	if creds.EAPMethod == EAPTLS {
+		lines = append(lines, prefix+"ClientCert="+creds.ClientCert)
+		// 未单独配置私钥时客户端证书文件中包含私钥
+		key := creds.PrivateKey
+		if key == "" {
+			key = creds.ClientCert
+		}
+		lines = append(lines, prefix+"ClientKey="+key)
+		if creds.PrivateKeyPassword != "" {
+			lines = append(lines, prefix+"ClientKeyPassphrase="+creds.PrivateKeyPassword)
+		}
+	} else {
+		if creds.Password != "" {
+			password = creds.Password
+		}
+		lines = append(lines,
+			prefix+"Phase2-Method="+iwdPhase2Method(creds.EAPMethod, creds.InnerMethod()),
+			prefix+"Phase2-Identity="+creds.Identity)
+		if password != "" {
+			lines = append(lines, prefix+"Phase2-Password="+password)
+		}
+	}
+	if options.Hidden {
+		lines = append(lines, "", "[Settings]", "Hidden=true")
+	}
+
+	path := filepath.Join(iwdStorageDir, iwdStorageName(networkName)+".8021x")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return fmt.Errorf("写入iwd网络配置失败: %v", err)
+	}
+	return nil
+}
+
+// iwdPhase2Method 返回iwd配置文件中的内层认证方式，TTLS的非EAP内层认证使用Tunneled-前缀
+func iwdPhase2Method(method EAPMethod, inner string) string {
+	if method == EAPTTLS {
+		switch inner {
+		case "pap":
+			return "Tunneled-PAP"
+		case "chap":
+			return "Tunneled-CHAP"
+		case "mschap":
+			return "Tunneled-MSCHAP"
+		case "mschapv2":
+			return "Tunneled-MSCHAPv2"
+		}
+	}
+	return strings.ToUpper(inner)
+}
+
+// iwdStorageName 返回iwd配置文件名（不含扩展名），只包含字母、数字、空格、-和_的SSID直接使用，否则使用=加十六进制
+func iwdStorageName(ssid string) string {
+	for _, r := range ssid {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == ' ' || r == '-' || r == '_') {
+			return fmt.Sprintf("=%x", ssid)
+		}
+	}
+	return ssid
+}
+
+// Scan 实现WiFiConnector接口 - iwd只提供每个网络的最强信号，结果不包含BSSID和信道
+func (c *IWDConnector) Scan() ([]ScanResult, error) {
+	bus, err := SystemBus()
+	if err != nil {
+		return nil, err
+	}
+	station, err := c.station(bus)
+	if err != nil {
+		return nil, err
+	}
+	c.scan(bus, station)
+
+	// 每项为 (网络对象路径, 信号强度)，信号强度单位为0.01dBm
+	reply, err := bus.Call(iwdService, station, iwdStationInterface+".GetOrderedNetworks", "")
+	if err != nil {
+		return nil, fmt.Errorf("扫描WiFi网络失败: %v", err)
+	}
+	if len(reply) == 0 {
+		return nil, nil
+	}
+	items, _ := reply[0].([]any)
+	var results []ScanResult
+	for _, item := range items {
+		fields, _ := item.([]any)
+		if len(fields) < 2 {
+			continue
+		}
+		path, _ := fields[0].(DBusObjectPath)
+		signal, _ := fields[1].(int16)
+		properties, err := bus.GetAllProperties(iwdService, path, iwdNetworkInterface)
+		if err != nil {
+			continue
+		}
+		result := ScanResult{}
+		result.SSID, _ = properties["Name"].(string)
+		networkType, _ := properties["Type"].(string)
+		result.Security = iwdSecurity(networkType)
+		result.SetSignalDBm(int(signal) / 100)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// iwdSecurity 将iwd的网络类型转换为安全类型说明，psk类型的网络由iwd自动选择WPA2或WPA3
+func iwdSecurity(networkType string) string {
+	switch networkType {
+	case "psk":
+		return "WPA2"
+	case "8021x":
+		return "802.1X"
+	case "wep":
+		return "WEP"
+	default:
+		return "Open"
+	}
+}
+
+// ConnectBSSID 实现WiFiConnector接口 - iwd自行选择和切换接入点，不支持指定BSSID
+func (c *IWDConnector) ConnectBSSID(networkName, bssid, password string) error {
+	return ErrBSSIDUnsupported
+}
+
+// ApplyIPConfig 实现WiFiConnector接口 - iwd的IP配置写在网络配置文件中，不支持在运行时修改
+func (c *IWDConnector) ApplyIPConfig(config *IPConfig) error {
+	return fmt.Errorf("iwd后端不支持修改IP配置，请在iwd网络配置文件的[IPv4]中或systemd-networkd中配置")
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/hex"
+	"net"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const fakeIWDStation DBusObjectPath = "/net/connman/iwd/0/3"
+
+// fakeIWDNetwork 模拟的iwd网络
+type fakeIWDNetwork struct {
+	name        string
+	networkType string
+	// signal 信号强度，单位为0.01dBm
+	signal int16
+	// password 接入点的实际密码，saved为已保存网络（KnownNetwork）中的密码
+	password string
+	known    bool
+	saved    string
+	// scanned 是否已在扫描结果中，为false时扫描后才出现
+	scanned bool
+	hidden  bool
+}
+
+func (n *fakeIWDNetwork) path() DBusObjectPath {
+	return fakeIWDStation + "/" + DBusObjectPath(hex.EncodeToString([]byte(n.name))+"_"+n.networkType)
+}
+
+func (n *fakeIWDNetwork) knownPath() DBusObjectPath {
+	return "/net/connman/iwd/" + DBusObjectPath(hex.EncodeToString([]byte(n.name))+"_"+n.networkType)
+}
+
+// fakeIWD 模拟iwd服务，导出wlan0的Station、扫描到的Network、KnownNetwork和AgentManager对象，
+// 连接需要密码时向注册的代理请求密码
+type fakeIWD struct {
+	bus      *DBusConn
+	mutex    sync.Mutex
+	calls    []string
+	networks []*fakeIWDNetwork
+	// connected 当前连接的网络
+	connected *fakeIWDNetwork
+	agent     DBusObjectPath
+}
+
+// startFakeIWD 启动模拟的iwd服务，并将其作为系统总线上的net.connman.iwd
+func startFakeIWD(t *testing.T, networks ...*fakeIWDNetwork) *fakeIWD {
+	t.Helper()
+	f := &fakeIWD{bus: newTestSystemBus(t, iwdService), networks: networks}
+	paths := []DBusObjectPath{"/", iwdAgentManagerPath, fakeIWDStation}
+	for _, n := range networks {
+		paths = append(paths, n.path(), n.knownPath())
+	}
+	for _, path := range paths {
+		f.bus.Export(path, func(sender, member string, args []any) (string, []any, error) {
+			return f.handle(path, member, args)
+		})
+	}
+	return f
+}
+
+// takeCalls 返回并清空收到的调用，读取对象和属性的调用除外
+func (f *fakeIWD) takeCalls() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+func (f *fakeIWD) record(call string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeIWD) handle(path DBusObjectPath, member string, args []any) (string, []any, error) {
+	if member != "GetManagedObjects" && member != "Get" && member != "GetAll" {
+		f.record(member)
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	switch member {
+	case "GetManagedObjects":
+		return "a{oa{sa{sv}}}", []any{f.objects()}, nil
+	case "Get", "GetAll":
+		properties := f.objects()[path][args[0].(string)]
+		if member == "GetAll" {
+			return "a{sv}", []any{properties}, nil
+		}
+		if value, ok := properties[args[1].(string)]; ok {
+			return "v", []any{value}, nil
+		}
+		return "", nil, &DBusError{Name: "org.freedesktop.DBus.Error.InvalidArgs", Message: args[1].(string)}
+	case "RegisterAgent":
+		if f.agent != "" {
+			return "", nil, &DBusError{Name: iwdAlreadyExistsError}
+		}
+		f.agent = args[0].(DBusObjectPath)
+		return "", nil, nil
+	case "Scan":
+		for _, n := range f.networks {
+			n.scanned = n.scanned || !n.hidden
+		}
+		return "", nil, nil
+	case "GetOrderedNetworks":
+		var networks []any
+		for _, n := range f.networks {
+			if n.scanned {
+				networks = append(networks, []any{n.path(), n.signal})
+			}
+		}
+		return "a(on)", []any{networks}, nil
+	case "Forget":
+		for _, n := range f.networks {
+			if n.knownPath() == path {
+				n.known, n.saved = false, ""
+			}
+		}
+		return "", nil, nil
+	case "Connect":
+		for _, n := range f.networks {
+			if n.path() == path {
+				return "", nil, f.connect(n)
+			}
+		}
+	case "ConnectHiddenNetwork":
+		for _, n := range f.networks {
+			if n.hidden && n.name == args[0] {
+				return "", nil, f.connect(n)
+			}
+		}
+		return "", nil, &DBusError{Name: "net.connman.iwd.NotFound"}
+	}
+	return "", nil, &DBusError{Name: "org.freedesktop.DBus.Error.UnknownMethod", Message: member}
+}
+
+// connect 连接网络，未保存的加密网络向代理请求密码，连接成功后保存网络
+func (f *fakeIWD) connect(n *fakeIWDNetwork) error {
+	password := n.saved
+	if n.networkType == "psk" && !n.known {
+		// 请求密码时不持有锁，代理会回调查询名称所有者
+		f.mutex.Unlock()
+		f.record("RequestPassphrase")
+		reply, err := f.bus.Call("", f.agent, "net.connman.iwd.Agent.RequestPassphrase", "o", n.path())
+		f.mutex.Lock()
+		if err != nil {
+			return &DBusError{Name: "net.connman.iwd.Aborted", Message: err.Error()}
+		}
+		password, _ = reply[0].(string)
+	}
+	if n.networkType == "psk" && password != n.password {
+		return &DBusError{Name: "net.connman.iwd.Failed", Message: "Operation failed"}
+	}
+	n.known, n.saved, n.scanned = true, password, true
+	f.connected = n
+	return nil
+}
+
+// objects 返回GetManagedObjects的结果
+func (f *fakeIWD) objects() map[DBusObjectPath]map[string]map[string]DBusVariant {
+	station := map[string]DBusVariant{
+		"State":    {Signature: "s", Value: "disconnected"},
+		"Scanning": {Signature: "b", Value: false},
+	}
+	if f.connected != nil {
+		station["State"] = DBusVariant{Signature: "s", Value: "connected"}
+		station["ConnectedNetwork"] = DBusVariant{Signature: "o", Value: f.connected.path()}
+	}
+	objects := map[DBusObjectPath]map[string]map[string]DBusVariant{
+		fakeIWDStation: {
+			iwdDeviceInterface:  {"Name": {Signature: "s", Value: "wlan0"}},
+			iwdStationInterface: station,
+		},
+		"/net/connman/iwd/0/4": {iwdDeviceInterface: {"Name": {Signature: "s", Value: "wlan1"}}, iwdStationInterface: {}},
+	}
+	for _, n := range f.networks {
+		if n.known {
+			objects[n.knownPath()] = map[string]map[string]DBusVariant{
+				iwdKnownNetworkIface: {"Name": {Signature: "s", Value: n.name}},
+			}
+		}
+		if !n.scanned {
+			continue
+		}
+		network := map[string]DBusVariant{
+			"Name":   {Signature: "s", Value: n.name},
+			"Type":   {Signature: "s", Value: n.networkType},
+			"Device": {Signature: "o", Value: fakeIWDStation},
+		}
+		if n.known {
+			network["KnownNetwork"] = DBusVariant{Signature: "o", Value: n.knownPath()}
+		}
+		objects[n.path()] = map[string]map[string]DBusVariant{iwdNetworkInterface: network}
+	}
+	return objects
+}
+
+func TestIWDAgentRequestPassphrase(t *testing.T) {
+	// 模拟iwd通过总线调用本程序导出的密码代理
+	agentSide, iwdSide := net.Pipe()
+	agent := newTestDBusConn(t, agentSide)
+	iwd := newTestDBusConn(t, iwdSide)
+	iwd.name = ":1.5"
+	var owner atomic.Value
+	owner.Store(iwd.name)
+	// 直接连接时没有总线，由iwd一端回答名称所有者的查询
+	iwd.Export("/org/freedesktop/DBus", func(sender, member string, args []any) (string, []any, error) {
+		return "s", []any{owner.Load()}, nil
+	})
+	agent.Export(iwdAgentPath, iwdAgentHandler(agent))
+
+	iwdAgent.Lock()
+	previous := iwdAgent.passphrases
+	iwdAgent.passphrases = map[DBusObjectPath]string{
+		"/net/connman/iwd/0/3": "secret",
+		"/net/connman/iwd/0/5": "",
+	}
+	iwdAgent.Unlock()
+	t.Cleanup(func() {
+		iwdAgent.Lock()
+		iwdAgent.passphrases = previous
+		iwdAgent.Unlock()
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		network DBusObjectPath
+		want    []any
+	}{
+		{"网络属于登记了密码的网卡", "RequestPassphrase", "/net/connman/iwd/0/3/4f6666696365_psk", []any{"secret"}},
+		{"其他网卡的网络", "RequestPassphrase", "/net/connman/iwd/0/4/4f6666696365_psk", nil},
+		{"路径前缀相同但不是子路径", "RequestPassphrase", "/net/connman/iwd/0/30/4f6666696365_psk", nil},
+		{"网卡没有登记密码", "RequestPassphrase", "/net/connman/iwd/0/5/4f6666696365_psk", nil},
+		{"企业级网络的用户密码", "RequestUserPassword", "/net/connman/iwd/0/3/436f7270_8021x", []any{"secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signature, args := "o", []any{tt.network}
+			if tt.method == "RequestUserPassword" {
+				signature, args = "os", []any{tt.network, "alice"}
+			}
+			reply, err := iwd.Call("", iwdAgentPath, "net.connman.iwd.Agent."+tt.method, signature, args...)
+			if tt.want == nil {
+				if dbusErr, ok := err.(*DBusError); !ok || dbusErr.Name != iwdAgentCanceledError {
+					t.Fatalf("错误 = %v，期望取消请求", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("请求密码失败: %v", err)
+			}
+			if !reflect.DeepEqual(reply, tt.want) {
+				t.Fatalf("回复 = %#v，期望 %#v", reply, tt.want)
+			}
+		})
+	}
+
+	if _, err := iwd.Call("", iwdAgentPath, "net.connman.iwd.Agent.Cancel", "s", "user-canceled"); err != nil {
+		t.Fatalf("Cancel调用失败: %v", err)
+	}
+
+	// 调用方不是iwd服务时拒绝提供密码
+	owner.Store(":1.9")
+	_, err := iwd.Call("", iwdAgentPath, "net.connman.iwd.Agent.RequestPassphrase", "o", DBusObjectPath("/net/connman/iwd/0/3/4f6666696365_psk"))
+	if dbusErr, ok := err.(*DBusError); !ok || dbusErr.Name != "org.freedesktop.DBus.Error.AccessDenied" {
+		t.Fatalf("错误 = %v，期望拒绝访问", err)
+	}
+}
+
+func TestIWDConnect(t *testing.T) {
+	office := &fakeIWDNetwork{name: "Office", networkType: "psk", password: "secret", scanned: true}
+	home := &fakeIWDNetwork{name: "Home", networkType: "psk", password: "new", known: true, saved: "old", scanned: true}
+	cafe := &fakeIWDNetwork{name: "Cafe", networkType: "open"}
+	lab := &fakeIWDNetwork{name: "Lab", networkType: "psk", password: "hidden", hidden: true}
+	fake := startFakeIWD(t, office, home, cafe, lab)
+	connector := NewIWDConnector(&LinuxConnector{interfaceName: "wlan0"})
+
+	if current, err := connector.GetCurrentNetwork(); err != nil || current != "" {
+		t.Fatalf("未连接时当前网络 = %q, %v", current, err)
+	}
+
+	steps := []struct {
+		name     string
+		network  string
+		password string
+		options  ConnectOptions
+		want     []string
+		wantErr  bool
+	}{
+		{"未保存的网络由代理提供密码", "Office", "secret", ConnectOptions{}, []string{"RegisterAgent", "Connect", "RequestPassphrase"}, false},
+		{"已保存的网络直接连接", "Office", "", ConnectOptions{}, []string{"RegisterAgent", "Connect"}, false},
+		{"已保存的密码错误时删除后重新连接", "Home", "new", ConnectOptions{}, []string{"RegisterAgent", "Connect", "Forget", "Connect", "RequestPassphrase"}, false},
+		{"未扫描到的网络先扫描", "Cafe", "", ConnectOptions{}, []string{"RegisterAgent", "Scan", "Connect"}, false},
+		{"隐藏网络", "Lab", "hidden", ConnectOptions{Hidden: true}, []string{"RegisterAgent", "Scan", "ConnectHiddenNetwork", "RequestPassphrase"}, false},
+		{"未找到网络", "Missing", "", ConnectOptions{}, []string{"RegisterAgent", "Scan"}, true},
+	}
+	for _, step := range steps {
+		err := connector.Connect(step.network, step.password, step.options)
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: 错误 = %v，期望出错: %v", step.name, err, step.wantErr)
+		}
+		if got := fake.takeCalls(); !slices.Equal(got, step.want) {
+			t.Fatalf("%s: 调用 = %q\n期望 %q", step.name, got, step.want)
+		}
+		if step.wantErr {
+			continue
+		}
+		if current, err := connector.GetCurrentNetwork(); err != nil || current != step.network {
+			t.Fatalf("%s: 当前网络 = %q, %v", step.name, current, err)
+		}
+	}
+	if home.saved != "new" {
+		t.Fatalf("Home保存的密码 = %q，期望 new", home.saved)
+	}
+
+	// 未保存的网络密码错误时连接失败，不删除配置
+	office.known, office.saved = false, ""
+	if err := connector.Connect("Office", "wrong", ConnectOptions{}); err == nil {
+		t.Fatalf("密码错误时期望连接失败")
+	}
+	if got, want := fake.takeCalls(), []string{"RegisterAgent", "Connect", "RequestPassphrase"}; !slices.Equal(got, want) {
+		t.Fatalf("调用 = %q\n期望 %q", got, want)
+	}
+}
+
+func TestIWDScan(t *testing.T) {
+	fake := startFakeIWD(t,
+		&fakeIWDNetwork{name: "Office", networkType: "psk", signal: -5200, scanned: true},
+		&fakeIWDNetwork{name: "Corp", networkType: "8021x", signal: -7000},
+		&fakeIWDNetwork{name: "Cafe", networkType: "open", signal: -8000, scanned: true},
+	)
+	connector := NewIWDConnector(&LinuxConnector{interfaceName: "wlan0"})
+	results, err := connector.Scan()
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if got := fake.takeCalls(); !slices.Equal(got, []string{"Scan", "GetOrderedNetworks"}) {
+		t.Fatalf("调用 = %q", got)
+	}
+	want := []struct {
+		ssid, security string
+		signal         int
+	}{{"Office", "WPA2", -52}, {"Corp", "802.1X", -70}, {"Cafe", "Open", -80}}
+	if len(results) != len(want) {
+		t.Fatalf("扫描结果 = %+v，期望%d条", results, len(want))
+	}
+	for i, w := range want {
+		if r := results[i]; r.SSID != w.ssid || r.Security != w.security || r.SignalDBm != w.signal {
+			t.Fatalf("第%d条扫描结果 = %+v，期望 %+v", i+1, r, w)
+		}
+	}
+
+	if _, err := NewIWDConnector(&LinuxConnector{interfaceName: "wlan2"}).Scan(); err == nil {
+		t.Fatalf("iwd未管理的网卡期望扫描失败")
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LinkQualityThresholds 链路质量告警阈值
+type LinkQualityThresholds struct {
+	// MaxLatency 平均延迟上限
+	MaxLatency time.Duration
+	// MaxLossPercent 丢包率上限（百分比）
+	MaxLossPercent float64
+	// Sustain 超过阈值持续多久才认为链路质量下降
+	Sustain time.Duration
+}
+
+// LinkTargetStats 单个探测目标的滚动统计
+type LinkTargetStats struct {
+	Target      string  `json:"target"`
+	Sent        int     `json:"sent"`
+	Received    int     `json:"received"`
+	LossPercent float64 `json:"loss_percent"`
+	AvgMs       float64 `json:"avg_ms"`
+	MinMs       float64 `json:"min_ms"`
+	MaxMs       float64 `json:"max_ms"`
+	JitterMs    float64 `json:"jitter_ms"`
+	LastError   string  `json:"last_error,omitempty"`
+	// samples 最近的探测结果，负数表示丢包
+	samples []float64
+}
+
+// add 追加一个探测结果并重新计算统计值，window为保留的样本数量
+func (s *LinkTargetStats) add(rttMs float64, window int) {
+	s.samples = append(s.samples, rttMs)
+	if len(s.samples) > window {
+		s.samples = s.samples[len(s.samples)-window:]
+	}
+
+	s.Sent, s.Received = len(s.samples), 0
+	s.AvgMs, s.MinMs, s.MaxMs, s.JitterMs = 0, 0, 0, 0
+	var sum, jitterSum float64
+	var jitterCount int
+	previous := -1.0
+	for _, sample := range s.samples {
+		if sample < 0 {
+			continue
+		}
+		if s.Received == 0 || sample < s.MinMs {
+			s.MinMs = sample
+		}
+		if sample > s.MaxMs {
+			s.MaxMs = sample
+		}
+		s.Received++
+		sum += sample
+		// 抖动为相邻两次成功探测延迟差的平均值
+		if previous >= 0 {
+			jitterSum += math.Abs(sample - previous)
+			jitterCount++
+		}
+		previous = sample
+	}
+	if s.Received > 0 {
+		s.AvgMs = sum / float64(s.Received)
+	}
+	if jitterCount > 0 {
+		s.JitterMs = jitterSum / float64(jitterCount)
+	}
+	s.LossPercent = float64(s.Sent-s.Received) * 100 / float64(s.Sent)
+}
+
+// LinkQualityStatus 链路质量状态
+type LinkQualityStatus struct {
+	Degraded      bool              `json:"degraded"`
+	DegradedSince time.Time         `json:"degraded_since,omitzero"`
+	Reason        string            `json:"reason,omitempty"`
+	LastCheck     time.Time         `json:"last_check,omitzero"`
+	Targets       []LinkTargetStats `json:"targets"`
+}
+
+// LinkQualityMonitor 周期性探测网关和指定目标的延迟、抖动和丢包
+type LinkQualityMonitor struct {
+	target     string
+	network    func() *NetworkInfo
+	window     int
+	timeout    time.Duration
+	thresholds LinkQualityThresholds
+	stats      map[string]*LinkTargetStats
+	// breachSince 开始超过阈值的时间，未超过时为零值
+	breachSince time.Time
+	status      LinkQualityStatus
+	mutex       sync.RWMutex
+}
+
+// NewLinkQualityMonitor 创建链路质量监控器，network返回当前网络信息（用于获取默认网关），target为额外的探测目标（可为空）
+func NewLinkQualityMonitor(network func() *NetworkInfo, target string, window int, thresholds LinkQualityThresholds) *LinkQualityMonitor {
+	if window < 1 {
+		window = 1
+	}
+	return &LinkQualityMonitor{
+		target:     target,
+		network:    network,
+		window:     window,
+		timeout:    2 * time.Second,
+		thresholds: thresholds,
+		stats:      make(map[string]*LinkTargetStats),
+		status:     LinkQualityStatus{Targets: []LinkTargetStats{}},
+	}
+}
+
+// Status 获取链路质量状态
+func (l *LinkQualityMonitor) Status() LinkQualityStatus {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	status := l.status
+	status.Targets = append([]LinkTargetStats{}, l.status.Targets...)
+	return status
+}
+
+// Run 按间隔持续探测
+func (l *LinkQualityMonitor) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		l.probe()
+		<-ticker.C
+	}
+}
+
+// probe 并发探测所有目标一次，更新统计并判断链路质量
+func (l *LinkQualityMonitor) probe() {
+	var targets []string
+	networkName := ""
+	if info := l.network(); info != nil {
+		networkName = info.SSID
+		if info.Gateway != "" {
+			targets = append(targets, info.Gateway)
+		}
+	}
+	if l.target != "" {
+		targets = append(targets, l.target)
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	results := make([]float64, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			rtt, err := pingHost(target, l.timeout)
+			results[i], errs[i] = float64(rtt.Microseconds())/1000, err
+			if err != nil {
+				results[i] = -1
+			}
+		}(i, target)
+	}
+	wg.Wait()
+
+	l.mutex.Lock()
+	current := make([]LinkTargetStats, 0, len(targets))
+	for i, target := range targets {
+		stats, ok := l.stats[target]
+		if !ok {
+			stats = &LinkTargetStats{Target: target}
+			l.stats[target] = stats
+		}
+		stats.add(results[i], l.window)
+		stats.LastError = ""
+		if errs[i] != nil {
+			stats.LastError = errs[i].Error()
+		}
+		current = append(current, *stats)
+	}
+	// 网关变化后旧网关的统计不再展示，也不参与判断
+	for target := range l.stats {
+		if !containsString(targets, target) {
+			delete(l.stats, target)
+		}
+	}
+	now := time.Now()
+	l.status.LastCheck = now
+	l.status.Targets = current
+	reason := l.breachReason(current)
+	transition := l.updateState(now, reason)
+	status := l.status
+	l.mutex.Unlock()
+
+	switch transition {
+	case "degraded":
+		log.Printf("链路质量下降: %s", status.Reason)
+		recordHistory(HistoryEvent{Type: HistoryEventLinkQuality, SSID: networkName, From: "good", To: "degraded", Error: status.Reason})
+		if enableNotification && feishuNotifier != nil {
+			feishuNotifier.SendLinkQualityDegradedNotificationAsync(networkName, status.Reason, status.Targets)
+		}
+	case "restored":
+		duration := now.Sub(status.DegradedSince)
+		log.Printf("链路质量已恢复，持续时长: %s", duration.Round(time.Second))
+		recordHistory(HistoryEvent{Type: HistoryEventLinkQuality, SSID: networkName, From: "degraded", To: "good", DurationMs: duration.Milliseconds()})
+		if enableNotification && feishuNotifier != nil {
+			feishuNotifier.SendLinkQualityRestoredNotificationAsync(networkName, duration, status.Targets)
+		}
+	}
+}
+
+// breachReason 返回超过阈值的说明，未超过时返回空字符串
+func (l *LinkQualityMonitor) breachReason(stats []LinkTargetStats) string {
+	var reasons []string
+	for _, s := range stats {
+		if s.LossPercent > l.thresholds.MaxLossPercent {
+			reasons = append(reasons, fmt.Sprintf("%s 丢包率 %.0f%%", s.Target, s.LossPercent))
+		}
+		if l.thresholds.MaxLatency > 0 && s.Received > 0 && s.AvgMs > float64(l.thresholds.MaxLatency.Milliseconds()) {
+			reasons = append(reasons, fmt.Sprintf("%s 平均延迟 %.0fms", s.Target, s.AvgMs))
+		}
+	}
+	return strings.Join(reasons, "，")
+}
+
+// updateState 根据是否超过阈值更新状态，返回状态变化（degraded、restored或空）
+func (l *LinkQualityMonitor) updateState(now time.Time, reason string) string {
+	if reason == "" {
+		l.breachSince = time.Time{}
+		if !l.status.Degraded {
+			return ""
+		}
+		l.status.Degraded = false
+		l.status.Reason = ""
+		return "restored"
+	}
+
+	if l.breachSince.IsZero() {
+		l.breachSince = now
+	}
+	if l.status.Degraded {
+		l.status.Reason = reason
+		return ""
+	}
+	// 持续超过阈值才认为链路质量下降，避免偶发波动导致误报
+	if now.Sub(l.breachSince) < l.thresholds.Sustain {
+		return ""
+	}
+	l.status.Degraded = true
+	l.status.DegradedSince = l.breachSince
+	l.status.Reason = reason
+	return "degraded"
+}
+
+// containsString 检查切片中是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatLinkStats 返回用于通知展示的多行统计文本
+func formatLinkStats(stats []LinkTargetStats) string {
+	var lines []string
+	for _, s := range stats {
+		lines = append(lines, fmt.Sprintf("%s：延迟 %.1fms（%.1f~%.1f），抖动 %.1fms，丢包 %.0f%%（%d/%d）",
+			s.Target, s.AvgMs, s.MinMs, s.MaxMs, s.JitterMs, s.LossPercent, s.Sent-s.Received, s.Sent))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"fmt"
+	"net"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -23,19 +27,160 @@ func NewLinuxConnector() (*LinuxConnector, error) {
 	return connector, nil
 }
 
-// detectInterface Linux平台的WiFi接口检测
+// LinuxBackend Linux平台连接WiFi使用的后端
+type LinuxBackend string
+
+const (
+	// LinuxBackendAuto 按NetworkManager、iwd、wpa_supplicant控制接口的顺序选择正在运行的后端（默认）
+	LinuxBackendAuto LinuxBackend = "auto"
+	// LinuxBackendNetworkManager 通过NetworkManager的D-Bus接口连接，无法访问D-Bus时使用nmcli
+	LinuxBackendNetworkManager LinuxBackend = "networkmanager"
+	// LinuxBackendNmcli 通过nmcli由NetworkManager连接
+	LinuxBackendNmcli LinuxBackend = "nmcli"
+	// LinuxBackendWPASupplicant 直接通过wpa_supplicant控制接口连接
+	LinuxBackendWPASupplicant LinuxBackend = "wpa_supplicant"
+	// LinuxBackendIWD 通过iwd的D-Bus接口连接
+	LinuxBackendIWD LinuxBackend = "iwd"
+)
+
+// ParseLinuxBackend 解析Linux连接后端
+func ParseLinuxBackend(value string) (LinuxBackend, error) {
+	switch backend := LinuxBackend(strings.ToLower(strings.TrimSpace(value))); backend {
+	case "", LinuxBackendAuto:
+		return LinuxBackendAuto, nil
+	case LinuxBackendNetworkManager, LinuxBackendNmcli, LinuxBackendWPASupplicant, LinuxBackendIWD:
+		return backend, nil
+	default:
+		return LinuxBackendAuto, fmt.Errorf("不支持的Linux连接后端: %s（可选 auto、networkmanager、nmcli、wpa_supplicant、iwd）", value)
+	}
+}
+
+// newLinuxBackend 按 -linux-backend 为网卡选择连接后端
+func newLinuxBackend(connector *LinuxConnector) WiFiConnector {
+	switch linuxBackend {
+	case LinuxBackendNetworkManager:
+		return NewNMConnector(connector)
+	case LinuxBackendNmcli:
+		return connector
+	case LinuxBackendWPASupplicant:
+		return NewWPASupplicantConnector(connector)
+	case LinuxBackendIWD:
+		return NewIWDConnector(connector)
+	}
+	if networkManagerDBusRunning() {
+		return NewNMConnector(connector)
+	}
+	if networkManagerRunning() {
+		return connector
+	}
+	if iwdRunning() {
+		return NewIWDConnector(connector)
+	}
+	if _, err := os.Stat(wpaControlPath(connector.interfaceName)); err == nil {
+		return NewWPASupplicantConnector(connector)
+	}
+	return connector
+}
+
+// networkManagerRunning 检查nmcli是否可用且NetworkManager正在运行
+func networkManagerRunning() bool {
+	if _, err := exec.LookPath("nmcli"); err != nil {
+		return false
+	}
+	output, err := exec.Command("nmcli", "-t", "-f", "RUNNING", "general").Output()
+	return err == nil && strings.TrimSpace(string(output)) == "running"
+}
+
+// sysfsNetDir 内核导出网络接口信息的sysfs目录
+var sysfsNetDir = "/sys/class/net"
+
+// detectInterface Linux平台的WiFi接口检测，有多个WiFi网卡时选择排序最靠前的
 func (l *LinuxConnector) detectInterface() (string, error) {
+	interfaces, _ := listLinuxWiFiInterfaces()
+	if len(interfaces) == 0 {
+		return "", fmt.Errorf("未找到WiFi网络接口")
+	}
+	return interfaces[0], nil
+}
+
+// listLinuxWiFiInterfaces 列出全部WiFi接口，优先从sysfs检测，sysfs不可用时依次使用 iw dev 和常见接口名称
+func listLinuxWiFiInterfaces() ([]string, error) {
+	if interfaces := sysfsWiFiInterfaces(sysfsNetDir); len(interfaces) > 0 {
+		return interfaces, nil
+	}
+
+	var interfaces []string
+	if output, err := exec.Command("iw", "dev").Output(); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			if name, ok := strings.CutPrefix(strings.TrimSpace(line), "Interface "); ok {
+				interfaces = append(interfaces, strings.TrimSpace(name))
+			}
+		}
+	}
+	if len(interfaces) > 0 {
+		return interfaces, nil
+	}
+
 	// 尝试常见的WiFi接口名称
-	commonInterfaces := []string{"wlan0", "wlp2s0", "wlp3s0", "wlo1"}
-	for _, iface := range commonInterfaces {
+	for _, iface := range []string{"wlan0", "wlp2s0", "wlp3s0", "wlo1"} {
 		// 检查接口是否存在
-		cmd := exec.Command("ip", "link", "show", iface)
-		if err := cmd.Run(); err == nil {
-			return iface, nil
+		if err := exec.Command("ip", "link", "show", iface).Run(); err == nil {
+			interfaces = append(interfaces, iface)
+		}
+	}
+	return interfaces, nil
+}
+
+// sysfsWiFiInterfaces 列出sysfs中的WiFi接口（包含 wireless 或 phy80211 目录），
+// 已启用（operstate为up）的排在前面，其次是绑定了驱动的物理网卡，同等条件下按名称排序；忽略监听模式等非以太网类型的接口
+func sysfsWiFiInterfaces(root string) []string {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	type candidate struct {
+		name   string
+		up     bool
+		driver bool
+	}
+	var candidates []candidate
+	for _, entry := range entries {
+		dir := filepath.Join(root, entry.Name())
+		if !sysfsExists(filepath.Join(dir, "wireless")) && !sysfsExists(filepath.Join(dir, "phy80211")) {
+			continue
 		}
+		// 类型1为以太网帧（station模式），监听模式的接口类型为803
+		if data, err := os.ReadFile(filepath.Join(dir, "type")); err == nil && strings.TrimSpace(string(data)) != "1" {
+			continue
+		}
+		operstate, _ := os.ReadFile(filepath.Join(dir, "operstate"))
+		candidates = append(candidates, candidate{
+			name:   entry.Name(),
+			up:     strings.TrimSpace(string(operstate)) == "up",
+			driver: sysfsExists(filepath.Join(dir, "device", "driver")),
+		})
 	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].up != candidates[j].up {
+			return candidates[i].up
+		}
+		if candidates[i].driver != candidates[j].driver {
+			return candidates[i].driver
+		}
+		return candidates[i].name < candidates[j].name
+	})
 
-	return "", fmt.Errorf("未找到WiFi网络接口")
+	interfaces := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		interfaces = append(interfaces, c.name)
+	}
+	return interfaces
+}
+
+// sysfsExists 检查sysfs中的文件或目录是否存在（跟随符号链接）
+func sysfsExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 // GetInterface 实现WiFiConnector接口 - 获取WiFi接口名称
@@ -46,7 +191,7 @@ func (l *LinuxConnector) GetInterface() (string, error) {
 // GetCurrentNetwork 实现WiFiConnector接口 - 获取当前WiFi网络
 func (l *LinuxConnector) GetCurrentNetwork() (string, error) {
 	// 优先使用iwgetid命令
-	cmd := exec.Command("iwgetid", "-r")
+	cmd := exec.Command("iwgetid", l.interfaceName, "-r")
 	output, err := cmd.Output()
 	if err == nil {
 		networkName := strings.TrimSpace(string(output))
@@ -56,15 +201,15 @@ func (l *LinuxConnector) GetCurrentNetwork() (string, error) {
 	}
 
 	// 备用方案：使用nmcli
-	cmd = exec.Command("nmcli", "-t", "-f", "active,ssid", "dev", "wifi")
+	cmd = exec.Command("nmcli", "-t", "-f", "active,ssid", "dev", "wifi", "list", "ifname", l.interfaceName, "--rescan", "no")
 	output, err = cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("获取当前WiFi失败: %v", err)
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		parts := strings.Split(line, ":")
+	// SSID中的冒号会被转义为 \:
+	for _, line := range strings.Split(string(output), "\n") {
+		parts := splitNmcliTerse(strings.TrimRight(line, "\r"))
 		if len(parts) >= 2 && parts[0] == "yes" {
 			return parts[1], nil
 		}
@@ -74,12 +219,25 @@ func (l *LinuxConnector) GetCurrentNetwork() (string, error) {
 }
 
 // Connect 实现WiFiConnector接口 - 连接WiFi网络
-func (l *LinuxConnector) Connect(networkName, password string) error {
+func (l *LinuxConnector) Connect(networkName, password string, options ConnectOptions) error {
 	var cmd *exec.Cmd
-	if password != "" {
-		cmd = exec.Command("nmcli", "dev", "wifi", "connect", networkName, "password", password)
+	if options.Security != SecurityAuto {
+		// 企业级网络和指定了安全类型的网络无法通过 dev wifi connect 连接，需要使用连接配置
+		if err := l.saveConnectionProfile(networkName, password, options); err != nil {
+			return err
+		}
+		cmd = exec.Command("nmcli", "connection", "up", "id", networkName, "ifname", l.interfaceName)
 	} else {
-		cmd = exec.Command("nmcli", "dev", "wifi", "connect", networkName)
+		// 指定接口，有多个WiFi网卡时不会连接到其他网卡上
+		args := []string{"dev", "wifi", "connect", networkName, "ifname", l.interfaceName}
+		if password != "" {
+			args = append(args, "password", password)
+		}
+		if options.Hidden {
+			// 隐藏网络不在扫描结果中，需要主动探测
+			args = append(args, "hidden", "yes")
+		}
+		cmd = exec.Command("nmcli", args...)
 	}
 
 	if err := cmd.Run(); err != nil {
@@ -129,23 +287,504 @@ func (l *LinuxConnector) GetIPAddress() (string, error) {
 		return "", fmt.Errorf("获取IP地址失败: %v", err)
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.Contains(line, "inet ") && !strings.Contains(line, "127.0.0.1") {
-			parts := strings.Fields(line)
-			for i, part := range parts {
-				if part == "inet" && i+1 < len(parts) {
-					ipWithMask := parts[i+1]
-					// 移除子网掩码部分 (例如: 192.168.1.100/24 -> 192.168.1.100)
-					if slashIndex := strings.Index(ipWithMask, "/"); slashIndex != -1 {
-						return ipWithMask[:slashIndex], nil
-					}
-					return ipWithMask, nil
+	// 接口可能同时有DHCP地址和链路本地地址，优先使用非链路本地地址
+	var addresses []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(strings.TrimSpace(line))
+		if len(fields) >= 2 && fields[0] == "inet" {
+			// 移除子网掩码部分 (例如: 192.168.1.100/24 -> 192.168.1.100)
+			address, _, _ := strings.Cut(fields[1], "/")
+			addresses = append(addresses, address)
+		}
+	}
+	if address := selectIPv4Address(addresses); address != "" {
+		return address, nil
+	}
+
+	return "", fmt.Errorf("未找到IP地址")
+}
+
+// GetIPv6Addresses 实现WiFiConnector接口 - 获取当前WiFi接口的IPv6地址
+func (l *LinuxConnector) GetIPv6Addresses(options IPv6Options) ([]string, error) {
+	cmd := exec.Command("ip", "-6", "addr", "show", "dev", l.interfaceName)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取IPv6地址失败: %v", err)
+	}
+
+	// 解析格式: inet6 2001:db8::1/64 scope global temporary dynamic
+	var candidates []ipv6Candidate
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(strings.TrimSpace(line))
+		if len(fields) < 2 || fields[0] != "inet6" {
+			continue
+		}
+		candidate := ipv6Candidate{address: fields[1]}
+		for _, flag := range fields[2:] {
+			if flag == "temporary" {
+				candidate.temporary = true
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return selectIPv6Addresses(candidates, options), nil
+}
+
+// GetNetworkInfo 实现WiFiConnector接口 - 获取当前WiFi连接的详细网络信息
+func (l *LinuxConnector) GetNetworkInfo() (*NetworkInfo, error) {
+	ssid, err := l.GetCurrentNetwork()
+	if err != nil {
+		return nil, err
+	}
+	return l.networkInfo(ssid), nil
+}
+
+// networkInfo 通过ip和iw获取网络信息，网络名称由各后端提供
+func (l *LinuxConnector) networkInfo(ssid string) *NetworkInfo {
+	info := &NetworkInfo{Interface: l.interfaceName, SSID: ssid}
+
+	// IPv4地址和前缀长度，格式: inet 192.168.1.100/24 brd 192.168.1.255 scope global dynamic wlan0
+	if output, err := exec.Command("ip", "-4", "addr", "show", "dev", l.interfaceName).Output(); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			fields := strings.Fields(strings.TrimSpace(line))
+			if len(fields) >= 2 && fields[0] == "inet" {
+				if ip, ipNet, err := net.ParseCIDR(fields[1]); err == nil {
+					info.IPAddress = ip.String()
+					info.PrefixLength, _ = ipNet.Mask.Size()
+					break
 				}
 			}
 		}
 	}
 
-	return "", fmt.Errorf("未找到IP地址")
-}
\ No newline at end of file
+	// MAC地址，格式: link/ether aa:bb:cc:dd:ee:ff brd ff:ff:ff:ff:ff:ff
+	if output, err := exec.Command("ip", "link", "show", "dev", l.interfaceName).Output(); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			fields := strings.Fields(strings.TrimSpace(line))
+			if len(fields) >= 2 && fields[0] == "link/ether" {
+				info.MACAddress = fields[1]
+			}
+		}
+	}
+
+	// 默认网关，格式: default via 192.168.1.1 proto dhcp metric 600
+	if output, err := exec.Command("ip", "-4", "route", "show", "default", "dev", l.interfaceName).Output(); err == nil {
+		fields := strings.Fields(string(output))
+		for i, field := range fields {
+			if field == "via" && i+1 < len(fields) {
+				info.Gateway = fields[i+1]
+				break
+			}
+		}
+	}
+
+	info.DNSServers = l.getDNSServers()
+
+	// 无线链路信息，格式:
+	// Connected to aa:bb:cc:dd:ee:ff (on wlan0)
+	//         freq: 5745
+	//         signal: -52 dBm
+	if output, err := exec.Command("iw", "dev", l.interfaceName, "link").Output(); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			fields := strings.Fields(strings.TrimSpace(line))
+			switch {
+			case len(fields) >= 3 && fields[0] == "Connected" && fields[1] == "to":
+				info.BSSID = strings.ToLower(fields[2])
+			case len(fields) >= 2 && fields[0] == "freq:":
+				info.FrequencyMHz = parseLeadingInt(fields[1])
+				info.Channel = frequencyToChannel(info.FrequencyMHz)
+			case len(fields) >= 2 && fields[0] == "signal:":
+				info.SetSignalDBm(parseLeadingInt(fields[1]))
+			}
+		}
+	}
+
+	return info
+}
+
+// getDNSServers 获取WiFi接口使用的DNS服务器，优先从NetworkManager读取
+func (l *LinuxConnector) getDNSServers() []string {
+	var servers []string
+
+	// 格式: IP4.DNS[1]:192.168.1.1
+	if output, err := exec.Command("nmcli", "-t", "-f", "IP4.DNS", "dev", "show", l.interfaceName).Output(); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			if index := strings.Index(line, ":"); index != -1 && strings.HasPrefix(line, "IP4.DNS") {
+				servers = append(servers, strings.TrimSpace(line[index+1:]))
+			}
+		}
+	}
+	if len(servers) > 0 {
+		return servers
+	}
+
+	// 备用方案：读取resolv.conf
+	data, err := os.ReadFile("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// Scan 实现WiFiConnector接口 - 扫描附近可见的WiFi网络
+func (l *LinuxConnector) Scan() ([]ScanResult, error) {
+	// 格式: MyWiFi:AA\:BB\:CC\:DD\:EE\:FF:82:149:5745 MHz:WPA2 WPA3
+	cmd := exec.Command("nmcli", "-t", "-f", "SSID,BSSID,SIGNAL,CHAN,FREQ,SECURITY",
+		"dev", "wifi", "list", "ifname", l.interfaceName, "--rescan", "auto")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("扫描WiFi网络失败: %v", err)
+	}
+
+	var results []ScanResult
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := splitNmcliTerse(strings.TrimRight(line, "\r"))
+		if len(fields) < 6 {
+			continue
+		}
+		result := ScanResult{
+			SSID:     fields[0],
+			BSSID:    strings.ToLower(fields[1]),
+			Security: fields[5],
+		}
+		result.SetSignalPercent(parseLeadingInt(fields[2]))
+		if freq := parseLeadingInt(fields[4]); freq > 0 {
+			result.SetFrequency(freq)
+		} else {
+			result.SetChannel(parseLeadingInt(fields[3]))
+		}
+		if result.Security == "" || result.Security == "--" {
+			result.Security = "Open"
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// splitNmcliTerse 拆分nmcli -t的输出行，字段内的冒号和反斜杠以反斜杠转义
+func splitNmcliTerse(line string) []string {
+	var fields []string
+	var current strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(line[i])
+		}
+	}
+	return append(fields, current.String())
+}
+
+// ConnectBSSID 实现WiFiConnector接口 - 连接到指定网络的指定接入点
+func (l *LinuxConnector) ConnectBSSID(networkName, bssid, password string) error {
+	args := []string{"dev", "wifi", "connect", networkName, "bssid", bssid, "ifname", l.interfaceName}
+	if password != "" {
+		args = append(args, "password", password)
+	}
+	if err := exec.Command("nmcli", args...).Run(); err != nil {
+		return fmt.Errorf("连接接入点失败: %v", err)
+	}
+
+	// 等待连接完成并验证已关联到目标接入点
+	for i := 0; i < 10; i++ { // 最多等待10秒
+		time.Sleep(1 * time.Second)
+		info, err := l.GetNetworkInfo()
+		if err != nil {
+			continue
+		}
+		if info.SSID == networkName && strings.EqualFold(info.BSSID, bssid) {
+			return nil
+		}
+	}
+	return fmt.Errorf("连接超时：无法连接到接入点 %s", bssid)
+}
+
+// saveConnectionProfile 按安全类型保存与网络同名的连接配置。已有同名配置时只修改安全相关的设置，
+// 保留其中的IP、DNS和自动连接等设置；没有时创建新配置
+func (l *LinuxConnector) saveConnectionProfile(networkName, password string, options ConnectOptions) error {
+	exists := nmcliConnectionExists(networkName)
+	if exists && options.Enterprise == nil && options.Security.RequiresPassword() && password == "" {
+		return nil // 未提供密码时使用已保存的配置
+	}
+	settings, err := nmcliSecurityArgs(password, options)
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	if exists {
+		// 移除不再使用的安全设置，配置中没有该设置时nmcli会报错，忽略
+		if options.Enterprise == nil {
+			exec.Command("nmcli", "connection", "modify", "id", networkName, "remove", "802-1x").Run()
+		}
+		if options.Security == SecurityOpen {
+			exec.Command("nmcli", "connection", "modify", "id", networkName, "remove", "802-11-wireless-security").Run()
+		}
+		if len(settings) == 0 {
+			return nil
+		}
+		args = append([]string{"connection", "modify", "id", networkName}, settings...)
+	} else {
+		args = append([]string{"connection", "add", "type", "wifi", "con-name", networkName, "ifname", l.interfaceName, "ssid", networkName}, settings...)
+	}
+	if output, err := exec.Command("nmcli", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("保存连接配置失败: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// nmcliConnectionExists 检查是否已有指定名称的连接配置
+func nmcliConnectionExists(name string) bool {
+	output, err := exec.Command("nmcli", "-t", "-f", "NAME", "connection", "show").Output()
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := splitNmcliTerse(line); fields[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// nmcliSecurityArgs 生成按安全类型配置连接的nmcli属性，用于创建或修改连接配置
+func nmcliSecurityArgs(password string, options ConnectOptions) ([]string, error) {
+	var args []string
+	if options.Hidden {
+		args = append(args, "802-11-wireless.hidden", "yes")
+	}
+	if options.Enterprise != nil {
+		return append(args, nmcliEnterpriseArgs(password, options.Enterprise)...), nil
+	}
+
+	if options.Security.RequiresPassword() && password == "" {
+		return nil, fmt.Errorf("安全类型 %s 需要WiFi密码", options.Security)
+	}
+	switch options.Security {
+	case SecurityOpen:
+	case SecurityWPA2PSK:
+		args = append(args, "wifi-sec.key-mgmt", "wpa-psk", "wifi-sec.psk", password)
+	case SecurityWPA3SAE:
+		args = append(args, "wifi-sec.key-mgmt", "sae", "wifi-sec.psk", password)
+	case SecurityWPA2WPA3:
+		// 过渡模式下按WPA2配置并启用可选的管理帧保护，NetworkManager会在网卡支持时使用SAE
+		args = append(args, "wifi-sec.key-mgmt", "wpa-psk", "wifi-sec.psk", password, "wifi-sec.pmf", "optional")
+	default:
+		return nil, fmt.Errorf("不支持的安全类型: %s", options.Security)
+	}
+	return args, nil
+}
+
+// nmcliEnterpriseArgs 生成企业级（802.1X）连接配置的nmcli参数
+func nmcliEnterpriseArgs(password string, creds *EnterpriseCredentials) []string {
+	args := []string{
+		"wifi-sec.key-mgmt", "wpa-eap",
+		"802-1x.eap", string(creds.EAPMethod),
+		"802-1x.identity", creds.Identity,
+	}
+	if creds.AnonymousIdentity != "" {
+		args = append(args, "802-1x.anonymous-identity", creds.AnonymousIdentity)
+	}
+	if creds.CACert != "" {
+		args = append(args, "802-1x.ca-cert", creds.CACert)
+	}
+	if creds.ServerName != "" {
+		args = append(args, "802-1x.domain-suffix-match", creds.ServerName)
+	}
+
+	if creds.EAPMethod == EAPTLS {
+		args = append(args, "802-1x.client-cert", creds.ClientCert)
+		if creds.PrivateKey != "" {
+			args = append(args, "802-1x.private-key", creds.PrivateKey)
+		}
+		if creds.PrivateKeyPassword != "" {
+			args = append(args, "802-1x.private-key-password", creds.PrivateKeyPassword)
+		}
+		return args
+	}
+
+	if creds.Password != "" {
+		password = creds.Password
+	}
+	args = append(args, "802-1x.phase2-auth", creds.InnerMethod())
+	if password != "" {
+		args = append(args, "802-1x.password", password)
+	}
+	return args
+}
+
+// ApplyIPConfig 实现WiFiConnector接口 - 修改当前连接配置的IP设置并重新启用连接
+func (l *LinuxConnector) ApplyIPConfig(config *IPConfig) error {
+	connection, err := l.activeConnection()
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"connection", "modify", "id", connection}, nmcliIPConfigArgs(config)...)
+	if output, err := exec.Command("nmcli", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("修改IP配置失败: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	if output, err := exec.Command("nmcli", "connection", "up", "id", connection).CombinedOutput(); err != nil {
+		return fmt.Errorf("重新启用连接失败: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// nmcliIPConfigArgs 生成IP配置对应的nmcli连接属性
+func nmcliIPConfigArgs(config *IPConfig) []string {
+	var args []string
+	if config.IsStatic() {
+		args = append(args, "ipv4.method", "manual", "ipv4.addresses", config.Address, "ipv4.gateway", config.Gateway)
+	} else {
+		args = append(args, "ipv4.method", "auto", "ipv4.addresses", "", "ipv4.gateway", "")
+	}
+	// 配置了自定义DNS时忽略DHCP下发的DNS
+	args = append(args, "ipv4.dns", strings.Join(config.DNS, ","), "ipv4.ignore-auto-dns", fmt.Sprint(len(config.DNS) > 0))
+	return append(args, "ipv4.dns-search", strings.Join(config.SearchDomains, ","))
+}
+
+// activeConnection 获取WiFi接口当前活动的NetworkManager连接配置名称
+func (l *LinuxConnector) activeConnection() (string, error) {
+	// 格式: GENERAL.CONNECTION:MyWiFi
+	output, err := exec.Command("nmcli", "-t", "-f", "GENERAL.CONNECTION", "dev", "show", l.interfaceName).Output()
+	if err != nil {
+		return "", fmt.Errorf("获取当前连接配置失败: %v", err)
+	}
+	_, connection, _ := strings.Cut(strings.TrimSpace(string(output)), ":")
+	if connection == "" || connection == "--" {
+		return "", fmt.Errorf("WiFi接口 %s 没有活动的连接配置", l.interfaceName)
+	}
+	return connection, nil
+}
+
+// RenewDHCP 实现WiFiConnector接口 - 重新申请DHCP租约。
+// 优先重新启用NetworkManager连接，没有NetworkManager时使用dhclient
+func (l *LinuxConnector) RenewDHCP() error {
+	if networkManagerRunning() {
+		connection, err := l.activeConnection()
+		if err != nil {
+			return err
+		}
+		if output, err := exec.Command("nmcli", "connection", "up", "id", connection).CombinedOutput(); err != nil {
+			return fmt.Errorf("重新启用连接失败: %v: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	if _, err := exec.LookPath("dhclient"); err != nil {
+		// 树莓派等系统使用dhcpcd管理地址
+		if _, err := exec.LookPath("dhcpcd"); err == nil {
+			if output, err := exec.Command("dhcpcd", "-n", l.interfaceName).CombinedOutput(); err != nil {
+				return fmt.Errorf("dhcpcd续租失败: %v: %s", err, strings.TrimSpace(string(output)))
+			}
+			return nil
+		}
+		return fmt.Errorf("未找到nmcli、dhclient或dhcpcd，无法续租DHCP")
+	}
+	// 先释放旧租约，释放失败不影响重新申请
+	exec.Command("dhclient", "-r", l.interfaceName).Run()
+	if output, err := exec.Command("dhclient", "-1", l.interfaceName).CombinedOutput(); err != nil {
+		return fmt.Errorf("dhclient续租失败: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// GetWiredInterfaces 实现WiFiConnector接口 - 通过sysfs查找已连接的物理有线网卡
+func (l *LinuxConnector) GetWiredInterfaces() ([]WiredInterface, error) {
+	entries, err := os.ReadDir(sysfsNetDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取网络接口列表失败: %v", err)
+	}
+	var interfaces []WiredInterface
+	for _, entry := range entries {
+		name := entry.Name()
+		base := filepath.Join(sysfsNetDir, name)
+		// 只考虑物理网卡，排除无线网卡和虚拟接口（网桥、容器、VPN等）
+		if !sysfsExists(filepath.Join(base, "device")) {
+			continue
+		}
+		if sysfsExists(filepath.Join(base, "wireless")) || sysfsExists(filepath.Join(base, "phy80211")) {
+			continue
+		}
+		if state, err := os.ReadFile(filepath.Join(base, "operstate")); err != nil || strings.TrimSpace(string(state)) != "up" {
+			continue
+		}
+
+		// 格式: 2: eth0    inet 192.168.1.20/24 brd 192.168.1.255 scope global eth0
+		output, err := exec.Command("ip", "-4", "-o", "addr", "show", "dev", name).Output()
+		if err != nil {
+			continue
+		}
+		var addresses []string
+		for _, line := range strings.Split(string(output), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[2] == "inet" {
+				address, _, _ := strings.Cut(fields[3], "/")
+				addresses = append(addresses, address)
+			}
+		}
+		address := selectIPv4Address(addresses)
+		if address == "" || isLinkLocalIPv4(address) {
+			continue
+		}
+		wired := WiredInterface{Name: name, IPAddress: address}
+		// 格式: default via 192.168.1.1 proto dhcp metric 100（指定dev时输出中不含dev）
+		if route, err := exec.Command("ip", "-4", "route", "show", "default", "dev", name).Output(); err == nil {
+			fields := strings.Fields(string(route))
+			for i := 0; i+1 < len(fields); i++ {
+				if fields[i] == "via" {
+					wired.Gateway = fields[i+1]
+					break
+				}
+			}
+		}
+		interfaces = append(interfaces, wired)
+	}
+	return interfaces, nil
+}
+
+// GetDefaultRoute 实现WiFiConnector接口 - 获取度量值最小的默认路由
+func (l *LinuxConnector) GetDefaultRoute() (string, string, error) {
+	output, err := exec.Command("ip", "-4", "route", "show", "default").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("获取默认路由失败: %v", err)
+	}
+	iface, gateway := parseLinuxDefaultRoute(string(output))
+	return iface, gateway, nil
+}
+
+// parseLinuxDefaultRoute 解析 ip route show default 的输出，返回度量值最小的路由的接口和网关，
+// 格式: default via 192.168.1.1 dev eth0 proto dhcp metric 100
+func parseLinuxDefaultRoute(output string) (string, string) {
+	bestIface, bestGateway, bestMetric := "", "", -1
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		iface, gateway, metric := "", "", 0
+		for i := 0; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "dev":
+				iface = fields[i+1]
+			case "via":
+				gateway = fields[i+1]
+			case "metric":
+				metric = parseLeadingInt(fields[i+1])
+			}
+		}
+		if iface != "" && (bestMetric < 0 || metric < bestMetric) {
+			bestIface, bestGateway, bestMetric = iface, gateway, metric
+		}
+	}
+	return bestIface, bestGateway
+}
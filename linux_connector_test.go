@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNmcliEnterpriseArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		creds    EnterpriseCredentials
+		want     []string
+	}{
+		{
+			name:     "PEAP使用WiFi密码",
+			password: "wifi-secret",
+			creds:    EnterpriseCredentials{EAPMethod: EAPPEAP, Identity: "alice", CACert: "/etc/ca.pem", ServerName: "radius.example.com"},
+			want: []string{
+				"wifi-sec.key-mgmt", "wpa-eap",
+				"802-1x.eap", "peap",
+				"802-1x.identity", "alice",
+				"802-1x.ca-cert", "/etc/ca.pem",
+				"802-1x.domain-suffix-match", "radius.example.com",
+				"802-1x.phase2-auth", "mschapv2",
+				"802-1x.password", "wifi-secret",
+			},
+		},
+		{
+			name:     "TTLS-PAP使用配置的密码",
+			password: "wifi-secret",
+			creds:    EnterpriseCredentials{EAPMethod: EAPTTLS, Identity: "bob", AnonymousIdentity: "anonymous", Password: "p&ss", Phase2: "PAP"},
+			want: []string{
+				"wifi-sec.key-mgmt", "wpa-eap",
+				"802-1x.eap", "ttls",
+				"802-1x.identity", "bob",
+				"802-1x.anonymous-identity", "anonymous",
+				"802-1x.phase2-auth", "pap",
+				"802-1x.password", "p&ss",
+			},
+		},
+		{
+			name: "TLS客户端证书",
+			creds: EnterpriseCredentials{EAPMethod: EAPTLS, Identity: "carol", ClientCert: "/etc/carol.crt",
+				PrivateKey: "/etc/carol.key", PrivateKeyPassword: "key-pass"},
+			want: []string{
+				"wifi-sec.key-mgmt", "wpa-eap",
+				"802-1x.eap", "tls",
+				"802-1x.identity", "carol",
+				"802-1x.client-cert", "/etc/carol.crt",
+				"802-1x.private-key", "/etc/carol.key",
+				"802-1x.private-key-password", "key-pass",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nmcliEnterpriseArgs(tt.password, &tt.creds)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("nmcliEnterpriseArgs() = %q\n期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNmcliSecurityArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		options  ConnectOptions
+		want     []string
+		wantErr  bool
+	}{
+		{"开放网络", "", ConnectOptions{Security: SecurityOpen}, nil, false},
+		{"WPA3隐藏网络", "secret", ConnectOptions{Security: SecurityWPA3SAE, Hidden: true},
+			[]string{"802-11-wireless.hidden", "yes", "wifi-sec.key-mgmt", "sae", "wifi-sec.psk", "secret"}, false},
+		{"过渡模式", "secret", ConnectOptions{Security: SecurityWPA2WPA3},
+			[]string{"wifi-sec.key-mgmt", "wpa-psk", "wifi-sec.psk", "secret", "wifi-sec.pmf", "optional"}, false},
+		{"缺少密码", "", ConnectOptions{Security: SecurityWPA2PSK}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nmcliSecurityArgs(tt.password, tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误 = %v，期望出错: %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("nmcliSecurityArgs() = %q\n期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSysfsWiFiInterfaces(t *testing.T) {
+	root := t.TempDir()
+	// 在临时目录中模拟 /sys/class/net，文件内容为空字符串时不创建该文件
+	interfaces := []struct {
+		name   string
+		marker string
+		typ    string
+		state  string
+		driver bool
+	}{
+		{"wlp2s0", "wireless", "1", "up", false},
+		{"wlan1", "wireless", "1", "up", true},
+		{"wlan0", "wireless", "1", "up", false},
+		{"wlx001122334455", "phy80211", "1", "down", true},
+		{"wlan2", "wireless", "", "dormant", false},
+		{"mon0", "wireless", "803", "up", true},
+		{"eth0", "", "1", "up", true},
+	}
+	for _, iface := range interfaces {
+		dir := filepath.Join(root, iface.name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if iface.marker != "" {
+			if err := os.Mkdir(filepath.Join(dir, iface.marker), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if iface.driver {
+			if err := os.MkdirAll(filepath.Join(dir, "device", "driver"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for file, content := range map[string]string{"type": iface.typ, "operstate": iface.state} {
+			if content == "" {
+				continue
+			}
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(content+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 启用的优先，其次是有驱动的物理网卡，最后按名称排序；排除监听模式（类型803）和非无线接口
+	want := []string{"wlan1", "wlan0", "wlp2s0", "wlx001122334455", "wlan2"}
+	if got := sysfsWiFiInterfaces(root); !slices.Equal(got, want) {
+		t.Fatalf("sysfsWiFiInterfaces() = %q\n期望 %q", got, want)
+	}
+	if got := sysfsWiFiInterfaces(filepath.Join(root, "missing")); len(got) != 0 {
+		t.Fatalf("目录不存在时应返回空列表: %q", got)
+	}
+}
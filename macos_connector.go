@@ -1,8 +1,16 @@
 package main
 
 import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
 	"fmt"
+	"math/bits"
+	"net"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -57,6 +65,27 @@ func (m *MacOSConnector) detectInterface() (string, error) {
 	return "", fmt.Errorf("未找到WiFi网络接口")
 }
 
+// listMacOSWiFiInterfaces 从硬件端口列表中列出全部WiFi接口
+func listMacOSWiFiInterfaces() ([]string, error) {
+	output, err := exec.Command("networksetup", "-listallhardwareports").Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取网络接口列表失败: %v", err)
+	}
+	var interfaces []string
+	lines := strings.Split(string(output), "\n")
+	for i, line := range lines {
+		if !strings.Contains(line, "Wi-Fi") && !strings.Contains(line, "AirPort") {
+			continue
+		}
+		if i+1 < len(lines) {
+			if device, ok := strings.CutPrefix(lines[i+1], "Device: "); ok {
+				interfaces = append(interfaces, strings.TrimSpace(device))
+			}
+		}
+	}
+	return interfaces, nil
+}
+
 // GetInterface 实现WiFiConnector接口 - 获取WiFi接口名称
 func (m *MacOSConnector) GetInterface() (string, error) {
 	return m.interfaceName, nil
@@ -85,7 +114,24 @@ func (m *MacOSConnector) GetCurrentNetwork() (string, error) {
 }
 
 // Connect 实现WiFiConnector接口 - 连接WiFi网络
-func (m *MacOSConnector) Connect(networkName, password string) error {
+func (m *MacOSConnector) Connect(networkName, password string, options ConnectOptions) error {
+	if options.Enterprise != nil {
+		// networksetup不支持企业级网络，通过描述文件配置后再加入网络
+		if err := m.installEnterpriseProfile(networkName, password, options); err != nil {
+			return err
+		}
+		password = ""
+	} else if options.Hidden || options.Security != SecurityAuto {
+		// 隐藏网络需要先加入首选网络列表，否则无法找到网络；指定了安全类型时同样按安全类型加入首选网络
+		args, err := preferredNetworkArgs(m.interfaceName, networkName, password, options.Security)
+		if err != nil {
+			return err
+		}
+		if err := exec.Command("networksetup", args...).Run(); err != nil {
+			return fmt.Errorf("添加首选网络失败: %v", err)
+		}
+	}
+
 	var cmd *exec.Cmd
 	if password != "" {
 		cmd = exec.Command("networksetup", "-setairportnetwork", m.interfaceName, networkName, password)
@@ -140,16 +186,571 @@ func (m *MacOSConnector) GetIPAddress() (string, error) {
 		return "", fmt.Errorf("获取IP地址失败: %v", err)
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
+	// 接口可能同时有DHCP地址和自分配的链路本地地址，优先使用非链路本地地址
+	var addresses []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(strings.TrimSpace(line))
+		if len(fields) >= 2 && fields[0] == "inet" {
+			addresses = append(addresses, fields[1])
+		}
+	}
+	if address := selectIPv4Address(addresses); address != "" {
+		return address, nil
+	}
+
+	return "", fmt.Errorf("未找到IP地址")
+}
+
+// GetIPv6Addresses 实现WiFiConnector接口 - 获取当前WiFi接口的IPv6地址
+func (m *MacOSConnector) GetIPv6Addresses(options IPv6Options) ([]string, error) {
+	cmd := exec.Command("ifconfig", m.interfaceName)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取IPv6地址失败: %v", err)
+	}
+
+	// 解析格式: inet6 2001:db8::1234 prefixlen 64 autoconf temporary
+	var candidates []ipv6Candidate
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(strings.TrimSpace(line))
+		if len(fields) < 2 || fields[0] != "inet6" {
+			continue
+		}
+		candidate := ipv6Candidate{address: fields[1]}
+		for _, flag := range fields[2:] {
+			if flag == "temporary" {
+				candidate.temporary = true
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return selectIPv6Addresses(candidates, options), nil
+}
+
+// GetNetworkInfo 实现WiFiConnector接口 - 获取当前WiFi连接的详细网络信息
+func (m *MacOSConnector) GetNetworkInfo() (*NetworkInfo, error) {
+	info := &NetworkInfo{Interface: m.interfaceName}
+
+	ssid, err := m.GetCurrentNetwork()
+	if err != nil {
+		return nil, err
+	}
+	info.SSID = ssid
+
+	// IPv4地址、子网掩码和MAC地址，格式:
+	// ether aa:bb:cc:dd:ee:ff
+	// inet 192.168.1.100 netmask 0xffffff00 broadcast 192.168.1.255
+	if output, err := exec.Command("ifconfig", m.interfaceName).Output(); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			fields := strings.Fields(strings.TrimSpace(line))
+			if len(fields) < 2 {
+				continue
+			}
+			switch fields[0] {
+			case "ether":
+				info.MACAddress = fields[1]
+			case "inet":
+				if info.IPAddress != "" || fields[1] == "127.0.0.1" {
+					continue
+				}
+				info.IPAddress = fields[1]
+				for i, field := range fields {
+					if field == "netmask" && i+1 < len(fields) {
+						if mask, err := strconv.ParseUint(strings.TrimPrefix(fields[i+1], "0x"), 16, 32); err == nil {
+							info.PrefixLength = bits.OnesCount32(uint32(mask))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// 默认网关，格式: gateway: 192.168.1.1
+	if output, err := exec.Command("route", "-n", "get", "default").Output(); err == nil {
+		values := parseKeyValueLines(string(output), ":")
+		if values["interface"] == m.interfaceName {
+			info.Gateway = values["gateway"]
+		}
+	}
+
+	// DHCP获取的DNS服务器，格式: nameserver[0] : 192.168.1.1
+	if output, err := exec.Command("scutil", "--dns").Output(); err == nil {
+		seen := make(map[string]bool)
+		for _, line := range strings.Split(string(output), "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "nameserver[") {
+				continue
+			}
+			if index := strings.Index(line, ":"); index != -1 {
+				server := strings.TrimSpace(line[index+1:])
+				if !seen[server] {
+					seen[server] = true
+					info.DNSServers = append(info.DNSServers, server)
+				}
+			}
+		}
+	}
+
+	m.fillWirelessInfo(info)
+	return info, nil
+}
+
+// fillWirelessInfo 填充BSSID、信道和信号强度
+func (m *MacOSConnector) fillWirelessInfo(info *NetworkInfo) {
+	// 优先使用airport工具（较新的macOS版本已移除），格式:
+	//      agrCtlRSSI: -52
+	//           BSSID: aa:bb:cc:dd:ee:ff
+	//         channel: 149,80
+	airport := "/System/Library/PrivateFrameworks/Apple80211.framework/Versions/Current/Resources/airport"
+	if output, err := exec.Command(airport, "-I").Output(); err == nil {
+		values := parseKeyValueLines(string(output), ":")
+		info.BSSID = values["BSSID"]
+		if rssi := values["agrCtlRSSI"]; rssi != "" {
+			info.SetSignalDBm(parseLeadingInt(rssi))
+		}
+		if channel := parseLeadingInt(values["channel"]); channel > 0 {
+			info.Channel = channel
+			info.FrequencyMHz = channelToFrequency(channel)
+		}
+		if info.SignalDBm != 0 {
+			return
+		}
+	}
+
+	// 备用方案：system_profiler，格式:
+	//   Current Network Information:
+	//     MyWiFi:
+	//       Channel: 149 (5GHz, 80MHz)
+	//       Signal / Noise: -52 dBm / -90 dBm
+	output, err := exec.Command("system_profiler", "SPAirPortDataType").Output()
+	if err != nil {
+		return
+	}
+	inCurrent := false
+	for _, line := range strings.Split(string(output), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Current Network Information:") {
+			inCurrent = true
+			continue
+		}
+		if !inCurrent {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "Other Local Wi-Fi Networks:") {
+			break
+		}
+		if strings.HasPrefix(trimmed, "Channel:") {
+			if channel := parseLeadingInt(strings.TrimPrefix(trimmed, "Channel:")); channel > 0 {
+				info.Channel = channel
+				info.FrequencyMHz = channelToFrequency(channel)
+			}
+		} else if strings.HasPrefix(trimmed, "Signal / Noise:") {
+			info.SetSignalDBm(parseLeadingInt(strings.TrimPrefix(trimmed, "Signal / Noise:")))
+		}
+	}
+}
+
+// Scan 实现WiFiConnector接口 - 扫描附近可见的WiFi网络
+func (m *MacOSConnector) Scan() ([]ScanResult, error) {
+	// 优先使用airport工具（较新的macOS版本已移除），格式:
+	//             SSID BSSID             RSSI CHANNEL HT CC SECURITY (auth/unicast/group)
+	//           MyWiFi aa:bb:cc:dd:ee:ff -52  149,+1  Y  CN WPA2(PSK/AES/AES)
+	airport := "/System/Library/PrivateFrameworks/Apple80211.framework/Versions/Current/Resources/airport"
+	if output, err := exec.Command(airport, "-s").Output(); err == nil {
+		if results := parseAirportScan(string(output)); len(results) > 0 {
+			return results, nil
+		}
+	}
+
+	// 备用方案：system_profiler，不包含BSSID，格式:
+	//   Other Local Wi-Fi Networks:
+	//     MyWiFi:
+	//       Channel: 149 (5GHz, 80MHz)
+	//       Security: WPA2 Personal
+	//       Signal / Noise: -52 dBm / -90 dBm
+	output, err := exec.Command("system_profiler", "SPAirPortDataType").Output()
+	if err != nil {
+		return nil, fmt.Errorf("扫描WiFi网络失败: %v", err)
+	}
+
+	var results []ScanResult
+	var current *ScanResult
+	inSection := false
+	sectionIndent, networkIndent := 0, -1
+	for _, line := range strings.Split(string(output), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if trimmed == "Current Network Information:" || trimmed == "Other Local Wi-Fi Networks:" {
+			inSection = true
+			sectionIndent, networkIndent = indent, -1
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if indent <= sectionIndent {
+			inSection = false
+			continue
+		}
+		// 网络名称行以冒号结尾，缩进比所属分组多一级
+		if strings.HasSuffix(trimmed, ":") && (networkIndent == -1 || indent == networkIndent) {
+			networkIndent = indent
+			results = append(results, ScanResult{SSID: strings.TrimSuffix(trimmed, ":")})
+			current = &results[len(results)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value, found := strings.Cut(trimmed, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Channel":
+			if strings.Contains(value, "6GHz") {
+				current.Band = "6GHz"
+				current.FrequencyMHz = 5950 + parseLeadingInt(value)*5
+			}
+			current.SetChannel(parseLeadingInt(value))
+		case "Security":
+			current.Security = value
+		case "Signal / Noise":
+			current.SetSignalDBm(parseLeadingInt(value))
+		}
+	}
+	return results, nil
+}
+
+// parseAirportScan 解析 airport -s 的输出，SSID右对齐且可能包含空格，以BSSID列定位
+func parseAirportScan(output string) []ScanResult {
+	var results []ScanResult
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		bssidIndex := -1
+		for i, field := range fields {
+			if _, err := net.ParseMAC(field); err == nil && strings.Count(field, ":") == 5 {
+				bssidIndex = i
+				break
+			}
+		}
+		if bssidIndex == -1 || len(fields) < bssidIndex+3 {
+			continue
+		}
+		result := ScanResult{
+			SSID:  strings.Join(fields[:bssidIndex], " "),
+			BSSID: strings.ToLower(fields[bssidIndex]),
+		}
+		result.SetSignalDBm(parseLeadingInt(fields[bssidIndex+1]))
+		result.SetChannel(parseLeadingInt(fields[bssidIndex+2]))
+		if len(fields) > bssidIndex+5 {
+			result.Security = strings.Join(fields[bssidIndex+5:], " ")
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// ConnectBSSID 实现WiFiConnector接口 - networksetup不支持指定接入点
+func (m *MacOSConnector) ConnectBSSID(networkName, bssid, password string) error {
+	return ErrBSSIDUnsupported
+}
+
+// preferredNetworkArgs 生成按安全类型加入首选网络列表的networksetup参数
+func preferredNetworkArgs(interfaceName, networkName, password string, security SecurityType) ([]string, error) {
+	if security == SecurityAuto {
+		security = SecurityWPA2PSK
+		if password == "" {
+			security = SecurityOpen
+		}
+	}
+	if security.RequiresPassword() && password == "" {
+		return nil, fmt.Errorf("安全类型 %s 需要WiFi密码", security)
+	}
+
+	args := []string{"-addpreferredwirelessnetworkatindex", interfaceName, networkName, "0"}
+	switch security {
+	case SecurityOpen:
+		return append(args, "OPEN"), nil
+	case SecurityWPA2PSK:
+		return append(args, "WPA2", password), nil
+	case SecurityWPA3SAE:
+		return append(args, "WPA3", password), nil
+	case SecurityWPA2WPA3:
+		return append(args, "WPA2/WPA3", password), nil
+	default:
+		return nil, fmt.Errorf("不支持的安全类型: %s", security)
+	}
+}
+
+// installEnterpriseProfile 生成并安装企业级网络的描述文件
+func (m *MacOSConnector) installEnterpriseProfile(networkName, password string, options ConnectOptions) error {
+	config, err := buildMobileConfig(networkName, password, options)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(os.TempDir(), "connect-"+profileUUID(networkName)+".mobileconfig")
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		return fmt.Errorf("写入描述文件失败: %v", err)
+	}
+
+	output, err := exec.Command("profiles", "install", "-type", "configuration", "-path", path).CombinedOutput()
+	if err == nil {
+		os.Remove(path)
+		return nil
+	}
+	// 较新的macOS不允许命令行静默安装描述文件，需要在系统设置中手动确认
+	exec.Command("open", path).Run()
+	return fmt.Errorf("安装描述文件失败，请在系统设置的描述文件中确认安装 %s: %v: %s", path, err, strings.TrimSpace(string(output)))
+}
+
+// buildMobileConfig 生成包含企业级WiFi配置的描述文件（.mobileconfig）
+func buildMobileConfig(networkName, password string, options ConnectOptions) (string, error) {
+	creds := options.Enterprise
+	identifier := "com.weibaohui.connect." + profileUUID(networkName)
+	var payloads []string
+	eap := []string{plistKey("UserName", plistString(creds.Identity))}
+
+	switch creds.EAPMethod {
+	case EAPPEAP:
+		eap = append(eap, plistKey("AcceptEAPTypes", "<array><integer>25</integer></array>"))
+	case EAPTTLS:
+		eap = append(eap, plistKey("AcceptEAPTypes", "<array><integer>21</integer></array>"))
+		inner := map[string]string{"mschapv2": "MSCHAPv2", "mschap": "MSCHAP", "pap": "PAP", "chap": "CHAP"}[creds.InnerMethod()]
+		if inner == "" {
+			inner = "EAP"
+		}
+		eap = append(eap, plistKey("TTLSInnerAuthentication", plistString(inner)))
+	case EAPTLS:
+		eap = append(eap, plistKey("AcceptEAPTypes", "<array><integer>13</integer></array>"))
+	}
+	if creds.EAPMethod != EAPTLS {
+		if creds.Password != "" {
+			password = creds.Password
+		}
+		if password != "" {
+			eap = append(eap, plistKey("UserPassword", plistString(password)))
+		}
+		if creds.AnonymousIdentity != "" {
+			eap = append(eap, plistKey("OuterIdentity", plistString(creds.AnonymousIdentity)))
+		}
+	}
+	if creds.ServerName != "" {
+		eap = append(eap, plistKey("TLSTrustedServerNames", "<array>"+plistString(creds.ServerName)+"</array>"))
+	}
+
+	if creds.CACert != "" {
+		der, err := readCertificateDER(creds.CACert)
+		if err != nil {
+			return "", err
+		}
+		uuid := profileUUID(networkName + "/ca")
+		payloads = append(payloads, plistPayload("com.apple.security.root", identifier+".ca", uuid, "CA: "+networkName,
+			plistKey("PayloadContent", "<data>"+base64.StdEncoding.EncodeToString(der)+"</data>")))
+		eap = append(eap, plistKey("PayloadCertificateAnchorUUID", "<array>"+plistString(uuid)+"</array>"))
+	}
+
+	wifi := []string{
+		plistKey("SSID_STR", plistString(networkName)),
+		plistKey("HIDDEN_NETWORK", plistBool(options.Hidden)),
+		plistKey("AutoJoin", "<true/>"),
+		plistKey("EncryptionType", plistString("WPA2")),
+		plistKey("EAPClientConfiguration", "<dict>"+strings.Join(eap, "")+"</dict>"),
+	}
+	if creds.EAPMethod == EAPTLS {
+		data, err := os.ReadFile(creds.ClientCert)
+		if err != nil {
+			return "", fmt.Errorf("读取客户端证书失败: %v", err)
+		}
+		uuid := profileUUID(networkName + "/identity")
+		payloads = append(payloads, plistPayload("com.apple.security.pkcs12", identifier+".identity", uuid, "Identity: "+networkName,
+			plistKey("PayloadContent", "<data>"+base64.StdEncoding.EncodeToString(data)+"</data>")+
+				plistKey("Password", plistString(creds.PrivateKeyPassword))))
+		wifi = append(wifi, plistKey("PayloadCertificateUUID", plistString(uuid)))
+	}
+	payloads = append(payloads, plistPayload("com.apple.wifi.managed", identifier+".wifi", profileUUID(networkName+"/wifi"), "WiFi: "+networkName,
+		strings.Join(wifi, "")))
+
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0"><dict>` +
+		plistKey("PayloadContent", "<array>"+strings.Join(payloads, "")+"</array>") +
+		plistKey("PayloadType", plistString("Configuration")) +
+		plistKey("PayloadVersion", "<integer>1</integer>") +
+		plistKey("PayloadIdentifier", plistString(identifier)) +
+		plistKey("PayloadUUID", plistString(profileUUID(networkName))) +
+		plistKey("PayloadDisplayName", plistString("WiFi: "+networkName)) +
+		"</dict></plist>\n", nil
+}
+
+// plistPayload 生成描述文件中的一个配置项
+func plistPayload(payloadType, identifier, uuid, displayName, content string) string {
+	return "<dict>" +
+		plistKey("PayloadType", plistString(payloadType)) +
+		plistKey("PayloadVersion", "<integer>1</integer>") +
+		plistKey("PayloadIdentifier", plistString(identifier)) +
+		plistKey("PayloadUUID", plistString(uuid)) +
+		plistKey("PayloadDisplayName", plistString(displayName)) +
+		content + "</dict>"
+}
+
+// plistKey 生成plist的键值对
+func plistKey(key, value string) string {
+	return "<key>" + key + "</key>" + value
+}
+
+// plistString 生成plist的字符串值
+func plistString(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return "<string>" + b.String() + "</string>"
+}
+
+// plistBool 生成plist的布尔值
+func plistBool(value bool) string {
+	if value {
+		return "<true/>"
+	}
+	return "<false/>"
+}
+
+// profileUUID 根据种子生成固定的UUID，重复安装时覆盖同一个描述文件
+func profileUUID(seed string) string {
+	sum := sha1.Sum([]byte("connect:" + seed))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// ApplyIPConfig 实现WiFiConnector接口 - 通过networksetup设置WiFi网络服务的IP和DNS
+func (m *MacOSConnector) ApplyIPConfig(config *IPConfig) error {
+	service, err := m.networkService()
+	if err != nil {
+		return err
+	}
+
+	var commands [][]string
+	if config.IsStatic() {
+		commands = append(commands, []string{"-setmanual", service, config.IP(), config.SubnetMask(), config.Gateway})
+	} else {
+		commands = append(commands, []string{"-setdhcp", service})
+	}
+	// Empty表示清除自定义设置，恢复使用DHCP下发的值
+	dns := append([]string{"-setdnsservers", service}, config.DNS...)
+	if len(config.DNS) == 0 {
+		dns = append(dns, "Empty")
+	}
+	domains := append([]string{"-setsearchdomains", service}, config.SearchDomains...)
+	if len(config.SearchDomains) == 0 {
+		domains = append(domains, "Empty")
+	}
+	commands = append(commands, dns, domains)
+
+	for _, args := range commands {
+		if output, err := exec.Command("networksetup", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("设置IP配置失败（networksetup %s）: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// networkService 获取WiFi接口对应的网络服务名称，格式:
+// (1) Wi-Fi
+// (Hardware Port: Wi-Fi, Device: en0)
+func (m *MacOSConnector) networkService() (string, error) {
+	output, err := exec.Command("networksetup", "-listnetworkserviceorder").Output()
+	if err != nil {
+		return "", fmt.Errorf("获取网络服务列表失败: %v", err)
+	}
+	var service string
+	for _, line := range strings.Split(string(output), "\n") {
 		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "inet ") && !strings.Contains(line, "127.0.0.1") {
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				return parts[1], nil
+		if strings.HasPrefix(line, "(Hardware Port:") {
+			if strings.Contains(line, "Device: "+m.interfaceName+")") && service != "" {
+				return service, nil
+			}
+			continue
+		}
+		// 服务名称行以序号开头，被禁用的服务以 (*) 开头
+		if strings.HasPrefix(line, "(") {
+			if index := strings.Index(line, ")"); index != -1 {
+				service = strings.TrimSpace(line[index+1:])
 			}
 		}
 	}
+	return "", fmt.Errorf("未找到接口 %s 对应的网络服务", m.interfaceName)
+}
 
-	return "", fmt.Errorf("未找到IP地址")
-}
\ No newline at end of file
+// RenewDHCP 实现WiFiConnector接口 - 重新申请DHCP租约
+func (m *MacOSConnector) RenewDHCP() error {
+	if output, err := exec.Command("ipconfig", "set", m.interfaceName, "DHCP").CombinedOutput(); err != nil {
+		return fmt.Errorf("续租DHCP失败: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// GetWiredInterfaces 实现WiFiConnector接口 - 查找已连接的以太网硬件端口
+func (m *MacOSConnector) GetWiredInterfaces() ([]WiredInterface, error) {
+	output, err := exec.Command("networksetup", "-listallhardwareports").Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取硬件端口列表失败: %v", err)
+	}
+	defaultRoute, gateway, _ := m.GetDefaultRoute()
+
+	// 格式:
+	// Hardware Port: USB 10/100/1000 LAN
+	// Device: en7
+	var interfaces []WiredInterface
+	port := ""
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(line, "Hardware Port:"); ok {
+			port = strings.TrimSpace(value)
+			continue
+		}
+		device, ok := strings.CutPrefix(line, "Device:")
+		if !ok || !(strings.Contains(port, "Ethernet") || strings.Contains(port, "LAN")) {
+			continue
+		}
+		device = strings.TrimSpace(device)
+
+		// 格式: status: active / inet 192.168.1.20 netmask 0xffffff00
+		ifconfig, err := exec.Command("ifconfig", device).Output()
+		if err != nil || !strings.Contains(string(ifconfig), "status: active") {
+			continue
+		}
+		var addresses []string
+		for _, ifLine := range strings.Split(string(ifconfig), "\n") {
+			fields := strings.Fields(strings.TrimSpace(ifLine))
+			if len(fields) >= 2 && fields[0] == "inet" {
+				addresses = append(addresses, fields[1])
+			}
+		}
+		address := selectIPv4Address(addresses)
+		if address == "" || isLinkLocalIPv4(address) {
+			continue
+		}
+		wired := WiredInterface{Name: device, IPAddress: address}
+		if device == defaultRoute {
+			wired.Gateway = gateway
+		}
+		interfaces = append(interfaces, wired)
+	}
+	return interfaces, nil
+}
+
+// GetDefaultRoute 实现WiFiConnector接口 - 获取默认路由的接口和网关
+func (m *MacOSConnector) GetDefaultRoute() (string, string, error) {
+	// 格式: interface: en0 / gateway: 192.168.1.1
+	output, err := exec.Command("route", "-n", "get", "default").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("获取默认路由失败: %v", err)
+	}
+	values := parseKeyValueLines(string(output), ":")
+	return values["interface"], values["gateway"], nil
+}
@@ -4,6 +4,10 @@ import (
 	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -21,22 +25,127 @@ var (
 	feishuSecret string
 	// 是否启用通知功能
 	enableNotification bool
-	// WiFi连接器实例
-	connector WiFiConnector
-	// IP变化检测器
-	ipDetector *IPChangeDetector
-	// WiFi连接状态检测器
-	wifiStateDetector *WiFiStateDetector
 	// 飞书通知器
 	feishuNotifier *FeishuNotifier
+	// 连接历史记录文件路径
+	historyFile string
+	// 连接历史存储
+	historyStore *HistoryStore
+	// 状态面板监听地址
+	dashboardAddr string
+	// 是否检测并上报IPv6地址
+	enableIPv6 bool
+	// IPv6地址过滤选项
+	ipv6Options IPv6Options
+	// 是否检测公网IP
+	enableWAN bool
+	// 公网IP查询来源（逗号分隔）
+	wanSources string
+	// 公网IP检测间隔（秒）
+	wanInterval int
+	// 公网IP交叉校验所需的一致来源数量
+	wanMinAgree int
+	// 公网IP检测器
+	wanDetector *WANIPDetector
+	// 是否监控到网关和指定目标的延迟和丢包
+	enableLinkQuality bool
+	// 链路质量额外探测目标
+	linkTarget string
+	// 链路质量探测间隔（秒）
+	linkInterval int
+	// 链路质量统计窗口（探测次数）
+	linkWindow int
+	// 链路质量平均延迟阈值（毫秒）
+	linkMaxLatency int
+	// 链路质量丢包率阈值（百分比）
+	linkMaxLoss float64
+	// 超过阈值持续多久才发送链路质量下降通知（秒）
+	linkSustain int
+	// 链路质量监控器
+	linkQualityMonitor *LinkQualityMonitor
+	// 是否检测互联网连通性
+	enableConnectivity bool
+	// 连通性检测项（逗号分隔）
+	connectivityChecks string
+	// 连通性检测超时时间（秒）
+	connectivityTimeout int
+	// 互联网连通性检测器
+	connectivityChecker *ConnectivityChecker
+	// 目标网络是否为隐藏网络（不广播SSID）
+	targetHidden bool
+	// 目标网络的安全类型
+	targetSecurity SecurityType
+	// 是否在同名网络的接入点之间按信号强度切换
+	enableRoaming bool
+	// 切换接入点所需的最小信号强度差（dB）
+	roamHysteresis int
+	// 检查是否需要切换接入点的间隔（秒）
+	roamInterval int
+	// 续租DHCP后等待获取地址的超时时间（秒）
+	dhcpTimeout int
+	// 有线网络已连接时对WiFi的处理策略
+	wiredPolicy WiredPolicy
+	// 是否通过mDNS通告本机
+	enableMDNS bool
+	// mDNS通告的主机名
+	mdnsName string
+	// mDNS通告的服务（逗号分隔）
+	mdnsServices string
+	// mDNS通告器
+	mdnsResponder *MDNSResponder
+	// 动态DNS配置文件路径
+	ddnsFile string
+	// 动态DNS更新器
+	ddnsUpdater *DDNSUpdater
+	// 指定的WiFi网卡名称，为空时自动检测
+	wifiInterface string
+	// 是否管理全部WiFi网卡
+	allAdapters bool
+	// 各WiFi网卡的目标网络
+	adapterTargets AdapterTargets
+	// 是否同时管理多个WiFi网卡，通知中附带接口名称
+	multiAdapter bool
+	// 是否监听网络变化事件（Linux netlink），变化时立即检查
+	enableEvents bool
+	// 监听网络变化事件时的兜底检查间隔（秒）
+	eventsInterval int
+	// Linux连接WiFi使用的后端
+	linuxBackend LinuxBackend
+	// wpa_supplicant控制套接字目录，为空时自动查找
+	wpaCtrlDir string
+	// 网络配置文件路径
+	networksFile string
+	// 受管网络配置
+	networkProfiles []NetworkProfile
+	// 是否输出调试日志
+	verbose bool
 	// 程序版本
 	version string = "1.0.0"
 )
 
+// flagSet 判断命令行是否显式指定了某个参数
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// debugf 启用 -verbose 时输出调试日志
+func debugf(format string, args ...any) {
+	if verbose {
+		log.Printf("[DEBUG] "+format, args...)
+	}
+}
+
 // WiFiStateDetector WiFi连接状态检测器
 type WiFiStateDetector struct {
 	previousNetwork string
 	currentNetwork  string
+	initialized     bool
 }
 
 // NewWiFiStateDetector 创建新的WiFi状态检测器
@@ -46,9 +155,10 @@ func NewWiFiStateDetector() *WiFiStateDetector {
 
 // CheckWiFiStateChange 检测WiFi连接状态是否发生变化
 func (d *WiFiStateDetector) CheckWiFiStateChange(newNetwork string) bool {
-	// 如果当前网络为空，说明是第一次设置
-	if d.currentNetwork == "" {
+	// 尚未初始化，说明是第一次设置
+	if !d.initialized {
 		d.currentNetwork = newNetwork
+		d.initialized = true
 		return true
 	}
 
@@ -73,133 +183,108 @@ func (d *WiFiStateDetector) GetCurrentNetwork() string {
 	return d.currentNetwork
 }
 
-// checkAndConnect 检查并连接WiFi的主要逻辑
-func checkAndConnect() {
-	// 自动检测WiFi网卡接口
-	interfaceName, err := connector.GetInterface()
-	if err != nil {
-		log.Printf("获取WiFi接口失败: %v", err)
-		return
-	}
-	log.Printf("检测到WiFi接口: %s", interfaceName)
-
-	// 检查WiFi是否启用
-	if !connector.IsEnabled() {
-		log.Println("WiFi未启用，正在启用...")
-		if err := connector.Enable(); err != nil {
-			log.Printf("启用WiFi失败: %v", err)
+func main() {
+	// 处理子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "history":
+			if err := runHistoryCommand(os.Args[2:]); err != nil {
+				log.Fatalf("查询连接历史失败: %v", err)
+			}
+			return
+		case "scan":
+			if err := runScanCommand(os.Args[2:]); err != nil {
+				log.Fatalf("扫描WiFi网络失败: %v", err)
+			}
 			return
 		}
-		log.Println("WiFi已启用")
-		// 等待WiFi启用完成
-		time.Sleep(3 * time.Second)
 	}
 
-	// 获取当前连接的WiFi
-	currentWiFi, err := connector.GetCurrentNetwork()
+	// 解析命令行参数
+	flag.StringVar(&targetWiFi, "w", "", "目标WiFi网络名称")
+	flag.StringVar(&wifiPassword, "p", "", "WiFi密码")
+	flag.IntVar(&checkInterval, "i", 10, "检查间隔（秒）")
+	flag.BoolVar(&targetHidden, "hidden", false, "目标WiFi网络是否为隐藏网络（不广播SSID）")
+	securityFlag := flag.String("security", "auto", "目标WiFi网络的安全类型: auto、open、wpa2-psk、wpa3-sae、wpa2-wpa3")
+	flag.BoolVar(&enableNotification, "enable-notification", false, "是否启用通知功能")
+	flag.StringVar(&historyFile, "history-file", defaultHistoryFile(), "连接历史记录文件路径（为空则不记录）")
+	flag.StringVar(&dashboardAddr, "dashboard", "", "Web状态面板监听地址，如 127.0.0.1:8080（为空则不启用）")
+	flag.BoolVar(&enableIPv6, "ipv6", false, "是否检测并上报IPv6全局地址和ULA地址")
+	flag.BoolVar(&ipv6Options.IncludeLinkLocal, "ipv6-link-local", false, "上报IPv6地址时包含链路本地地址（fe80::/10）")
+	flag.BoolVar(&ipv6Options.IncludeTemporary, "ipv6-temporary", false, "上报IPv6地址时包含临时隐私地址")
+	flag.BoolVar(&enableWAN, "wan", false, "是否检测公网IP变化")
+	flag.StringVar(&wanSources, "wan-sources", strings.Join(defaultWANSources, ","), "公网IP查询来源，逗号分隔，http(s)地址为回显服务，stun:host:port为STUN服务器")
+	flag.IntVar(&wanInterval, "wan-interval", 300, "公网IP检测间隔（秒）")
+	flag.IntVar(&wanMinAgree, "wan-min-agree", 2, "确认公网IP所需的一致来源数量")
+	flag.BoolVar(&enableLinkQuality, "link-quality", false, "是否监控到默认网关和指定目标的延迟、抖动和丢包")
+	flag.StringVar(&linkTarget, "link-target", "", "链路质量额外探测目标（主机名或IP），为空则只探测默认网关")
+	flag.IntVar(&linkInterval, "link-interval", 2, "链路质量探测间隔（秒）")
+	flag.IntVar(&linkWindow, "link-window", 30, "链路质量统计窗口（最近的探测次数）")
+	flag.IntVar(&linkMaxLatency, "link-max-latency", 200, "平均延迟超过该值（毫秒）视为链路质量下降")
+	flag.Float64Var(&linkMaxLoss, "link-max-loss", 20, "丢包率超过该值（百分比）视为链路质量下降")
+	flag.IntVar(&linkSustain, "link-sustain", 60, "超过阈值持续该时长（秒）后才发送链路质量下降通知")
+	flag.BoolVar(&enableConnectivity, "connectivity", false, "连接WiFi后是否检测互联网连通性")
+	flag.StringVar(&connectivityChecks, "connectivity-checks", defaultConnectivityChecks, "连通性检测项，逗号分隔: http:URL（期望204）、dns:域名、tcp:主机:端口、gateway（ping网关）")
+	flag.IntVar(&connectivityTimeout, "connectivity-timeout", 5, "单项连通性检测超时时间（秒）")
+	flag.BoolVar(&enableRoaming, "roam", false, "是否在目标网络的多个接入点之间按信号强度切换")
+	flag.IntVar(&roamHysteresis, "roam-hysteresis", 8, "切换接入点所需的最小信号强度差（dB）")
+	flag.IntVar(&roamInterval, "roam-interval", 60, "检查是否需要切换接入点的间隔（秒）")
+	flag.IntVar(&dhcpTimeout, "dhcp-timeout", 15, "未获取到IP地址或只有链路本地地址时续租DHCP，等待获取地址的超时时间（秒）")
+	wiredPolicyFlag := flag.String("wired-policy", "off", "有线网络已连接时的处理策略: off（不检测）、ignore（不管理WiFi）、report（不管理WiFi并上报有线地址）、standby（保持WiFi备用并上报有线地址）")
+	flag.BoolVar(&enableMDNS, "mdns", false, "是否通过组播DNS（mDNS/DNS-SD）在局域网中通告本机主机名和服务")
+	flag.StringVar(&mdnsName, "mdns-name", "", "mDNS通告的主机名（不含.local），为空时使用本机主机名")
+	flag.StringVar(&mdnsServices, "mdns-services", defaultMDNSServices(), "mDNS通告的服务，格式为 名称:端口，逗号分隔，如 ssh:22,rdp:3389")
+	flag.StringVar(&ddnsFile, "ddns", "", "动态DNS配置文件路径（JSON），IP变化时自动更新DNS记录")
+	flag.BoolVar(&enableEvents, "events", true, "Linux上通过netlink监听链路、地址和WiFi关联变化并立即检查，未指定 -i 时检查间隔改为 -events-interval 的兜底轮询（其他平台忽略）")
+	flag.IntVar(&eventsInterval, "events-interval", 60, "监听网络变化事件且未指定 -i 时的兜底检查间隔（秒）")
+	flag.StringVar(&wifiInterface, "iface", "", "WiFi网卡名称，为空时自动检测（Linux从 /sys/class/net 中检测无线网卡）")
+	flag.BoolVar(&allAdapters, "all-adapters", false, "管理全部WiFi网卡，每个网卡独立检查和连接")
+	flag.Var(&adapterTargets, "adapter", "为指定WiFi网卡配置目标网络，格式为 网卡=网络1,网络2（按优先级），可重复指定")
+	linuxBackendFlag := flag.String("linux-backend", "auto", "Linux连接WiFi使用的后端: auto（依次检测NetworkManager、iwd、wpa_supplicant控制接口）、networkmanager（D-Bus接口）、nmcli、wpa_supplicant、iwd")
+	flag.StringVar(&wpaCtrlDir, "wpa-ctrl", "", "wpa_supplicant控制套接字目录（ctrl_interface），为空时在 /run/wpa_supplicant 和 /var/run/wpa_supplicant 中查找")
+	flag.StringVar(&networksFile, "networks", "", "网络配置文件路径（JSON），用于配置每个网络的密码、认证页面登录方式等")
+	flag.BoolVar(&verbose, "verbose", false, "是否输出调试日志（如Windows上执行的PowerShell命令和输出）")
+	flag.Parse()
+
+	// 检查必需参数
+	if targetWiFi == "" && adapterTargets.Len() == 0 {
+		log.Fatal("请指定目标WiFi网络名称，使用 -w 参数（或使用 -adapter 为网卡指定目标网络）")
+	}
+
+	security, err := ParseSecurityType(*securityFlag)
 	if err != nil {
-		log.Printf("获取当前WiFi失败: %v", err)
-		return
+		log.Fatalf("参数错误: %v", err)
 	}
+	targetSecurity = security
 
-	if currentWiFi == "" {
-		log.Println("当前未连接任何WiFi网络")
-	} else {
-		log.Printf("当前连接的WiFi: %s", currentWiFi)
+	wiredPolicy, err = ParseWiredPolicy(*wiredPolicyFlag)
+	if err != nil {
+		log.Fatalf("参数错误: %v", err)
 	}
 
-	// 检查WiFi状态是否发生变化
-	wifiStateChanged := false
-	if wifiStateDetector != nil {
-		wifiStateChanged = wifiStateDetector.CheckWiFiStateChange(currentWiFi)
+	linuxBackend, err = ParseLinuxBackend(*linuxBackendFlag)
+	if err != nil {
+		log.Fatalf("参数错误: %v", err)
 	}
 
-	// 如果当前WiFi不是目标WiFi，则尝试连接
-	if currentWiFi != targetWiFi {
-		log.Printf("尝试连接到WiFi: %s", targetWiFi)
-		if err := connector.Connect(targetWiFi, wifiPassword); err != nil {
-			log.Printf("连接WiFi失败: %v", err)
-		} else {
-			log.Printf("成功连接到WiFi: %s", targetWiFi)
-			// 等待网络配置完成
-			time.Sleep(2 * time.Second)
-			// 获取并显示IP地址
-			if ipAddr, err := connector.GetIPAddress(); err != nil {
-				log.Printf("获取IP地址失败: %v", err)
-			} else {
-				log.Printf("分配到的IP地址: %s", ipAddr)
-				// 检测IP地址是否变化并发送通知
-				if enableNotification && ipDetector != nil && feishuNotifier != nil {
-					log.Printf("检测IP地址变化: %s", ipAddr)
-					ipChanged := ipDetector.CheckIPChange(ipAddr)
-					if ipChanged {
-						oldIP := ipDetector.GetPreviousIP()
-						log.Printf("发送飞书通知(因IP变化): %s -> %s", oldIP, ipAddr)
-						feishuNotifier.SendIPChangeNotificationAsync(oldIP, ipAddr, targetWiFi)
-					} else if wifiStateChanged {
-						// 即使IP未变化，但如果WiFi重新连接了，也要发送通知
-						log.Printf("发送飞书通知(因WiFi重新连接): %s", ipAddr)
-						feishuNotifier.SendWiFiReconnectNotificationAsync(ipAddr, targetWiFi)
-					} else {
-						log.Printf("IP地址未变化，WiFi状态未变化，不发送通知: %s", ipAddr)
-					}
-				} else {
-					if !enableNotification {
-						log.Printf("通知功能未启用")
-					} else if ipDetector == nil {
-						log.Printf("IP检测器未初始化")
-					} else if feishuNotifier == nil {
-						log.Printf("飞书通知器未初始化")
-					}
-				}
-			}
+	// 加载网络配置
+	if networksFile != "" {
+		profiles, err := loadNetworkProfiles(networksFile)
+		if err != nil {
+			log.Fatalf("加载网络配置失败: %v", err)
 		}
-	} else {
-		log.Printf("已连接到目标WiFi: %s", targetWiFi)
-		// 显示当前IP地址
-		if ipAddr, err := connector.GetIPAddress(); err != nil {
-			log.Printf("获取IP地址失败: %v", err)
-		} else {
-			log.Printf("当前IP地址: %s", ipAddr)
-			// 检测IP地址是否变化并发送通知
-			if enableNotification && ipDetector != nil && feishuNotifier != nil {
-				log.Printf("检测IP地址变化: %s", ipAddr)
-				ipChanged := ipDetector.CheckIPChange(ipAddr)
-				if ipChanged {
-					oldIP := ipDetector.GetPreviousIP()
-					log.Printf("发送飞书通知(因IP变化): %s -> %s", oldIP, ipAddr)
-					feishuNotifier.SendIPChangeNotificationAsync(oldIP, ipAddr, targetWiFi)
-				} else if wifiStateChanged {
-					// 即使IP未变化，但如果WiFi重新连接了，也要发送通知
-					log.Printf("发送飞书通知(因WiFi重新连接): %s", ipAddr)
-					feishuNotifier.SendWiFiReconnectNotificationAsync(ipAddr, targetWiFi)
-				} else {
-					log.Printf("IP地址未变化，WiFi状态未变化，不发送通知: %s", ipAddr)
-				}
-			} else {
-				if !enableNotification {
-					log.Printf("通知功能未启用")
-				} else if ipDetector == nil {
-					log.Printf("IP检测器未初始化")
-				} else if feishuNotifier == nil {
-					log.Printf("飞书通知器未初始化")
-				}
-			}
+		networkProfiles = profiles
+		log.Printf("已加载%d个网络配置: %s", len(networkProfiles), networksFile)
+
+		if err := resolveNetworkCredentials(append([]string{targetWiFi}, adapterTargets.Networks()...)); err != nil {
+			log.Fatalf("%v", err)
 		}
 	}
-}
 
-func main() {
-	// 解析命令行参数
-	flag.StringVar(&targetWiFi, "w", "", "目标WiFi网络名称")
-	flag.StringVar(&wifiPassword, "p", "", "WiFi密码")
-	flag.IntVar(&checkInterval, "i", 10, "检查间隔（秒）")
-	flag.BoolVar(&enableNotification, "enable-notification", false, "是否启用通知功能")
-	flag.Parse()
-
-	// 检查必需参数
-	if targetWiFi == "" {
-		log.Fatal("请指定目标WiFi网络名称，使用 -w 参数")
+	// 初始化连接历史存储
+	if historyFile != "" {
+		historyStore = NewHistoryStore(historyFile)
+		log.Printf("连接历史记录文件: %s", historyFile)
 	}
 
 	// 初始化通知相关组件
@@ -222,39 +307,144 @@ func main() {
 			log.Println("可以通过设置环境变量 FEISHU_WEBHOOK_URL 和 FEISHU_SECRET 或使用命令行参数 -feishu-webhook 和 -feishu-secret")
 			enableNotification = false
 		} else {
-			ipDetector = NewIPChangeDetector()
-			wifiStateDetector = NewWiFiStateDetector()
 			feishuNotifier = NewFeishuNotifier(feishuWebhook, feishuSecret)
 			log.Printf("通知功能已启用: %s", feishuWebhook)
 		}
 	}
 
-	// 创建WiFi连接器
-	var err error
-	connector, err = NewWiFiConnector()
+	// 为每个受管的WiFi网卡创建监控器，网卡不存在时等待插入
+	monitors, err := newMonitorGroup(&adapterTargets, allAdapters)
 	if err != nil {
 		log.Fatalf("创建WiFi连接器失败: %v", err)
 	}
+	multiAdapter = allAdapters || adapterTargets.Len() > 1
 
 	log.Println("WiFi自动连接程序启动")
-	interfaceName, _ := connector.GetInterface()
-	log.Printf("检测到WiFi接口: %s", interfaceName)
-	log.Printf("目标WiFi网络: %s", targetWiFi)
-	if wifiPassword != "" {
-		log.Println("已设置WiFi密码")
-	} else {
-		log.Println("未设置WiFi密码，将尝试使用已保存的密码")
+	for _, m := range monitors.Monitors() {
+		if interfaceName, _ := m.connector.GetInterface(); interfacePresent(interfaceName) {
+			log.Printf("检测到WiFi接口: %s", interfaceName)
+			switch m.connector.(type) {
+			case *WPASupplicantConnector:
+				log.Printf("使用wpa_supplicant控制接口: %s", wpaControlPath(interfaceName))
+			case *IWDConnector:
+				log.Printf("使用iwd连接WiFi")
+			case *NMConnector:
+				log.Printf("使用NetworkManager D-Bus接口连接WiFi")
+			}
+		} else {
+			log.Printf("未检测到WiFi接口%s，等待网卡插入", interfaceName)
+		}
+		log.Printf("目标WiFi网络: %s", strings.Join(m.targets, "、"))
+		for _, target := range m.targets {
+			if networkPassword(target) != "" {
+				log.Printf("已设置WiFi密码: %s", target)
+			} else {
+				log.Printf("未设置WiFi密码，将尝试使用已保存的密码: %s", target)
+			}
+		}
 	}
 	log.Printf("检查间隔: %d秒", checkInterval)
 
-	// 执行检查和连接
-	checkAndConnect()
+	// 初始化互联网连通性检测
+	if enableConnectivity {
+		probes, err := ParseConnectivityProbes(connectivityChecks)
+		if err != nil {
+			log.Fatalf("连通性检测配置错误: %v", err)
+		}
+		connectivityChecker = NewConnectivityChecker(probes, time.Duration(connectivityTimeout)*time.Second)
+		log.Printf("互联网连通性检测已启用: %s", connectivityChecks)
+	}
+
+	// 初始化动态DNS
+	if ddnsFile != "" {
+		records, err := loadDDNSRecords(ddnsFile)
+		if err != nil {
+			log.Fatalf("加载动态DNS配置失败: %v", err)
+		}
+		ddnsUpdater, err = NewDDNSUpdater(records)
+		if err != nil {
+			log.Fatalf("动态DNS配置错误: %v", err)
+		}
+		for _, record := range records {
+			if record.Source == DDNSSourceWAN && !enableWAN {
+				log.Printf("动态DNS记录 %s 使用公网IP，但未启用 -wan，该记录不会更新", record.Name)
+			}
+		}
+		log.Printf("已加载%d条动态DNS记录: %s", len(records), ddnsFile)
+	}
+
+	// 初始化mDNS通告
+	if enableMDNS {
+		services, err := ParseMDNSServices(mdnsServices)
+		if err != nil {
+			log.Fatalf("mDNS服务配置错误: %v", err)
+		}
+		mdnsResponder = NewMDNSResponder(mdnsName, services)
+		log.Printf("mDNS通告已启用: %s，服务: %s", mdnsResponder.Hostname(), mdnsServices)
+		// 退出时撤销通告，避免局域网中残留过期记录
+		go func() {
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			<-signals
+			mdnsResponder.Withdraw()
+			os.Exit(0)
+		}()
+	}
+
+	// 启动公网IP检测
+	if enableWAN {
+		var sources []string
+		for _, source := range strings.Split(wanSources, ",") {
+			if source = strings.TrimSpace(source); source != "" {
+				sources = append(sources, source)
+			}
+		}
+		wanDetector = NewWANIPDetector(sources, wanMinAgree)
+		log.Printf("公网IP检测已启用，来源: %v，检测间隔: %d秒", sources, wanInterval)
+		go wanDetector.Run(time.Duration(wanInterval) * time.Second)
+	}
+
+	// 启动链路质量监控
+	if enableLinkQuality {
+		network := func() *NetworkInfo {
+			if primary := monitors.Primary(); primary != nil {
+				return primary.Status().Snapshot().NetworkInfo
+			}
+			return nil
+		}
+		linkQualityMonitor = NewLinkQualityMonitor(network, linkTarget, linkWindow, LinkQualityThresholds{
+			MaxLatency:     time.Duration(linkMaxLatency) * time.Millisecond,
+			MaxLossPercent: linkMaxLoss,
+			Sustain:        time.Duration(linkSustain) * time.Second,
+		})
+		log.Printf("链路质量监控已启用，探测间隔: %d秒，阈值: 延迟%dms、丢包%.0f%%，持续%d秒", linkInterval, linkMaxLatency, linkMaxLoss, linkSustain)
+		go linkQualityMonitor.Run(time.Duration(linkInterval) * time.Second)
+	}
 
-	// 定期检查
-	ticker := time.NewTicker(time.Duration(checkInterval) * time.Second)
-	defer ticker.Stop()
+	// 启动状态面板
+	if dashboardAddr != "" {
+		dashboard := NewDashboardServer(monitors)
+		go func() {
+			log.Printf("状态面板已启动: http://%s", dashboardAddr)
+			if err := dashboard.ListenAndServe(dashboardAddr); err != nil {
+				log.Printf("状态面板启动失败: %v", err)
+			}
+		}()
+	}
 
-	for range ticker.C {
-		checkAndConnect()
+	// 监听网络变化事件，变化时立即检查，定期检查只作为兜底；显式指定的 -i 仍作为兜底检查间隔
+	interval := time.Duration(checkInterval) * time.Second
+	if enableEvents && runtime.GOOS == "linux" {
+		if err := watchNetworkEvents(monitors.TriggerCheck); err != nil {
+			log.Printf("监听网络变化事件失败，按检查间隔轮询: %v", err)
+		} else {
+			if !flagSet("i") {
+				interval = time.Duration(eventsInterval) * time.Second
+			}
+			log.Printf("已启用网络变化事件监听，兜底检查间隔: %d秒", int(interval/time.Second))
+		}
 	}
+
+	// 每个网卡的监控器独立执行检查并定期检查
+	monitors.Run(interval)
 }
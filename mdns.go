@@ -0,0 +1,524 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mDNS使用的常量
+const (
+	mdnsPort       = 5353
+	dnsTypePTR     = 12
+	dnsTypeTXT     = 16
+	dnsTypeSRV     = 33
+	dnsTypeANY     = 255
+	mdnsCacheFlush = 0x8000
+	// mdnsHostTTL 主机记录和SRV/TXT记录的TTL（RFC 6762建议120秒）
+	mdnsHostTTL = 120
+	// mdnsServiceTTL PTR记录的TTL（RFC 6762建议75分钟）
+	mdnsServiceTTL = 4500
+	// mdnsProbeCount 通告前发送的探测次数，mdnsProbeInterval 为探测间隔（RFC 6762 8.1节）
+	mdnsProbeCount    = 3
+	mdnsProbeInterval = 250 * time.Millisecond
+	// mdnsMaxRenames 名称冲突时最多改名的次数
+	mdnsMaxRenames = 10
+)
+
+// mdnsGroup mDNS的IPv4组播地址
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: mdnsPort}
+
+// defaultMDNSServices 默认通告的服务，Windows为远程桌面，其他平台为SSH
+func defaultMDNSServices() string {
+	if runtime.GOOS == "windows" {
+		return "rdp:3389"
+	}
+	return "ssh:22"
+}
+
+// MDNSService 通过DNS-SD通告的服务
+type MDNSService struct {
+	// Type 服务类型，如 _ssh._tcp
+	Type string
+	// Port 服务端口
+	Port int
+}
+
+// ParseMDNSServices 解析服务配置，格式为 名称:端口，多个用逗号分隔，如 ssh:22,rdp:3389
+func ParseMDNSServices(value string) ([]MDNSService, error) {
+	var services []MDNSService
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, portText, ok := strings.Cut(item, ":")
+		port, err := strconv.Atoi(portText)
+		if !ok || name == "" || err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("服务格式应为 名称:端口: %s", item)
+		}
+		services = append(services, MDNSService{Type: "_" + strings.TrimPrefix(name, "_") + "._tcp", Port: port})
+	}
+	return services, nil
+}
+
+// MDNSResponder 通过组播DNS在WiFi接口上通告本机的主机名和服务，并应答查询
+type MDNSResponder struct {
+	// host 主机名标签，通告为 host.local，名称冲突时会改为 baseHost-2、baseHost-3 等
+	host     string
+	baseHost string
+	renames  int
+	services []MDNSService
+	iface    *net.Interface
+	conn     *net.UDPConn
+	ipv4     net.IP
+	ipv6     []net.IP
+	mutex    sync.Mutex
+	// probing 正在探测名称是否被占用，探测期间不应答查询
+	probing bool
+	// conflict 探测期间收到了其他设备对同名记录的应答
+	conflict bool
+}
+
+// NewMDNSResponder 创建mDNS通告器，host为空时使用本机主机名
+func NewMDNSResponder(host string, services []MDNSService) *MDNSResponder {
+	if host == "" {
+		host, _ = os.Hostname()
+		// 去掉主机名中的域名部分
+		host, _, _ = strings.Cut(host, ".")
+	}
+	host = strings.ToLower(host)
+	return &MDNSResponder{host: host, baseHost: host, services: services}
+}
+
+// Hostname 返回通告的完整主机名
+func (r *MDNSResponder) Hostname() string {
+	return r.host + ".local."
+}
+
+// Announce 在指定接口上通告当前地址，接口或地址未变化时不重复通告。
+// 切换到新接口时先按RFC 6762探测名称是否被占用，探测通过后再通告
+func (r *MDNSResponder) Announce(interfaceName, ipAddr string) {
+	iface, err := net.InterfaceByName(interfaceName)
+	if err != nil {
+		log.Printf("mDNS获取网络接口失败: %v", err)
+		return
+	}
+	ipv4 := net.ParseIP(ipAddr).To4()
+	var ipv6 []net.IP
+	if addrs, err := iface.Addrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() == nil {
+				ipv6 = append(ipv6, ipNet.IP)
+			}
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	sameInterface := r.iface != nil && r.iface.Index == iface.Index
+	if sameInterface && r.ipv4.Equal(ipv4) && slices.EqualFunc(r.ipv6, ipv6, net.IP.Equal) {
+		return
+	}
+	if r.conn != nil && !sameInterface {
+		r.close()
+	}
+	r.iface, r.ipv4, r.ipv6 = iface, ipv4, ipv6
+
+	if r.conn == nil {
+		conn, err := net.ListenMulticastUDP("udp4", iface, mdnsGroup)
+		if err != nil {
+			log.Printf("mDNS监听失败: %v", err)
+			r.iface, r.ipv4, r.ipv6 = nil, nil, nil
+			return
+		}
+		r.conn = conn
+		r.probing = true
+		go r.serve(conn)
+		go r.probe(conn)
+		return
+	}
+	// 探测中时由探测结束后统一通告最新的地址
+	if !r.probing {
+		r.announce()
+	}
+}
+
+// announce 发送通告，RFC 6762建议至少发送两次，间隔1秒，调用方需持有锁
+func (r *MDNSResponder) announce() {
+	log.Printf("mDNS通告: %s -> %s", r.Hostname(), r.ipv4)
+	r.send(r.buildResponse(nil, mdnsHostTTL, false, 0), mdnsGroup)
+	conn := r.conn
+	go func() {
+		time.Sleep(1 * time.Second)
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.conn == conn {
+			r.send(r.buildResponse(nil, mdnsHostTTL, false, 0), mdnsGroup)
+		}
+	}()
+}
+
+// probe 通告前探测主机名和服务实例名是否已被其他设备使用，被占用时改名后重新探测
+func (r *MDNSResponder) probe(conn *net.UDPConn) {
+	for {
+		r.mutex.Lock()
+		if r.conn != conn {
+			r.mutex.Unlock()
+			return
+		}
+		r.conflict = false
+		msg := r.buildProbe()
+		r.mutex.Unlock()
+
+		for i := 0; i < mdnsProbeCount; i++ {
+			r.mutex.Lock()
+			if r.conn != conn {
+				r.mutex.Unlock()
+				return
+			}
+			r.send(msg, mdnsGroup)
+			r.mutex.Unlock()
+			time.Sleep(mdnsProbeInterval)
+		}
+
+		r.mutex.Lock()
+		if r.conn != conn {
+			r.mutex.Unlock()
+			return
+		}
+		if !r.conflict {
+			r.probing = false
+			r.announce()
+			r.mutex.Unlock()
+			return
+		}
+		if r.renames >= mdnsMaxRenames {
+			log.Printf("mDNS名称冲突次数过多，停止通告: %s", r.Hostname())
+			r.mutex.Unlock()
+			return
+		}
+		r.renames++
+		previous := r.Hostname()
+		r.host = fmt.Sprintf("%s-%d", r.baseHost, r.renames+1)
+		log.Printf("mDNS名称 %s 已被其他设备使用，改用 %s", previous, r.Hostname())
+		r.mutex.Unlock()
+	}
+}
+
+// buildProbe 生成探测报文，询问本机的唯一记录名称，并在授权段中带上准备通告的记录，调用方需持有锁
+func (r *MDNSResponder) buildProbe() []byte {
+	var names []string
+	var authorities []mdnsRecord
+	for _, record := range r.records(mdnsHostTTL) {
+		if !record.unique {
+			continue
+		}
+		if !slices.Contains(names, record.name) {
+			names = append(names, record.name)
+		}
+		authorities = append(authorities, record)
+	}
+	msg := dnsHeader(0, 0, uint16(len(names)), 0, uint16(len(authorities)), 0)
+	for _, name := range names {
+		msg = appendDNSQuestion(msg, name, dnsTypeANY, dnsClassIN)
+	}
+	for _, record := range authorities {
+		msg = appendDNSRecord(msg, record.name, record.rtype, dnsClassIN, record.ttl, record.rdata)
+	}
+	return msg
+}
+
+// conflicts 判断其他设备应答的地址记录是否与本机主机名相同但地址不同，调用方需持有锁。
+// SRV记录中的主机名可能被压缩，无法直接比较，服务实例名包含主机名，随主机名一起改名
+func (r *MDNSResponder) conflicts(answer mdnsRecord) bool {
+	if answer.ttl == 0 || (answer.rtype != dnsTypeA && answer.rtype != dnsTypeAAAA) {
+		return false
+	}
+	owned := false
+	for _, record := range r.records(mdnsHostTTL) {
+		if !record.unique || !strings.EqualFold(record.name, answer.name) || record.rtype != answer.rtype {
+			continue
+		}
+		// 组播环回会收到本机发出的应答，内容相同的记录不算冲突
+		if slices.Equal(record.rdata, answer.rdata) {
+			return false
+		}
+		owned = true
+	}
+	return owned
+}
+
+// Withdraw 发送TTL为0的通告撤销记录，并停止应答查询
+func (r *MDNSResponder) Withdraw() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.conn == nil {
+		return
+	}
+	log.Printf("撤销mDNS通告: %s", r.Hostname())
+	r.close()
+	r.iface, r.ipv4, r.ipv6 = nil, nil, nil
+}
+
+// close 撤销已通告的记录并关闭连接，探测中的记录尚未通告，无需撤销，调用方需持有锁
+func (r *MDNSResponder) close() {
+	if !r.probing {
+		r.sendGoodbye()
+	}
+	r.conn.Close()
+	r.conn = nil
+	r.probing = false
+}
+
+// sendGoodbye 发送TTL为0的全部记录，调用方需持有锁
+func (r *MDNSResponder) sendGoodbye() {
+	r.send(r.buildResponse(nil, 0, false, 0), mdnsGroup)
+}
+
+// send 发送报文，调用方需持有锁
+func (r *MDNSResponder) send(msg []byte, addr *net.UDPAddr) {
+	if r.conn == nil || msg == nil {
+		return
+	}
+	if _, err := r.conn.WriteToUDP(msg, addr); err != nil {
+		log.Printf("发送mDNS报文失败: %v", err)
+	}
+}
+
+// serve 接收查询并应答与本机相关的问题，连接关闭后退出
+func (r *MDNSResponder) serve(conn *net.UDPConn) {
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if answers, err := parseMDNSRecords(buf[:n]); err == nil && len(answers) > 0 {
+			r.checkConflicts(conn, answers)
+			continue
+		}
+		questions, err := parseDNSQuestions(buf[:n])
+		if err != nil || len(questions) == 0 {
+			continue
+		}
+
+		r.mutex.Lock()
+		// 探测期间名称尚未确认可用，不应答查询
+		if r.conn == conn && !r.probing {
+			if from.Port != mdnsPort {
+				// 传统单播查询: 带原报文ID和问题直接回复给查询方
+				id := binary.BigEndian.Uint16(buf[0:2])
+				r.send(r.buildResponse(questions, 10, true, id), from)
+			} else {
+				r.send(r.buildResponse(questions, mdnsHostTTL, false, 0), mdnsGroup)
+			}
+		}
+		r.mutex.Unlock()
+	}
+}
+
+// checkConflicts 检查收到的应答是否与本机记录冲突，探测期间标记冲突以便改名，通告后只记录日志
+func (r *MDNSResponder) checkConflicts(conn *net.UDPConn, answers []mdnsRecord) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.conn != conn {
+		return
+	}
+	for _, answer := range answers {
+		if !r.conflicts(answer) {
+			continue
+		}
+		if r.probing {
+			r.conflict = true
+		} else {
+			log.Printf("mDNS检测到冲突: 其他设备也在通告 %s", strings.TrimSuffix(answer.name, "."))
+		}
+		return
+	}
+}
+
+// dnsQuestion 查询报文中的一个问题
+type dnsQuestion struct {
+	Name string
+	Type uint16
+}
+
+// mdnsRecord 待发送的一条资源记录
+type mdnsRecord struct {
+	name  string
+	rtype uint16
+	// unique 唯一记录，发送时设置缓存刷新位
+	unique bool
+	ttl    uint32
+	rdata  []byte
+}
+
+// records 返回本机的全部记录，ttl为主机和服务实例记录的TTL，为0时表示撤销
+func (r *MDNSResponder) records(ttl uint32) []mdnsRecord {
+	serviceTTL := uint32(0)
+	if ttl > 0 {
+		serviceTTL = mdnsServiceTTL
+	}
+	hostname := r.Hostname()
+	var records []mdnsRecord
+	if r.ipv4 != nil {
+		records = append(records, mdnsRecord{hostname, dnsTypeA, true, ttl, r.ipv4})
+	}
+	for _, ip := range r.ipv6 {
+		records = append(records, mdnsRecord{hostname, dnsTypeAAAA, true, ttl, ip.To16()})
+	}
+	for _, service := range r.services {
+		serviceName := service.Type + ".local."
+		instance := r.host + "." + serviceName
+		srv := binary.BigEndian.AppendUint16(nil, 0)
+		srv = binary.BigEndian.AppendUint16(srv, 0)
+		srv = binary.BigEndian.AppendUint16(srv, uint16(service.Port))
+		srv = appendDNSName(srv, hostname)
+		records = append(records,
+			mdnsRecord{"_services._dns-sd._udp.local.", dnsTypePTR, false, serviceTTL, appendDNSName(nil, serviceName)},
+			mdnsRecord{serviceName, dnsTypePTR, false, serviceTTL, appendDNSName(nil, instance)},
+			mdnsRecord{instance, dnsTypeSRV, true, ttl, srv},
+			// 空TXT记录由一个长度为0的字符串组成
+			mdnsRecord{instance, dnsTypeTXT, true, ttl, []byte{0}},
+		)
+	}
+	return records
+}
+
+// buildResponse 生成应答报文，questions为空时包含全部记录（通告），否则只包含匹配的记录；没有匹配时返回nil。
+// legacy为true时按传统单播查询应答，带上查询的ID和问题
+func (r *MDNSResponder) buildResponse(questions []dnsQuestion, ttl uint32, legacy bool, id uint16) []byte {
+	var answers []mdnsRecord
+	for _, record := range r.records(ttl) {
+		if questions == nil {
+			answers = append(answers, record)
+			continue
+		}
+		for _, q := range questions {
+			if strings.EqualFold(strings.TrimSuffix(q.Name, ".")+".", record.name) && (q.Type == record.rtype || q.Type == dnsTypeANY) {
+				answers = append(answers, record)
+				break
+			}
+		}
+	}
+	if len(answers) == 0 {
+		return nil
+	}
+
+	// 标准响应，权威应答
+	qdCount := uint16(0)
+	if legacy {
+		qdCount = uint16(len(questions))
+	}
+	msg := dnsHeader(id, 0x8400, qdCount, uint16(len(answers)), 0, 0)
+	if legacy {
+		for _, q := range questions {
+			msg = appendDNSQuestion(msg, q.Name, q.Type, dnsClassIN)
+		}
+	}
+	for _, record := range answers {
+		class := uint16(dnsClassIN)
+		// 传统单播应答不设置缓存刷新位
+		if record.unique && !legacy {
+			class |= mdnsCacheFlush
+		}
+		msg = appendDNSRecord(msg, record.name, record.rtype, class, record.ttl, record.rdata)
+	}
+	return msg
+}
+
+// parseDNSQuestions 解析查询报文中的问题，响应报文返回空
+func parseDNSQuestions(msg []byte) ([]dnsQuestion, error) {
+	if len(msg) < 12 || msg[2]&0x80 != 0 {
+		return nil, nil
+	}
+	count := int(binary.BigEndian.Uint16(msg[4:6]))
+	offset := 12
+	var questions []dnsQuestion
+	for i := 0; i < count; i++ {
+		name, next, err := readDNSName(msg, offset)
+		if err != nil || next+4 > len(msg) {
+			return nil, fmt.Errorf("DNS报文格式错误")
+		}
+		questions = append(questions, dnsQuestion{Name: name, Type: binary.BigEndian.Uint16(msg[next : next+2])})
+		offset = next + 4
+	}
+	return questions, nil
+}
+
+// parseMDNSRecords 解析响应报文中的全部资源记录，查询报文返回空
+func parseMDNSRecords(msg []byte) ([]mdnsRecord, error) {
+	if len(msg) < 12 || msg[2]&0x80 == 0 {
+		return nil, nil
+	}
+	qdCount := int(binary.BigEndian.Uint16(msg[4:6]))
+	rrCount := int(binary.BigEndian.Uint16(msg[6:8])) + int(binary.BigEndian.Uint16(msg[8:10])) + int(binary.BigEndian.Uint16(msg[10:12]))
+	offset := 12
+	for i := 0; i < qdCount; i++ {
+		_, next, err := readDNSName(msg, offset)
+		if err != nil || next+4 > len(msg) {
+			return nil, fmt.Errorf("DNS报文格式错误")
+		}
+		offset = next + 4
+	}
+	var records []mdnsRecord
+	for i := 0; i < rrCount; i++ {
+		name, next, err := readDNSName(msg, offset)
+		if err != nil || next+10 > len(msg) {
+			return nil, fmt.Errorf("DNS报文格式错误")
+		}
+		length := int(binary.BigEndian.Uint16(msg[next+8 : next+10]))
+		if next+10+length > len(msg) {
+			return nil, fmt.Errorf("DNS报文格式错误")
+		}
+		records = append(records, mdnsRecord{
+			name:  name,
+			rtype: binary.BigEndian.Uint16(msg[next : next+2]),
+			ttl:   binary.BigEndian.Uint32(msg[next+4 : next+8]),
+			rdata: msg[next+10 : next+10+length],
+		})
+		offset = next + 10 + length
+	}
+	return records, nil
+}
+
+// readDNSName 读取offset处的域名（支持压缩指针），返回域名和域名之后的位置
+func readDNSName(msg []byte, offset int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; offset < len(msg); {
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case length&0xc0 == 0xc0:
+			if offset+1 >= len(msg) || jumps > 10 {
+				return "", 0, fmt.Errorf("DNS报文格式错误")
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:offset+2]) & 0x3fff)
+			jumps++
+		default:
+			if offset+1+length > len(msg) {
+				return "", 0, fmt.Errorf("DNS报文格式错误")
+			}
+			labels = append(labels, string(msg[offset+1:offset+1+length]))
+			offset += length + 1
+		}
+	}
+	return "", 0, fmt.Errorf("DNS报文格式错误")
+}
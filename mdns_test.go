@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMDNSConflicts(t *testing.T) {
+	services := []MDNSService{{Type: "_ssh._tcp", Port: 22}}
+	local := NewMDNSResponder("office-pc", services)
+	local.ipv4 = net.ParseIP("192.168.1.10").To4()
+
+	tests := []struct {
+		name string
+		host string
+		ip   string
+		ttl  uint32
+		want bool
+	}{
+		{"组播环回收到本机通告", "office-pc", "192.168.1.10", mdnsHostTTL, false},
+		{"其他设备使用同名不同地址", "office-pc", "192.168.1.20", mdnsHostTTL, true},
+		{"其他设备撤销通告", "office-pc", "192.168.1.20", 0, false},
+		{"不同主机名", "laptop", "192.168.1.20", mdnsHostTTL, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := NewMDNSResponder(tt.host, services)
+			remote.ipv4 = net.ParseIP(tt.ip).To4()
+			records, err := parseMDNSRecords(remote.buildResponse(nil, tt.ttl, false, 0))
+			if err != nil {
+				t.Fatalf("解析应答失败: %v", err)
+			}
+			got := false
+			for _, record := range records {
+				if local.conflicts(record) {
+					got = true
+				}
+			}
+			if got != tt.want {
+				t.Fatalf("冲突 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMDNSBuildProbe(t *testing.T) {
+	responder := NewMDNSResponder("office-pc", []MDNSService{{Type: "_ssh._tcp", Port: 22}})
+	responder.ipv4 = net.ParseIP("192.168.1.10").To4()
+	questions, err := parseDNSQuestions(responder.buildProbe())
+	if err != nil {
+		t.Fatalf("解析探测报文失败: %v", err)
+	}
+	want := []dnsQuestion{
+		{Name: "office-pc.local.", Type: dnsTypeANY},
+		{Name: "office-pc._ssh._tcp.local.", Type: dnsTypeANY},
+	}
+	if len(questions) != len(want) {
+		t.Fatalf("问题 = %v，期望 %v", questions, want)
+	}
+	for i := range want {
+		if questions[i] != want[i] {
+			t.Fatalf("问题 = %v，期望 %v", questions, want)
+		}
+	}
+}
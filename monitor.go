@@ -0,0 +1,688 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Monitor WiFi监控器，周期性检查WiFi连接并维护运行状态
+type Monitor struct {
+	connector         WiFiConnector
+	ipDetector        *IPChangeDetector
+	wifiStateDetector *WiFiStateDetector
+	status            *MonitorStatus
+	// trigger 用于请求立即执行一次检查
+	trigger chan struct{}
+	// forceReconnect 下一次检查时即使已连接到目标网络也重新连接
+	forceReconnect atomic.Bool
+	// connectivityKnown 是否已有连通性检测结果
+	connectivityKnown bool
+	// online 最近一次连通性检测时互联网是否可用
+	online bool
+	// offlineSince 互联网不可用的开始时间
+	offlineSince time.Time
+	// lastRoamCheck 最近一次检查是否需要切换接入点的时间
+	lastRoamCheck time.Time
+	// roamUnsupported 当前平台不支持连接到指定接入点，不再尝试切换
+	roamUnsupported bool
+	// wired 当前已连接的有线网络接口，未连接或未启用检测时为nil
+	wired *WiredInterface
+	// defaultRoute 最近一次检测到的默认路由接口
+	defaultRoute string
+	// targets 该网卡的目标网络，按优先级排列
+	targets []string
+	// target 当前连接或正在尝试连接的目标网络
+	target string
+	// primary 是否为主监控器，有线网络检测、动态DNS和mDNS通告只由主监控器处理
+	primary bool
+	// detect 网卡不存在时重新检测网卡，为nil时只等待指定的网卡重新插入
+	detect func() (WiFiConnector, error)
+	// adapterKnown 是否已检查过网卡是否存在
+	adapterKnown bool
+	// adapterPresent 最近一次检查时网卡是否存在
+	adapterPresent bool
+	// adapterName 网卡不存在时最近一次使用的接口名称
+	adapterName string
+	// adapterLostSince 网卡移除（或启动时未检测到网卡）的时间
+	adapterLostSince time.Time
+	// detectFailures 网卡不存在时连续重新检测失败的次数，nextDetect 为下次允许重新检测的时间
+	detectFailures int
+	nextDetect     time.Time
+	// portalAttempts 各认证页面自动登录的失败记录，用于退避
+	portalAttempts map[string]*portalAttempt
+}
+
+// NewMonitor 创建新的WiFi监控器，targets为按优先级排列的目标网络
+func NewMonitor(connector WiFiConnector, targets []string, primary bool) *Monitor {
+	return &Monitor{
+		connector:         connector,
+		ipDetector:        NewIPChangeDetector(),
+		wifiStateDetector: NewWiFiStateDetector(),
+		status:            NewMonitorStatus(targets[0]),
+		trigger:           make(chan struct{}, 1),
+		targets:           targets,
+		target:            targets[0],
+		primary:           primary,
+	}
+}
+
+// Status 获取监控器维护的运行状态
+func (m *Monitor) Status() *MonitorStatus {
+	return m.status
+}
+
+// TriggerCheck 请求立即执行一次检查，已有待处理请求时忽略
+func (m *Monitor) TriggerCheck() {
+	select {
+	case m.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// RequestReconnect 请求立即重新连接目标网络
+func (m *Monitor) RequestReconnect() {
+	m.forceReconnect.Store(true)
+	m.TriggerCheck()
+}
+
+// Run 执行检查并按间隔周期性检查，收到触发请求时立即检查
+func (m *Monitor) Run(interval time.Duration) {
+	// 执行检查和连接
+	m.checkAndConnect()
+
+	// 定期检查
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-m.trigger:
+		}
+		m.checkAndConnect()
+	}
+}
+
+// setTarget 切换当前目标网络
+func (m *Monitor) setTarget(ssid string) {
+	if m.target == ssid {
+		return
+	}
+	m.target = ssid
+	m.status.SetTargetNetwork(ssid)
+}
+
+// notifyName 返回通知中展示的网络名称，管理多个WiFi网卡时附带接口名称
+func (m *Monitor) notifyName(networkName, interfaceName string) string {
+	if !multiAdapter {
+		return networkName
+	}
+	return fmt.Sprintf("%s [%s]", networkName, interfaceName)
+}
+
+// record 记录一条事件到历史存储和运行状态
+func (m *Monitor) record(event HistoryEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	recordHistory(event)
+	m.status.AddEvent(event)
+}
+
+// checkAndConnect 检查并连接WiFi的主要逻辑
+func (m *Monitor) checkAndConnect() {
+	// 有线网络已连接时按策略处理WiFi
+	if wired := m.checkWired(); wired != nil {
+		switch wiredPolicy {
+		case WiredPolicyIgnore:
+			log.Printf("有线网络 %s 已连接，跳过WiFi检查", wired.Name)
+			return
+		case WiredPolicyReport:
+			log.Printf("有线网络 %s 已连接，跳过WiFi检查，当前IP地址: %s", wired.Name, wired.IPAddress)
+			m.status.ClearError()
+			info := m.handleIPAddress(wired.IPAddress, wired.Name, false)
+			m.checkConnectivity(wired.Name, info)
+			return
+		}
+	}
+
+	// 网卡不存在时等待插入
+	if !m.checkAdapter() {
+		return
+	}
+
+	// 自动检测WiFi网卡接口
+	interfaceName, err := m.connector.GetInterface()
+	if err != nil {
+		log.Printf("获取WiFi接口失败: %v", err)
+		m.status.SetError(err)
+		return
+	}
+	log.Printf("检测到WiFi接口: %s", interfaceName)
+
+	// 检查WiFi是否启用
+	if !m.connector.IsEnabled() {
+		log.Println("WiFi未启用，正在启用...")
+		if err := m.connector.Enable(); err != nil {
+			log.Printf("启用WiFi失败: %v", err)
+			m.status.SetError(err)
+			return
+		}
+		log.Println("WiFi已启用")
+		// 等待WiFi启用完成
+		time.Sleep(3 * time.Second)
+	}
+
+	// 获取当前连接的WiFi
+	currentWiFi, err := m.connector.GetCurrentNetwork()
+	if err != nil {
+		log.Printf("获取当前WiFi失败: %v", err)
+		m.status.SetError(err)
+		return
+	}
+
+	if currentWiFi == "" {
+		log.Println("当前未连接任何WiFi网络")
+	} else {
+		log.Printf("当前连接的WiFi: %s", currentWiFi)
+	}
+	m.status.UpdateNetwork(interfaceName, currentWiFi)
+	// 离开目标网络时撤销mDNS通告，重新连接后再通告
+	if m.primary && !slices.Contains(m.targets, currentWiFi) && mdnsResponder != nil {
+		mdnsResponder.Withdraw()
+	}
+
+	// 检查WiFi状态是否发生变化
+	wifiStateChanged := m.wifiStateDetector.CheckWiFiStateChange(currentWiFi)
+	if wifiStateChanged {
+		previousWiFi := m.wifiStateDetector.GetPreviousNetwork()
+		stateSSID := currentWiFi
+		if stateSSID == "" {
+			// 断开连接时记录离开的网络
+			stateSSID = previousWiFi
+		}
+		m.record(HistoryEvent{
+			Type:      HistoryEventState,
+			SSID:      stateSSID,
+			Interface: interfaceName,
+			From:      previousWiFi,
+			To:        currentWiFi,
+		})
+	}
+
+	// 如果当前WiFi不是目标WiFi或请求了重新连接，则尝试连接
+	forceReconnect := m.forceReconnect.Swap(false)
+	if !slices.Contains(m.targets, currentWiFi) || forceReconnect {
+		target, detected, visible := m.scanTargets()
+		if !visible {
+			err := fmt.Errorf("未扫描到目标WiFi网络: %s", strings.Join(m.targets, "、"))
+			log.Printf("%v，跳过本次连接", err)
+			m.status.SetError(err)
+			return
+		}
+		m.setTarget(target)
+		options := targetConnectOptions(target)
+		password := networkPassword(target)
+		if options.Security == SecurityAuto {
+			switch detected {
+			case SecurityAuto:
+			case SecurityEnterprise:
+				log.Printf("目标WiFi为企业级网络，但未在网络配置中配置enterprise认证凭据")
+			case SecurityWPA2PSK, SecurityWPA3SAE, SecurityWPA2WPA3:
+				// 未指定密码时使用系统保存的配置
+				if password == "" {
+					break
+				}
+				fallthrough
+			default:
+				// 扫描结果只作为新建配置的参考，不当作指定的安全类型，避免每次重连都按扫描结果改写系统中保存的连接配置
+				log.Printf("根据扫描结果判断目标WiFi的安全类型: %s", detected)
+				options.Detected = detected
+			}
+		}
+		if forceReconnect {
+			log.Printf("收到重新连接请求，重新连接到WiFi: %s", m.target)
+		} else {
+			log.Printf("尝试连接到WiFi: %s", m.target)
+		}
+		startTime := time.Now()
+		err := m.connector.Connect(m.target, password, options)
+		attempt := HistoryEvent{
+			Type:       HistoryEventConnect,
+			SSID:       m.target,
+			Interface:  interfaceName,
+			From:       currentWiFi,
+			Success:    err == nil,
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		m.record(attempt)
+		if err != nil {
+			log.Printf("连接WiFi失败: %v", err)
+			m.status.SetError(err)
+		} else {
+			log.Printf("成功连接到WiFi: %s", m.target)
+			m.status.UpdateNetwork(interfaceName, m.target)
+			// 等待网络配置完成
+			time.Sleep(2 * time.Second)
+			m.applyIPConfig()
+			// 获取并显示IP地址
+			var info *NetworkInfo
+			if ipAddr, err := m.obtainIPAddress(interfaceName, false); err != nil {
+				log.Printf("获取IP地址失败: %v", err)
+				m.status.SetError(err)
+			} else {
+				log.Printf("分配到的IP地址: %s", ipAddr)
+				m.status.ClearError()
+				info = m.reportAddress(ipAddr, interfaceName, wifiStateChanged)
+			}
+			m.checkConnectivity(interfaceName, info)
+		}
+	} else {
+		m.setTarget(currentWiFi)
+		log.Printf("已连接到目标WiFi: %s", m.target)
+		// 显示当前IP地址
+		var info *NetworkInfo
+		if ipAddr, err := m.obtainIPAddress(interfaceName, true); err != nil {
+			log.Printf("获取IP地址失败: %v", err)
+			m.status.SetError(err)
+		} else {
+			log.Printf("当前IP地址: %s", ipAddr)
+			// 固定IP被系统或DHCP改掉时重新应用
+			if config := targetIPConfig(m.target); config != nil && config.IsStatic() && ipAddr != config.IP() {
+				log.Printf("当前IP地址与配置的固定IP %s 不一致，重新应用IP配置", config.IP())
+				m.applyIPConfig()
+				if newIP, err := m.connector.GetIPAddress(); err == nil {
+					ipAddr = newIP
+				}
+			}
+			m.status.ClearError()
+			info = m.reportAddress(ipAddr, interfaceName, wifiStateChanged)
+		}
+		m.checkConnectivity(interfaceName, info)
+		m.checkRoaming(interfaceName, info)
+	}
+}
+
+// checkRoaming 比较当前接入点与同名网络其他接入点的信号强度，超过阈值时切换到更强的接入点
+func (m *Monitor) checkRoaming(interfaceName string, info *NetworkInfo) {
+	if !enableRoaming || m.roamUnsupported || info == nil || info.BSSID == "" || info.SignalDBm == 0 {
+		return
+	}
+	if time.Since(m.lastRoamCheck) < time.Duration(roamInterval)*time.Second {
+		return
+	}
+	m.lastRoamCheck = time.Now()
+
+	results, err := m.connector.Scan()
+	if err != nil {
+		log.Printf("扫描WiFi网络失败，跳过接入点切换检查: %v", err)
+		return
+	}
+	var best *ScanResult
+	for _, result := range filterScanResults(results, m.target) {
+		if result.BSSID == "" || strings.EqualFold(result.BSSID, info.BSSID) {
+			continue
+		}
+		if best == nil || result.SignalDBm > best.SignalDBm {
+			best = &result
+		}
+	}
+	if best == nil {
+		return
+	}
+	if best.SignalDBm-info.SignalDBm < roamHysteresis {
+		log.Printf("当前接入点 %s 信号 %d dBm，最强的其他接入点 %s 信号 %d dBm，未达到切换阈值",
+			info.BSSID, info.SignalDBm, best.BSSID, best.SignalDBm)
+		return
+	}
+
+	log.Printf("切换接入点: %s (%d dBm) -> %s (%d dBm)", info.BSSID, info.SignalDBm, best.BSSID, best.SignalDBm)
+	startTime := time.Now()
+	err = m.connector.ConnectBSSID(m.target, best.BSSID, networkPassword(m.target))
+	if errors.Is(err, ErrBSSIDUnsupported) {
+		log.Printf("%v，停止接入点切换", err)
+		m.roamUnsupported = true
+		return
+	}
+	event := HistoryEvent{
+		Type:       HistoryEventRoam,
+		SSID:       m.target,
+		Interface:  interfaceName,
+		From:       info.BSSID,
+		To:         best.BSSID,
+		Success:    err == nil,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	m.record(event)
+	if err != nil {
+		log.Printf("切换接入点失败: %v", err)
+		m.status.SetError(err)
+		return
+	}
+	log.Printf("已切换到接入点: %s", best.BSSID)
+	// 切换后IP地址等信息可能变化，立即重新检查
+	m.TriggerCheck()
+}
+
+// applyIPConfig 为目标网络应用配置的IP设置，固定IP时校验实际地址是否生效
+func (m *Monitor) applyIPConfig() {
+	config := targetIPConfig(m.target)
+	if config == nil {
+		return
+	}
+	log.Printf("应用IP配置: %s", config)
+	if err := m.connector.ApplyIPConfig(config); err != nil {
+		log.Printf("应用IP配置失败: %v", err)
+		m.status.SetError(err)
+		return
+	}
+	if !config.IsStatic() {
+		return
+	}
+
+	// 等待地址生效
+	for i := 0; i < 5; i++ {
+		time.Sleep(1 * time.Second)
+		if ipAddr, err := m.connector.GetIPAddress(); err == nil && ipAddr == config.IP() {
+			log.Printf("固定IP地址已生效: %s", ipAddr)
+			return
+		}
+	}
+	err := fmt.Errorf("固定IP地址未生效: 期望 %s", config.IP())
+	log.Printf("%v", err)
+	m.status.SetError(err)
+}
+
+// obtainIPAddress 获取当前IP地址，没有地址或只有链路本地地址时续租DHCP。
+// escalate为true时续租失败会请求重新连接目标网络
+func (m *Monitor) obtainIPAddress(interfaceName string, escalate bool) (string, error) {
+	ipAddr, err := m.connector.GetIPAddress()
+	if err == nil && !isLinkLocalIPv4(ipAddr) {
+		return ipAddr, nil
+	}
+	// 固定IP由applyIPConfig负责，不续租DHCP
+	if config := targetIPConfig(m.target); config != nil && config.IsStatic() {
+		return ipAddr, err
+	}
+	if err != nil {
+		log.Printf("未获取到IP地址（%v），尝试续租DHCP", err)
+	} else {
+		log.Printf("当前IP地址 %s 为链路本地地址，未从DHCP获取到地址，尝试续租DHCP", ipAddr)
+	}
+
+	startTime := time.Now()
+	renewedIP, renewErr := m.renewDHCP()
+	event := HistoryEvent{
+		Type:       HistoryEventDHCP,
+		SSID:       m.target,
+		Interface:  interfaceName,
+		From:       ipAddr,
+		To:         renewedIP,
+		Success:    renewErr == nil,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}
+	if renewErr != nil {
+		event.Error = renewErr.Error()
+	}
+	m.record(event)
+	if renewErr == nil {
+		log.Printf("DHCP续租成功，IP地址: %s", renewedIP)
+		return renewedIP, nil
+	}
+
+	log.Printf("DHCP续租失败: %v", renewErr)
+	if escalate {
+		log.Printf("DHCP续租失败，重新连接WiFi: %s", m.target)
+		m.RequestReconnect()
+	}
+	if err != nil {
+		return "", fmt.Errorf("未获取到IP地址，DHCP续租失败: %v", renewErr)
+	}
+	return "", fmt.Errorf("仅有链路本地地址 %s，DHCP续租失败: %v", ipAddr, renewErr)
+}
+
+// renewDHCP 续租DHCP并等待获取到有效的IP地址
+func (m *Monitor) renewDHCP() (string, error) {
+	if err := m.connector.RenewDHCP(); err != nil {
+		return "", err
+	}
+	deadline := time.Now().Add(time.Duration(dhcpTimeout) * time.Second)
+	for {
+		ipAddr, err := m.connector.GetIPAddress()
+		if err == nil && !isLinkLocalIPv4(ipAddr) {
+			return ipAddr, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("等待DHCP分配地址超时（%d秒）", dhcpTimeout)
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
+
+// scanTargets 扫描附近网络，按优先级返回第一个可见的目标网络以及扫描结果中的安全类型。
+// 扫描失败或隐藏网络时按可见处理以免错过连接
+func (m *Monitor) scanTargets() (string, SecurityType, bool) {
+	var results []ScanResult
+	var scanErr error
+	scanned := false
+	for _, ssid := range m.targets {
+		// 隐藏网络不会出现在扫描结果中
+		if targetConnectOptions(ssid).Hidden {
+			return ssid, SecurityAuto, true
+		}
+		if !scanned {
+			results, scanErr = m.connector.Scan()
+			scanned = true
+			if scanErr != nil {
+				log.Printf("扫描WiFi网络失败，直接尝试连接: %v", scanErr)
+			}
+		}
+		if scanErr != nil {
+			return ssid, SecurityAuto, true
+		}
+		matched := filterScanResults(results, ssid)
+		if len(matched) == 0 {
+			continue
+		}
+		sortScanResults(matched)
+		log.Printf("扫描到目标WiFi: %s（%d个接入点，最强信号 %d dBm）", ssid, len(matched), matched[0].SignalDBm)
+		return ssid, matched[0].SecurityType(), true
+	}
+	return "", SecurityAuto, false
+}
+
+// reportAddress 上报当前使用的地址，有线网络已连接且策略为standby时上报有线网络地址，WiFi作为备用
+func (m *Monitor) reportAddress(ipAddr, interfaceName string, wifiStateChanged bool) *NetworkInfo {
+	if m.wired != nil && wiredPolicy.ReportsWired() {
+		log.Printf("有线网络 %s 已连接，WiFi地址 %s 作为备用，上报有线网络地址: %s", m.wired.Name, ipAddr, m.wired.IPAddress)
+		// mDNS只在WiFi接口上通告，上报有线网络地址时仍通告WiFi地址
+		if m.primary && mdnsResponder != nil {
+			mdnsResponder.Announce(interfaceName, ipAddr)
+		}
+		return m.handleIPAddress(m.wired.IPAddress, m.wired.Name, wifiStateChanged)
+	}
+	return m.handleIPAddress(ipAddr, interfaceName, wifiStateChanged)
+}
+
+// handleIPAddress 检测IP地址是否变化，记录历史并按需发送通知，返回详细网络信息
+func (m *Monitor) handleIPAddress(ipAddr, interfaceName string, wifiStateChanged bool) *NetworkInfo {
+	m.status.SetIPAddress(ipAddr)
+	// 上报有线网络地址时没有WiFi相关信息
+	wired := m.wired != nil && interfaceName == m.wired.Name
+	networkName := m.target
+	if wired {
+		networkName = "有线网络 " + interfaceName
+	}
+
+	log.Printf("检测IP地址变化: %s", ipAddr)
+	ipChanged := m.ipDetector.CheckIPChange(ipAddr)
+	if ipChanged {
+		m.record(HistoryEvent{
+			Type:      HistoryEventIP,
+			SSID:      networkName,
+			Interface: interfaceName,
+			From:      m.ipDetector.GetPreviousIP(),
+			To:        ipAddr,
+		})
+		// 局域网IP变化时公网IP也可能变化，立即重新检测
+		if m.primary && wanDetector != nil {
+			wanDetector.TriggerCheck()
+		}
+	}
+	// 每次检查都提交，已生效的地址不会重复更新，失败的更新会在下次检查时重试
+	if m.primary && ddnsUpdater != nil {
+		ddnsUpdater.UpdateAsync(DDNSSourceLAN, ipAddr)
+	}
+	// mDNS只在WiFi接口上通告，report策略下不检查WiFi，保持之前的通告
+	if m.primary && mdnsResponder != nil && !wired {
+		mdnsResponder.Announce(interfaceName, ipAddr)
+	}
+
+	// 检测IPv6地址变化，未启用时ipv6保持为nil，通知中不展示
+	var ipv6 []string
+	ipv6Changed := false
+	if enableIPv6 && !wired {
+		addrs, err := m.connector.GetIPv6Addresses(ipv6Options)
+		if err != nil {
+			log.Printf("获取IPv6地址失败: %v", err)
+		} else {
+			ipv6 = append([]string{}, addrs...)
+			log.Printf("当前IPv6地址: %s", formatIPv6List(ipv6))
+			m.status.SetIPv6Addresses(ipv6)
+			ipv6Changed = m.ipDetector.CheckIPv6Change(ipv6)
+			if ipv6Changed {
+				m.record(HistoryEvent{
+					Type:      HistoryEventIPv6,
+					SSID:      m.target,
+					Interface: interfaceName,
+					From:      strings.Join(m.ipDetector.GetPreviousIPv6(), ","),
+					To:        strings.Join(ipv6, ","),
+				})
+			}
+		}
+	}
+
+	// 获取详细网络信息，失败时仅使用已知的IP地址
+	var info *NetworkInfo
+	if wired {
+		info = m.wired.NetworkInfo(m.defaultRoute)
+	} else {
+		var err error
+		info, err = m.connector.GetNetworkInfo()
+		if err != nil {
+			log.Printf("获取详细网络信息失败: %v", err)
+			info = &NetworkInfo{Interface: interfaceName, SSID: m.target, IPAddress: ipAddr}
+		}
+		info.DefaultRoute = m.defaultRoute
+	}
+	info.IPv6Addresses = ipv6
+	m.status.SetNetworkInfo(info)
+	if m.ipDetector.UpdateNetworkInfo(info) {
+		previous := m.ipDetector.GetPreviousNetworkInfo()
+		log.Printf("网络信息变化: 网关 %s -> %s, BSSID %s -> %s", previous.Gateway, info.Gateway, previous.BSSID, info.BSSID)
+	}
+
+	if !enableNotification {
+		log.Printf("通知功能未启用")
+		return info
+	}
+	// 有线网络名称中已包含接口名称
+	notifyName := networkName
+	if !wired {
+		notifyName = m.notifyName(networkName, interfaceName)
+	}
+	if feishuNotifier == nil {
+		log.Printf("飞书通知器未初始化")
+		return info
+	}
+
+	if ipChanged {
+		oldIP := m.ipDetector.GetPreviousIP()
+		log.Printf("发送飞书通知(因IP变化): %s -> %s", oldIP, ipAddr)
+		feishuNotifier.SendIPChangeNotificationAsync(oldIP, ipAddr, notifyName, info)
+	} else if ipv6Changed {
+		oldIPv6 := m.ipDetector.GetPreviousIPv6()
+		log.Printf("发送飞书通知(因IPv6变化): %s -> %s", formatIPv6List(oldIPv6), formatIPv6List(ipv6))
+		feishuNotifier.SendIPv6ChangeNotificationAsync(oldIPv6, ipv6, ipAddr, notifyName)
+	} else if wifiStateChanged {
+		// 即使IP未变化，但如果WiFi重新连接了，也要发送通知
+		log.Printf("发送飞书通知(因WiFi重新连接): %s", ipAddr)
+		feishuNotifier.SendWiFiReconnectNotificationAsync(ipAddr, notifyName, info)
+	} else {
+		log.Printf("IP地址未变化，WiFi状态未变化，不发送通知: %s", ipAddr)
+	}
+	return info
+}
+
+// checkConnectivity 在已连接WiFi后检测互联网连通性，连通性丢失或恢复时记录历史并发送通知
+func (m *Monitor) checkConnectivity(interfaceName string, info *NetworkInfo) {
+	if connectivityChecker == nil {
+		return
+	}
+
+	gateway, ipAddr := "", ""
+	if info != nil {
+		gateway, ipAddr = info.Gateway, info.IPAddress
+	}
+
+	report := connectivityChecker.Check(gateway)
+	if report.CaptivePortal {
+		report = m.handleCaptivePortal(interfaceName, gateway, report)
+	}
+	m.status.SetConnectivity(report)
+	if report.Online {
+		log.Printf("互联网连通性检测通过")
+	} else {
+		log.Printf("已连接WiFi但互联网不可用:\n%s", report.FailureSummary())
+	}
+
+	// 首次检测只记录状态
+	if !m.connectivityKnown {
+		m.connectivityKnown = true
+		m.online = report.Online
+		if !report.Online {
+			m.offlineSince = report.Time
+		}
+		return
+	}
+	if report.Online == m.online {
+		return
+	}
+
+	m.online = report.Online
+	event := HistoryEvent{
+		Type:      HistoryEventConnectivity,
+		SSID:      m.target,
+		Interface: interfaceName,
+	}
+	if report.Online {
+		downtime := report.Time.Sub(m.offlineSince)
+		event.From, event.To = string(StateDegraded), string(StateConnected)
+		event.DurationMs = downtime.Milliseconds()
+		m.record(event)
+		log.Printf("互联网连通性已恢复，中断时长: %s", downtime.Round(time.Second))
+		if enableNotification && feishuNotifier != nil {
+			feishuNotifier.SendConnectivityRestoredNotificationAsync(m.notifyName(m.target, interfaceName), ipAddr, downtime)
+		}
+	} else {
+		m.offlineSince = report.Time
+		event.From, event.To = string(StateConnected), string(StateDegraded)
+		event.Error = report.FailureSummary()
+		m.record(event)
+		log.Printf("互联网连通性丢失")
+		if enableNotification && feishuNotifier != nil {
+			feishuNotifier.SendConnectivityLostNotificationAsync(m.notifyName(m.target, interfaceName), ipAddr, report.FailureSummary())
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// netlink监听使用的常量
+const (
+	// netlinkEventDelay 收到事件后等待后续事件的时间，连接过程中的一串事件只触发一次检查
+	netlinkEventDelay = 500 * time.Millisecond
+	// netlinkMinTriggerInterval 两次由事件触发的检查之间的最小间隔，避免连接失败产生的事件导致连续重连
+	netlinkMinTriggerInterval = 5 * time.Second
+
+	rtmgrpLink       = 0x1
+	rtmgrpIPv4IfAddr = 0x10
+	rtmgrpIPv6IfAddr = 0x100
+
+	genlIDCtrl           = 0x10
+	genlCmdGetFamily     = 3
+	genlAttrFamilyName   = 2
+	genlAttrFamilyID     = 1
+	genlAttrMcastGroups  = 7
+	genlAttrMcastGrpName = 1
+	genlAttrMcastGrpID   = 2
+	solNetlink           = 270
+	netlinkAddMembership = 1
+)
+
+// watchNetworkEvents 通过netlink监听链路、地址（rtnetlink）和WiFi关联（nl80211）变化，
+// 变化时调用notify。无法打开rtnetlink时返回错误，nl80211不可用时只监听链路和地址变化
+func watchNetworkEvents(notify func()) error {
+	routeFd, err := openNetlinkSocket(syscall.NETLINK_ROUTE, rtmgrpLink|rtmgrpIPv4IfAddr|rtmgrpIPv6IfAddr)
+	if err != nil {
+		return fmt.Errorf("打开rtnetlink失败: %v", err)
+	}
+	events := make(chan string, 16)
+	go readNetlinkEvents(routeFd, events, parseRouteEvent)
+
+	if genlFd, family, err := openNL80211Socket(); err != nil {
+		log.Printf("监听WiFi关联事件失败，只监听链路和地址变化: %v", err)
+	} else {
+		go readNetlinkEvents(genlFd, events, func(msg syscall.NetlinkMessage) string {
+			if msg.Header.Type != family || len(msg.Data) < 4 {
+				return ""
+			}
+			return fmt.Sprintf("WiFi关联变化（nl80211命令%d）", msg.Data[0])
+		})
+	}
+
+	go throttleNetworkEvents(events, notify)
+	return nil
+}
+
+// openNetlinkSocket 打开netlink套接字并加入指定的组播组
+func openNetlinkSocket(protocol, groups int) (int, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, protocol)
+	if err != nil {
+		return -1, err
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: uint32(groups)}); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	return fd, nil
+}
+
+// openNL80211Socket 打开通用netlink套接字并加入nl80211的mlme组播组（连接、断开、认证、关联等事件），
+// 返回套接字和nl80211的family ID。不加入scan组，避免扫描结果触发检查
+func openNL80211Socket() (int, uint16, error) {
+	fd, err := openNetlinkSocket(syscall.NETLINK_GENERIC, 0)
+	if err != nil {
+		return -1, 0, err
+	}
+	family, groups, err := resolveGenlFamily(fd, "nl80211")
+	if err != nil {
+		syscall.Close(fd)
+		return -1, 0, err
+	}
+	group, ok := groups["mlme"]
+	if !ok {
+		syscall.Close(fd)
+		return -1, 0, fmt.Errorf("nl80211没有mlme组播组")
+	}
+	if err := syscall.SetsockoptInt(fd, solNetlink, netlinkAddMembership, int(group)); err != nil {
+		syscall.Close(fd)
+		return -1, 0, fmt.Errorf("加入nl80211组播组失败: %v", err)
+	}
+	return fd, family, nil
+}
+
+// resolveGenlFamily 查询通用netlink协议族的ID和组播组
+func resolveGenlFamily(fd int, name string) (uint16, map[string]uint32, error) {
+	// 请求: netlink头 + genl头(命令、版本) + 协议族名称属性
+	attr := appendNetlinkAttr(nil, genlAttrFamilyName, append([]byte(name), 0))
+	msg := make([]byte, syscall.NLMSG_HDRLEN+4, syscall.NLMSG_HDRLEN+4+len(attr))
+	msg = append(msg, attr...)
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(len(msg)))
+	binary.NativeEndian.PutUint16(msg[4:6], genlIDCtrl)
+	binary.NativeEndian.PutUint16(msg[6:8], syscall.NLM_F_REQUEST)
+	binary.NativeEndian.PutUint32(msg[8:12], 1)
+	msg[syscall.NLMSG_HDRLEN] = genlCmdGetFamily
+	msg[syscall.NLMSG_HDRLEN+1] = 1
+	if err := syscall.Sendto(fd, msg, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return 0, nil, fmt.Errorf("查询%s失败: %v", name, err)
+	}
+
+	buf := make([]byte, 65536)
+	n, _, err := syscall.Recvfrom(fd, buf, 0)
+	if err != nil {
+		return 0, nil, fmt.Errorf("查询%s失败: %v", name, err)
+	}
+	msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+	if err != nil || len(msgs) == 0 {
+		return 0, nil, fmt.Errorf("解析%s查询结果失败", name)
+	}
+	reply := msgs[0]
+	if reply.Header.Type == syscall.NLMSG_ERROR {
+		if len(reply.Data) >= 4 {
+			if errno := -int32(binary.NativeEndian.Uint32(reply.Data[0:4])); errno != 0 {
+				return 0, nil, fmt.Errorf("内核不支持%s: %v", name, syscall.Errno(errno))
+			}
+		}
+		return 0, nil, fmt.Errorf("内核不支持%s", name)
+	}
+	if len(reply.Data) < 4 {
+		return 0, nil, fmt.Errorf("解析%s查询结果失败", name)
+	}
+
+	attrs := parseNetlinkAttrs(reply.Data[4:])
+	idAttr := attrs[genlAttrFamilyID]
+	if len(idAttr) < 2 {
+		return 0, nil, fmt.Errorf("解析%s查询结果失败", name)
+	}
+	groups := make(map[string]uint32)
+	for _, group := range parseNetlinkAttrs(attrs[genlAttrMcastGroups]) {
+		groupAttrs := parseNetlinkAttrs(group)
+		if id := groupAttrs[genlAttrMcastGrpID]; len(id) >= 4 {
+			groups[strings.TrimRight(string(groupAttrs[genlAttrMcastGrpName]), "\x00")] = binary.NativeEndian.Uint32(id)
+		}
+	}
+	return binary.NativeEndian.Uint16(idAttr), groups, nil
+}
+
+// readNetlinkEvents 读取netlink消息，describe返回非空说明时发送到events，套接字出错时退出
+func readNetlinkEvents(fd int, events chan<- string, describe func(syscall.NetlinkMessage) string) {
+	defer syscall.Close(fd)
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			if err == syscall.ENOBUFS {
+				// 接收缓冲区溢出时丢失了部分事件，直接触发一次检查
+				events <- "netlink事件溢出"
+				continue
+			}
+			log.Printf("读取netlink事件失败，停止监听: %v", err)
+			return
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		for _, msg := range msgs {
+			if reason := describe(msg); reason != "" {
+				events <- reason
+			}
+		}
+	}
+}
+
+// parseRouteEvent 返回rtnetlink链路和地址事件的说明，与网络连接无关的事件返回空字符串
+func parseRouteEvent(msg syscall.NetlinkMessage) string {
+	switch msg.Header.Type {
+	case syscall.RTM_NEWLINK, syscall.RTM_DELLINK:
+		attrs, err := syscall.ParseNetlinkRouteAttr(&msg)
+		if err != nil {
+			return ""
+		}
+		name := ""
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case syscall.IFLA_WIRELESS:
+				// 无线扩展事件（如扫描完成）不代表连接变化，忽略以免扫描触发检查
+				return ""
+			case syscall.IFLA_IFNAME:
+				name = strings.TrimRight(string(attr.Value), "\x00")
+			}
+		}
+		if msg.Header.Type == syscall.RTM_DELLINK {
+			// 网卡已移除，无法再判断是否为物理网卡
+			return name + " 网卡移除"
+		}
+		if !physicalInterface(name) {
+			return ""
+		}
+		return name + " 链路变化"
+	case syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+		if len(msg.Data) < syscall.SizeofIfAddrmsg {
+			return ""
+		}
+		iface, err := net.InterfaceByIndex(int(binary.NativeEndian.Uint32(msg.Data[4:8])))
+		if err != nil || !physicalInterface(iface.Name) {
+			return ""
+		}
+		return iface.Name + " 地址变化"
+	}
+	return ""
+}
+
+// physicalInterface 判断接口是否为物理网卡（WiFi或有线），忽略回环、网桥、容器等虚拟接口
+func physicalInterface(name string) bool {
+	if name == "" {
+		return false
+	}
+	_, err := os.Stat(sysfsNetDir + "/" + name + "/device")
+	return err == nil
+}
+
+// throttleNetworkEvents 合并连续的事件后调用notify，两次调用之间至少间隔netlinkMinTriggerInterval
+func throttleNetworkEvents(events <-chan string, notify func()) {
+	var last time.Time
+	for reason := range events {
+		// 等待连接过程中的后续事件
+		timer := time.NewTimer(netlinkEventDelay)
+		if wait := netlinkMinTriggerInterval - time.Since(last); wait > netlinkEventDelay {
+			timer.Reset(wait)
+		}
+		reasons := []string{reason}
+	collect:
+		for {
+			select {
+			case next := <-events:
+				if len(reasons) < 5 && !containsString(reasons, next) {
+					reasons = append(reasons, next)
+				}
+			case <-timer.C:
+				break collect
+			}
+		}
+		last = time.Now()
+		log.Printf("检测到网络变化（%s），立即检查", strings.Join(reasons, "，"))
+		notify()
+	}
+}
+
+// appendNetlinkAttr 追加一个netlink属性（按4字节对齐）
+func appendNetlinkAttr(b []byte, attrType uint16, value []byte) []byte {
+	b = binary.NativeEndian.AppendUint16(b, uint16(syscall.SizeofRtAttr+len(value)))
+	b = binary.NativeEndian.AppendUint16(b, attrType)
+	b = append(b, value...)
+	for len(b)%syscall.NLMSG_ALIGNTO != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// parseNetlinkAttrs 解析netlink属性列表，嵌套属性的值可再次解析
+func parseNetlinkAttrs(b []byte) map[uint16][]byte {
+	attrs := make(map[uint16][]byte)
+	for len(b) >= syscall.SizeofRtAttr {
+		length := int(binary.NativeEndian.Uint16(b[0:2]))
+		attrType := binary.NativeEndian.Uint16(b[2:4]) & 0x3fff
+		if length < syscall.SizeofRtAttr || length > len(b) {
+			break
+		}
+		attrs[attrType] = b[syscall.SizeofRtAttr:length]
+		aligned := (length + syscall.NLMSG_ALIGNTO - 1) &^ (syscall.NLMSG_ALIGNTO - 1)
+		if aligned > len(b) {
+			break
+		}
+		b = b[aligned:]
+	}
+	return attrs
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "fmt"
+
+// watchNetworkEvents 当前平台不支持通过netlink监听网络变化，按检查间隔轮询
+func watchNetworkEvents(notify func()) error {
+	return fmt.Errorf("当前平台不支持监听网络变化事件")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// NetworkInfo 当前WiFi连接的详细网络信息
+type NetworkInfo struct {
+	Interface     string   `json:"interface"`
+	SSID          string   `json:"ssid"`
+	BSSID         string   `json:"bssid,omitempty"`
+	MACAddress    string   `json:"mac_address,omitempty"`
+	IPAddress     string   `json:"ip_address,omitempty"`
+	PrefixLength  int      `json:"prefix_length,omitempty"`
+	Gateway       string   `json:"gateway,omitempty"`
+	DNSServers    []string `json:"dns_servers,omitempty"`
+	IPv6Addresses []string `json:"ipv6_addresses,omitempty"`
+	Channel       int      `json:"channel,omitempty"`
+	FrequencyMHz  int      `json:"frequency_mhz,omitempty"`
+	SignalDBm     int      `json:"signal_dbm,omitempty"`
+	SignalPercent int      `json:"signal_percent,omitempty"`
+	// DefaultRoute 承载默认路由的接口，仅在启用有线网络检测时获取
+	DefaultRoute string `json:"default_route,omitempty"`
+}
+
+// SubnetMask 根据前缀长度返回IPv4子网掩码
+func (n *NetworkInfo) SubnetMask() string {
+	if n.PrefixLength <= 0 || n.PrefixLength > 32 {
+		return ""
+	}
+	return net.IP(net.CIDRMask(n.PrefixLength, 32)).String()
+}
+
+// Summary 返回用于通知展示的多行文本，只包含已获取到的字段
+func (n *NetworkInfo) Summary() string {
+	var lines []string
+	if n.IPAddress != "" && n.PrefixLength > 0 {
+		lines = append(lines, fmt.Sprintf("子网：%s/%d (%s)", n.IPAddress, n.PrefixLength, n.SubnetMask()))
+	}
+	if n.Gateway != "" {
+		lines = append(lines, "网关："+n.Gateway)
+	}
+	if len(n.DNSServers) > 0 {
+		lines = append(lines, "DNS："+strings.Join(n.DNSServers, ", "))
+	}
+	if n.MACAddress != "" {
+		lines = append(lines, "MAC地址："+n.MACAddress)
+	}
+	if n.BSSID != "" {
+		lines = append(lines, "BSSID："+n.BSSID)
+	}
+	if n.Channel > 0 {
+		if n.FrequencyMHz > 0 {
+			lines = append(lines, fmt.Sprintf("信道：%d (%d MHz)", n.Channel, n.FrequencyMHz))
+		} else {
+			lines = append(lines, fmt.Sprintf("信道：%d", n.Channel))
+		}
+	}
+	if n.SignalDBm != 0 {
+		lines = append(lines, fmt.Sprintf("信号强度：%d dBm (%d%%)", n.SignalDBm, n.SignalPercent))
+	}
+	if n.IPv6Addresses != nil {
+		lines = append(lines, "IPv6地址："+formatIPv6List(n.IPv6Addresses))
+	}
+	if n.DefaultRoute != "" {
+		lines = append(lines, "默认路由接口："+n.DefaultRoute)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetSignalDBm 设置以dBm表示的信号强度，并换算百分比
+func (n *NetworkInfo) SetSignalDBm(dbm int) {
+	n.SignalDBm = dbm
+	n.SignalPercent = signalDBmToPercent(dbm)
+}
+
+// SetSignalPercent 设置以百分比表示的信号强度，并换算dBm
+func (n *NetworkInfo) SetSignalPercent(percent int) {
+	n.SignalPercent = percent
+	n.SignalDBm = signalPercentToDBm(percent)
+}
+
+// signalDBmToPercent 将dBm信号强度换算为百分比（-100 dBm为0%，-50 dBm及以上为100%）
+func signalDBmToPercent(dbm int) int {
+	switch {
+	case dbm >= -50:
+		return 100
+	case dbm <= -100:
+		return 0
+	default:
+		return 2 * (dbm + 100)
+	}
+}
+
+// signalPercentToDBm 将百分比信号强度换算为dBm，与Windows的换算方式一致
+func signalPercentToDBm(percent int) int {
+	return percent/2 - 100
+}
+
+// frequencyToChannel 将WiFi频率（MHz）换算为信道号
+func frequencyToChannel(freq int) int {
+	switch {
+	case freq == 2484:
+		return 14
+	case freq >= 2412 && freq < 2484:
+		return (freq - 2407) / 5
+	case freq >= 5955 && freq <= 7115:
+		// 6GHz频段
+		return (freq - 5950) / 5
+	case freq >= 5000 && freq < 5955:
+		return (freq - 5000) / 5
+	default:
+		return 0
+	}
+}
+
+// channelToFrequency 将信道号换算为频率（MHz），6GHz信道无法仅凭信道号区分，按2.4/5GHz处理
+func channelToFrequency(channel int) int {
+	switch {
+	case channel == 14:
+		return 2484
+	case channel >= 1 && channel <= 13:
+		return 2407 + channel*5
+	case channel >= 32 && channel <= 177:
+		return 5000 + channel*5
+	default:
+		return 0
+	}
+}
+
+// parseKeyValueLines 解析 "键 : 值" 形式的多行输出，键去除首尾空白，重复的键保留第一个值
+func parseKeyValueLines(output, separator string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		index := strings.Index(line, separator)
+		if index == -1 {
+			continue
+		}
+		key := strings.TrimSpace(line[:index])
+		value := strings.TrimSpace(line[index+len(separator):])
+		if key == "" {
+			continue
+		}
+		if _, exists := values[key]; !exists {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// firstValue 按顺序返回第一个存在的键对应的值，用于兼容不同语言的系统输出
+func firstValue(values map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := values[key]; ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseLeadingInt 解析字符串开头的整数（如 "-52 dBm" 或 "96%"）
+func parseLeadingInt(value string) int {
+	value = strings.TrimSpace(value)
+	end := 0
+	for end < len(value) && (value[end] == '-' || (value[end] >= '0' && value[end] <= '9')) {
+		end++
+	}
+	number, _ := strconv.Atoi(value[:end])
+	return number
+}
@@ -0,0 +1,666 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// NetworkManager的D-Bus服务名称、接口和对象路径
+const (
+	nmService                   = "org.freedesktop.NetworkManager"
+	nmPath                      = "/org/freedesktop/NetworkManager"
+	nmSettingsPath              = "/org/freedesktop/NetworkManager/Settings"
+	nmInterface                 = "org.freedesktop.NetworkManager"
+	nmDeviceInterface           = "org.freedesktop.NetworkManager.Device"
+	nmWirelessInterface         = "org.freedesktop.NetworkManager.Device.Wireless"
+	nmAccessPointInterface      = "org.freedesktop.NetworkManager.AccessPoint"
+	nmActiveConnectionInterface = "org.freedesktop.NetworkManager.Connection.Active"
+	nmIP4ConfigInterface        = "org.freedesktop.NetworkManager.IP4Config"
+	nmSettingsInterface         = "org.freedesktop.NetworkManager.Settings"
+	nmConnectionInterface       = "org.freedesktop.NetworkManager.Settings.Connection"
+
+	// 接入点的WpaFlags/RsnFlags中的密钥管理方式
+	nmKeyMgmtPSK   = 0x100
+	nmKeyMgmt8021X = 0x200
+	nmKeyMgmtSAE   = 0x400
+	// nmAPFlagPrivacy 接入点要求加密（WEP）
+	nmAPFlagPrivacy = 0x1
+)
+
+// NMConnector 通过NetworkManager的D-Bus接口连接WiFi的Linux连接器，不依赖nmcli的文本输出格式。
+// 无法访问NetworkManager的D-Bus接口时使用nmcli
+type NMConnector struct {
+	*LinuxConnector
+}
+
+// NewNMConnector 创建使用NetworkManager D-Bus接口的Linux连接器
+func NewNMConnector(connector *LinuxConnector) *NMConnector {
+	return &NMConnector{LinuxConnector: connector}
+}
+
+// networkManagerDBusRunning 检查系统总线上是否有NetworkManager服务
+func networkManagerDBusRunning() bool {
+	bus, err := SystemBus()
+	if err != nil {
+		return false
+	}
+	return bus.NameHasOwner(nmService)
+}
+
+// device 返回网卡对应的NetworkManager设备对象路径
+func (c *NMConnector) device() (*DBusConn, DBusObjectPath, error) {
+	bus, err := SystemBus()
+	if err != nil {
+		return nil, "", err
+	}
+	reply, err := bus.Call(nmService, nmPath, nmInterface+".GetDeviceByIpIface", "s", c.interfaceName)
+	if err != nil {
+		return nil, "", fmt.Errorf("NetworkManager未管理网卡 %s: %v", c.interfaceName, err)
+	}
+	if len(reply) == 0 {
+		return nil, "", fmt.Errorf("NetworkManager未管理网卡 %s", c.interfaceName)
+	}
+	device, _ := reply[0].(DBusObjectPath)
+	return bus, device, nil
+}
+
+// nmObjectPath 将属性值转换为对象路径，"/" 表示不存在的对象，返回空字符串
+func nmObjectPath(value any) DBusObjectPath {
+	path, _ := value.(DBusObjectPath)
+	if path == "/" {
+		return ""
+	}
+	return path
+}
+
+// GetCurrentNetwork 实现WiFiConnector接口 - 读取设备当前关联的接入点的SSID
+func (c *NMConnector) GetCurrentNetwork() (string, error) {
+	bus, device, err := c.device()
+	if err != nil {
+		return c.LinuxConnector.GetCurrentNetwork()
+	}
+	value, err := bus.GetProperty(nmService, device, nmWirelessInterface, "ActiveAccessPoint")
+	if err != nil {
+		return c.LinuxConnector.GetCurrentNetwork()
+	}
+	ap := nmObjectPath(value)
+	if ap == "" {
+		return "", nil // 未连接任何WiFi
+	}
+	ssid, err := bus.GetProperty(nmService, ap, nmAccessPointInterface, "Ssid")
+	if err != nil {
+		return "", fmt.Errorf("获取当前WiFi失败: %v", err)
+	}
+	name, _ := ssid.([]byte)
+	return string(name), nil
+}
+
+// Connect 实现WiFiConnector接口 - 使用未绑定网卡或绑定本网卡的已保存连接配置：与要求的安全设置和密码一致
+// （或没有提供密码和认证配置）时直接启用，不一致时通过Update2就地更新安全设置后启用，保留IP、DNS和自动连接优先级等设置；
+// 没有可用的已保存配置时通过AddAndActivateConnection创建并启用新配置
+func (c *NMConnector) Connect(networkName, password string, options ConnectOptions) error {
+	bus, device, err := c.device()
+	if err != nil {
+		return c.LinuxConnector.Connect(networkName, password, options)
+	}
+	ap, _ := c.findAccessPoint(bus, device, networkName, "")
+	specific := DBusObjectPath("/")
+	if ap != "" {
+		specific = ap
+	}
+	saved, err := nmSavedConnections(bus, c.interfaceName, networkName)
+	if err != nil {
+		return err
+	}
+
+	reuse := len(saved) > 0
+	var settings map[string]map[string]DBusVariant
+	if password != "" || options.Security != SecurityAuto || options.Enterprise != nil {
+		if settings, err = nmConnectionSettings(c.interfaceName, networkName, password, options, ap != ""); err != nil {
+			return err
+		}
+		reuse = reuse && nmSettingsMatch(nmSavedSettingsWithSecrets(bus, saved[0], settings), settings)
+	}
+
+	switch {
+	case len(saved) == 0:
+		if settings == nil {
+			if settings, err = nmConnectionSettings(c.interfaceName, networkName, password, options, ap != ""); err != nil {
+				return err
+			}
+		}
+		_, err = bus.Call(nmService, nmPath, nmInterface+".AddAndActivateConnection", "a{sa{sv}}oo", settings, device, specific)
+	case !reuse:
+		log.Printf("网络 %s 的已保存连接配置与要求的安全设置或密码不同，更新已保存的配置", networkName)
+		if err := nmUpdateConnection(bus, saved[0].path, settings); err != nil {
+			return err
+		}
+		fallthrough
+	default:
+		_, err = bus.Call(nmService, nmPath, nmInterface+".ActivateConnection", "ooo", saved[0].path, device, specific)
+	}
+	if err != nil {
+		return fmt.Errorf("连接WiFi失败: %v", err)
+	}
+
+	// 等待连接完成并验证连接结果
+	for i := 0; i < 10; i++ { // 最多等待10秒
+		time.Sleep(1 * time.Second)
+		currentNetwork, err := c.GetCurrentNetwork()
+		if err != nil {
+			continue
+		}
+		if currentNetwork == networkName {
+			return nil // 连接成功
+		}
+	}
+	return fmt.Errorf("连接超时：无法连接到WiFi网络 '%s'，可能网络不存在或密码错误", networkName)
+}
+
+// nmSavedConnection 一个已保存的连接配置及其设置（不含密码）
+type nmSavedConnection struct {
+	path     DBusObjectPath
+	settings map[string]any
+}
+
+// nmSavedConnections 返回已保存的指定SSID的WiFi连接配置，绑定了其他网卡（connection.interface-name）的配置除外
+func nmSavedConnections(bus *DBusConn, interfaceName, networkName string) ([]nmSavedConnection, error) {
+	reply, err := bus.Call(nmService, nmSettingsPath, nmSettingsInterface+".ListConnections", "")
+	if err != nil {
+		return nil, fmt.Errorf("读取NetworkManager连接配置失败: %v", err)
+	}
+	if len(reply) == 0 {
+		return nil, nil
+	}
+	paths, _ := reply[0].([]any)
+	var connections []nmSavedConnection
+	for _, item := range paths {
+		path, _ := item.(DBusObjectPath)
+		result, err := bus.Call(nmService, path, nmConnectionInterface+".GetSettings", "")
+		if err != nil || len(result) == 0 {
+			continue
+		}
+		settings, _ := result[0].(map[string]any)
+		wireless, _ := settings["802-11-wireless"].(map[string]any)
+		connection, _ := settings["connection"].(map[string]any)
+		if name, _ := connection["interface-name"].(string); name != "" && name != interfaceName {
+			continue
+		}
+		if ssid, _ := wireless["ssid"].([]byte); string(ssid) == networkName {
+			connections = append(connections, nmSavedConnection{path: path, settings: settings})
+		}
+	}
+	return connections, nil
+}
+
+// nmUpdateSettingsToDisk Update2的标志，将更新后的配置保存到磁盘
+const nmUpdateSettingsToDisk = 0x1
+
+// nmUpdateConnection 用要求的安全设置就地更新已保存的连接配置。Update2会替换全部设置，
+// 因此先读取保留签名的已保存设置，只替换隐藏网络标志、安全设置和802.1X设置，其余设置原样发回
+func nmUpdateConnection(bus *DBusConn, path DBusObjectPath, settings map[string]map[string]DBusVariant) error {
+	reply, err := bus.CallVariants(nmService, path, nmConnectionInterface+".GetSettings", "")
+	if err != nil || len(reply) == 0 {
+		return fmt.Errorf("读取NetworkManager连接配置失败: %v", err)
+	}
+	saved, _ := reply[0].(map[string]any)
+	updated := make(map[string]map[string]any, len(saved))
+	for name, section := range saved {
+		values, _ := section.(map[string]any)
+		updated[name] = values
+	}
+
+	wireless := updated["802-11-wireless"]
+	if wireless == nil {
+		wireless = make(map[string]any)
+		updated["802-11-wireless"] = wireless
+	}
+	for key, value := range settings["802-11-wireless"] {
+		wireless[key] = value
+	}
+	if security, ok := settings["802-11-wireless-security"]; ok {
+		// 未提供的项（如由NetworkManager按接入点判断的key-mgmt）保留已保存的值；
+		// 密码改为由NetworkManager保存，不再沿用已保存配置中的密码标志
+		section := updated["802-11-wireless-security"]
+		if section == nil {
+			section = map[string]any{"key-mgmt": DBusVariant{Signature: "s", Value: "wpa-psk"}}
+			updated["802-11-wireless-security"] = section
+		}
+		for key, value := range security {
+			section[key] = value
+		}
+		if _, ok := security["psk"]; ok {
+			delete(section, "psk-flags")
+		}
+	} else {
+		delete(updated, "802-11-wireless-security")
+	}
+	if enterprise, ok := settings["802-1x"]; ok {
+		section := make(map[string]any, len(enterprise))
+		for key, value := range enterprise {
+			section[key] = value
+		}
+		updated["802-1x"] = section
+	} else {
+		delete(updated, "802-1x")
+	}
+
+	_, err = bus.Call(nmService, path, nmConnectionInterface+".Update2", "a{sa{sv}}ua{sv}",
+		updated, uint32(nmUpdateSettingsToDisk), map[string]DBusVariant{})
+	if err != nil {
+		return fmt.Errorf("更新NetworkManager连接配置失败: %v", err)
+	}
+	return nil
+}
+
+// nmSavedSettingsWithSecrets 返回已保存连接配置的设置，并通过GetSecrets补充要比较的安全设置中的密码；
+// 无法读取密码时（如密码由用户会话的密钥环保存）不补充，比较时视为不一致
+func nmSavedSettingsWithSecrets(bus *DBusConn, saved nmSavedConnection, settings map[string]map[string]DBusVariant) map[string]any {
+	merged := make(map[string]any, len(saved.settings))
+	for name, section := range saved.settings {
+		merged[name] = section
+	}
+	for _, name := range []string{"802-11-wireless-security", "802-1x"} {
+		if _, ok := settings[name]; !ok {
+			continue
+		}
+		reply, err := bus.Call(nmService, saved.path, nmConnectionInterface+".GetSecrets", "s", name)
+		if err != nil || len(reply) == 0 {
+			continue
+		}
+		secrets, _ := reply[0].(map[string]any)
+		values, _ := secrets[name].(map[string]any)
+		section := make(map[string]any)
+		if current, ok := merged[name].(map[string]any); ok {
+			for key, value := range current {
+				section[key] = value
+			}
+		}
+		for key, value := range values {
+			section[key] = value
+		}
+		merged[name] = section
+	}
+	return merged
+}
+
+// nmSettingsMatch 判断已保存的设置是否满足要创建的连接配置：隐藏网络标志、安全设置和802.1X设置中
+// 要设置的每一项都与已保存的值相同，且两者都有或都没有安全设置
+func nmSettingsMatch(saved map[string]any, settings map[string]map[string]DBusVariant) bool {
+	for _, name := range []string{"802-11-wireless", "802-11-wireless-security", "802-1x"} {
+		savedSection, savedOK := saved[name].(map[string]any)
+		section, ok := settings[name]
+		if name != "802-11-wireless" && savedOK != ok {
+			return false
+		}
+		for key, value := range section {
+			if name == "802-11-wireless" && key != "hidden" {
+				continue
+			}
+			if !reflect.DeepEqual(savedSection[key], nmDecodedValue(value)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// nmDecodedValue 将要发送的变体值转换为从D-Bus读回时的形式（如 as 读回为[]any），便于与已保存的设置比较
+func nmDecodedValue(variant DBusVariant) any {
+	e := &dbusEncoder{}
+	if err := e.encode(variant.Signature, variant.Value); err != nil {
+		return nil
+	}
+	d := &dbusDecoder{buf: e.buf, order: binary.LittleEndian}
+	value, err := d.decode(variant.Signature)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// nmConnectionSettings 生成AddAndActivateConnection的连接配置。
+// 未指定安全类型且接入点可见时只提供密码，由NetworkManager按接入点判断密钥管理方式
+func nmConnectionSettings(interfaceName, networkName, password string, options ConnectOptions, visible bool) (map[string]map[string]DBusVariant, error) {
+	wireless := map[string]DBusVariant{
+		"ssid": {Signature: "ay", Value: []byte(networkName)},
+		"mode": {Signature: "s", Value: "infrastructure"},
+	}
+	if options.Hidden {
+		// 隐藏网络不在扫描结果中，需要主动探测
+		wireless["hidden"] = DBusVariant{Signature: "b", Value: true}
+	}
+	settings := map[string]map[string]DBusVariant{
+		"connection": {
+			"id":             {Signature: "s", Value: networkName},
+			"type":           {Signature: "s", Value: "802-11-wireless"},
+			"interface-name": {Signature: "s", Value: interfaceName},
+		},
+		"802-11-wireless": wireless,
+	}
+	if options.Enterprise != nil {
+		settings["802-11-wireless-security"] = map[string]DBusVariant{"key-mgmt": {Signature: "s", Value: "wpa-eap"}}
+		settings["802-1x"] = nmEnterpriseSettings(password, options.Enterprise)
+		return settings, nil
+	}
+
+	if options.Security.RequiresPassword() && password == "" {
+		return nil, fmt.Errorf("安全类型 %s 需要WiFi密码", options.Security)
+	}
+	security := map[string]DBusVariant{}
+	switch options.Security {
+	case SecurityAuto:
+		if password == "" {
+			return settings, nil
+		}
+		if !visible {
+			security["key-mgmt"] = DBusVariant{Signature: "s", Value: "wpa-psk"}
+		}
+	case SecurityOpen:
+		return settings, nil
+	case SecurityWPA2PSK:
+		security["key-mgmt"] = DBusVariant{Signature: "s", Value: "wpa-psk"}
+	case SecurityWPA3SAE:
+		security["key-mgmt"] = DBusVariant{Signature: "s", Value: "sae"}
+	case SecurityWPA2WPA3:
+		// 过渡模式下按WPA2配置并启用可选的管理帧保护，NetworkManager会在网卡支持时使用SAE
+		security["key-mgmt"] = DBusVariant{Signature: "s", Value: "wpa-psk"}
+		security["pmf"] = DBusVariant{Signature: "i", Value: int32(2)}
+	default:
+		return nil, fmt.Errorf("不支持的安全类型: %s", options.Security)
+	}
+	security["psk"] = DBusVariant{Signature: "s", Value: password}
+	settings["802-11-wireless-security"] = security
+	return settings, nil
+}
+
+// nmEnterpriseSettings 生成企业级（802.1X）连接配置，证书和私钥使用 file:// 路径
+func nmEnterpriseSettings(password string, creds *EnterpriseCredentials) map[string]DBusVariant {
+	settings := map[string]DBusVariant{
+		"eap":      {Signature: "as", Value: []string{string(creds.EAPMethod)}},
+		"identity": {Signature: "s", Value: creds.Identity},
+	}
+	if creds.AnonymousIdentity != "" {
+		settings["anonymous-identity"] = DBusVariant{Signature: "s", Value: creds.AnonymousIdentity}
+	}
+	if creds.CACert != "" {
+		settings["ca-cert"] = nmFileSetting(creds.CACert)
+	}
+	if creds.ServerName != "" {
+		settings["domain-suffix-match"] = DBusVariant{Signature: "s", Value: creds.ServerName}
+	}
+
+	if creds.EAPMethod == EAPTLS {
+		settings["client-cert"] = nmFileSetting(creds.ClientCert)
+		if creds.PrivateKey != "" {
+			settings["private-key"] = nmFileSetting(creds.PrivateKey)
+		}
+		if creds.PrivateKeyPassword != "" {
+			settings["private-key-password"] = DBusVariant{Signature: "s", Value: creds.PrivateKeyPassword}
+		}
+		return settings
+	}
+
+	if creds.Password != "" {
+		password = creds.Password
+	}
+	settings["phase2-auth"] = DBusVariant{Signature: "s", Value: creds.InnerMethod()}
+	if password != "" {
+		settings["password"] = DBusVariant{Signature: "s", Value: password}
+	}
+	return settings
+}
+
+// nmFileSetting 生成证书类配置的值，格式为以NUL结尾的 file:// 路径
+func nmFileSetting(path string) DBusVariant {
+	return DBusVariant{Signature: "ay", Value: append([]byte("file://"+path), 0)}
+}
+
+// findAccessPoint 在设备扫描到的接入点中查找指定网络（bssid不为空时同时匹配BSSID），未找到时返回空路径
+func (c *NMConnector) findAccessPoint(bus *DBusConn, device DBusObjectPath, networkName, bssid string) (DBusObjectPath, error) {
+	aps, err := c.accessPoints(bus, device)
+	if err != nil {
+		return "", err
+	}
+	for _, ap := range aps {
+		ssid, _ := ap.properties["Ssid"].([]byte)
+		address, _ := ap.properties["HwAddress"].(string)
+		if string(ssid) == networkName && (bssid == "" || strings.EqualFold(address, bssid)) {
+			return ap.path, nil
+		}
+	}
+	return "", nil
+}
+
+// nmAccessPoint 一个接入点对象及其属性
+type nmAccessPoint struct {
+	path       DBusObjectPath
+	properties map[string]any
+}
+
+// accessPoints 返回设备扫描到的全部接入点
+func (c *NMConnector) accessPoints(bus *DBusConn, device DBusObjectPath) ([]nmAccessPoint, error) {
+	reply, err := bus.Call(nmService, device, nmWirelessInterface+".GetAllAccessPoints", "")
+	if err != nil {
+		return nil, fmt.Errorf("读取接入点失败: %v", err)
+	}
+	if len(reply) == 0 {
+		return nil, nil
+	}
+	paths, _ := reply[0].([]any)
+	var aps []nmAccessPoint
+	for _, item := range paths {
+		path, _ := item.(DBusObjectPath)
+		properties, err := bus.GetAllProperties(nmService, path, nmAccessPointInterface)
+		if err != nil {
+			// 接入点可能在读取期间消失
+			continue
+		}
+		aps = append(aps, nmAccessPoint{path: path, properties: properties})
+	}
+	return aps, nil
+}
+
+// Scan 实现WiFiConnector接口 - 请求扫描并等待扫描完成后读取全部接入点
+func (c *NMConnector) Scan() ([]ScanResult, error) {
+	bus, device, err := c.device()
+	if err != nil {
+		return c.LinuxConnector.Scan()
+	}
+	lastScan, _ := bus.GetProperty(nmService, device, nmWirelessInterface, "LastScan")
+	// 距上次扫描时间过短时NetworkManager会拒绝扫描，直接使用已有结果
+	if _, err := bus.Call(nmService, device, nmWirelessInterface+".RequestScan", "a{sv}", map[string]DBusVariant{}); err == nil {
+		for i := 0; i < 10; i++ { // 最多等待10秒
+			time.Sleep(1 * time.Second)
+			current, err := bus.GetProperty(nmService, device, nmWirelessInterface, "LastScan")
+			if err != nil || current != lastScan {
+				break
+			}
+		}
+	}
+
+	aps, err := c.accessPoints(bus, device)
+	if err != nil {
+		return nil, fmt.Errorf("扫描WiFi网络失败: %v", err)
+	}
+	var results []ScanResult
+	for _, ap := range aps {
+		ssid, _ := ap.properties["Ssid"].([]byte)
+		address, _ := ap.properties["HwAddress"].(string)
+		strength, _ := ap.properties["Strength"].(byte)
+		frequency, _ := ap.properties["Frequency"].(uint32)
+		flags, _ := ap.properties["Flags"].(uint32)
+		wpaFlags, _ := ap.properties["WpaFlags"].(uint32)
+		rsnFlags, _ := ap.properties["RsnFlags"].(uint32)
+		result := ScanResult{
+			SSID:     string(ssid),
+			BSSID:    strings.ToLower(address),
+			Security: nmSecurity(flags, wpaFlags, rsnFlags),
+		}
+		result.SetSignalPercent(int(strength))
+		result.SetFrequency(int(frequency))
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// nmSecurity 将接入点的标志转换为安全类型说明
+func nmSecurity(flags, wpaFlags, rsnFlags uint32) string {
+	switch {
+	case (wpaFlags|rsnFlags)&nmKeyMgmt8021X != 0:
+		return "802.1X"
+	case rsnFlags&nmKeyMgmtSAE != 0 && rsnFlags&nmKeyMgmtPSK != 0:
+		return "WPA2 WPA3"
+	case rsnFlags&nmKeyMgmtSAE != 0:
+		return "WPA3"
+	case rsnFlags&nmKeyMgmtPSK != 0:
+		return "WPA2"
+	case wpaFlags&nmKeyMgmtPSK != 0:
+		return "WPA1"
+	case flags&nmAPFlagPrivacy != 0:
+		return "WEP"
+	default:
+		return "Open"
+	}
+}
+
+// activeConnection 返回设备当前启用的连接配置对象路径
+func (c *NMConnector) activeConnection(bus *DBusConn, device DBusObjectPath) (DBusObjectPath, error) {
+	value, err := bus.GetProperty(nmService, device, nmDeviceInterface, "ActiveConnection")
+	if err != nil {
+		return "", fmt.Errorf("获取当前连接配置失败: %v", err)
+	}
+	active := nmObjectPath(value)
+	if active == "" {
+		return "", fmt.Errorf("WiFi接口 %s 没有活动的连接配置", c.interfaceName)
+	}
+	connection, err := bus.GetProperty(nmService, active, nmActiveConnectionInterface, "Connection")
+	if err != nil {
+		return "", fmt.Errorf("获取当前连接配置失败: %v", err)
+	}
+	return nmObjectPath(connection), nil
+}
+
+// ConnectBSSID 实现WiFiConnector接口 - 以目标接入点为specific_object重新启用当前连接配置
+func (c *NMConnector) ConnectBSSID(networkName, bssid, password string) error {
+	bus, device, err := c.device()
+	if err != nil {
+		return c.LinuxConnector.ConnectBSSID(networkName, bssid, password)
+	}
+	ap, err := c.findAccessPoint(bus, device, networkName, bssid)
+	if err != nil {
+		return err
+	}
+	if ap == "" {
+		return fmt.Errorf("连接接入点失败: 未找到接入点 %s", bssid)
+	}
+	connection, err := c.activeConnection(bus, device)
+	if err != nil {
+		return err
+	}
+	if _, err := bus.Call(nmService, nmPath, nmInterface+".ActivateConnection", "ooo", connection, device, ap); err != nil {
+		return fmt.Errorf("连接接入点失败: %v", err)
+	}
+
+	// 等待连接完成并验证已关联到目标接入点
+	for i := 0; i < 10; i++ { // 最多等待10秒
+		time.Sleep(1 * time.Second)
+		info, err := c.GetNetworkInfo()
+		if err != nil {
+			continue
+		}
+		if info.SSID == networkName && strings.EqualFold(info.BSSID, bssid) {
+			return nil
+		}
+	}
+	return fmt.Errorf("连接超时：无法连接到接入点 %s", bssid)
+}
+
+// GetNetworkInfo 实现WiFiConnector接口 - 在ip和iw获取的信息基础上，
+// 使用设备的IP4Config和当前接入点补充地址、网关、DNS和接入点信息
+func (c *NMConnector) GetNetworkInfo() (*NetworkInfo, error) {
+	ssid, err := c.GetCurrentNetwork()
+	if err != nil {
+		return nil, err
+	}
+	info := c.networkInfo(ssid)
+	bus, device, err := c.device()
+	if err != nil {
+		return info, nil
+	}
+
+	if value, err := bus.GetProperty(nmService, device, nmDeviceInterface, "Ip4Config"); err == nil {
+		if config := nmObjectPath(value); config != "" {
+			if properties, err := bus.GetAllProperties(nmService, config, nmIP4ConfigInterface); err == nil {
+				nmApplyIP4Config(info, properties)
+			}
+		}
+	}
+
+	// iw不可用时使用接入点的属性
+	if value, err := bus.GetProperty(nmService, device, nmWirelessInterface, "ActiveAccessPoint"); err == nil && info.BSSID == "" {
+		if ap := nmObjectPath(value); ap != "" {
+			if properties, err := bus.GetAllProperties(nmService, ap, nmAccessPointInterface); err == nil {
+				address, _ := properties["HwAddress"].(string)
+				frequency, _ := properties["Frequency"].(uint32)
+				strength, _ := properties["Strength"].(byte)
+				info.BSSID = strings.ToLower(address)
+				info.FrequencyMHz = int(frequency)
+				info.Channel = frequencyToChannel(info.FrequencyMHz)
+				if info.SignalDBm == 0 {
+					info.SignalPercent = int(strength)
+					info.SignalDBm = signalPercentToDBm(info.SignalPercent)
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// nmApplyIP4Config 使用IP4Config的AddressData、Gateway和NameserverData更新网络信息
+func nmApplyIP4Config(info *NetworkInfo, properties map[string]any) {
+	if addresses, _ := properties["AddressData"].([]any); len(addresses) > 0 {
+		address, _ := addresses[0].(map[string]any)
+		if ip, _ := address["address"].(string); ip != "" {
+			info.IPAddress = ip
+			if prefix, ok := address["prefix"].(uint32); ok {
+				info.PrefixLength = int(prefix)
+			}
+		}
+	}
+	if gateway, _ := properties["Gateway"].(string); gateway != "" {
+		info.Gateway = gateway
+	}
+	if nameservers, _ := properties["NameserverData"].([]any); len(nameservers) > 0 {
+		var servers []string
+		for _, item := range nameservers {
+			nameserver, _ := item.(map[string]any)
+			if address, _ := nameserver["address"].(string); address != "" {
+				servers = append(servers, address)
+			}
+		}
+		if len(servers) > 0 {
+			info.DNSServers = servers
+		}
+	}
+}
+
+// RenewDHCP 实现WiFiConnector接口 - 重新启用当前连接配置以重新申请DHCP租约
+func (c *NMConnector) RenewDHCP() error {
+	bus, device, err := c.device()
+	if err != nil {
+		return c.LinuxConnector.RenewDHCP()
+	}
+	connection, err := c.activeConnection(bus, device)
+	if err != nil {
+		return err
+	}
+	if _, err := bus.Call(nmService, nmPath, nmInterface+".ActivateConnection", "ooo", connection, device, DBusObjectPath("/")); err != nil {
+		return fmt.Errorf("重新启用连接失败: %v", err)
+	}
+	return nil
+}
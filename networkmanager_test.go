@@ -0,0 +1,467 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+)
+
+const fakeNMDevice DBusObjectPath = "/org/freedesktop/NetworkManager/Devices/3"
+
+// fakeNM 模拟NetworkManager服务，导出wlan0设备、扫描到的接入点和已保存的连接配置，
+// 连接配置按从D-Bus读回的形式保存（含密码）
+type fakeNM struct {
+	bus         *DBusConn
+	mutex       sync.Mutex
+	calls       []string
+	ssids       []string
+	connections map[DBusObjectPath]map[string]any
+	nextID      int
+	// active 当前连接的接入点
+	active DBusObjectPath
+}
+
+// startFakeNM 启动模拟的NetworkManager服务，ssids为扫描到的接入点，connections为已保存的连接配置
+func startFakeNM(t *testing.T, ssids []string, connections ...map[string]any) *fakeNM {
+	t.Helper()
+	f := &fakeNM{bus: newTestSystemBus(t, nmService), ssids: ssids, connections: make(map[DBusObjectPath]map[string]any)}
+	f.bus.Export(nmPath, f.handler(nmPath))
+	f.bus.Export(nmSettingsPath, f.handler(nmSettingsPath))
+	f.bus.Export(fakeNMDevice, f.handler(fakeNMDevice))
+	for i := range ssids {
+		path := f.apPath(i)
+		f.bus.Export(path, f.handler(path))
+	}
+	for _, settings := range connections {
+		f.addConnection(settings)
+	}
+	return f
+}
+
+func (f *fakeNM) apPath(i int) DBusObjectPath {
+	return DBusObjectPath(fmt.Sprintf("/org/freedesktop/NetworkManager/AccessPoint/%d", i+1))
+}
+
+func (f *fakeNM) connectionPath(id int) DBusObjectPath {
+	return DBusObjectPath(fmt.Sprintf("%s/%d", nmSettingsPath, id))
+}
+
+// addConnection 保存连接配置并导出其对象，调用时不能持有锁
+func (f *fakeNM) addConnection(settings map[string]any) DBusObjectPath {
+	f.mutex.Lock()
+	f.nextID++
+	path := f.connectionPath(f.nextID)
+	f.connections[path] = settings
+	f.mutex.Unlock()
+	f.bus.Export(path, f.handler(path))
+	return path
+}
+
+func (f *fakeNM) handler(path DBusObjectPath) DBusHandler {
+	return func(sender, member string, args []any) (string, []any, error) {
+		return f.handle(path, member, args)
+	}
+}
+
+// takeCalls 返回并清空收到的修改配置和启用连接的调用
+func (f *fakeNM) takeCalls() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+func (f *fakeNM) handle(path DBusObjectPath, member string, args []any) (string, []any, error) {
+	if member == "AddAndActivateConnection" {
+		settings, _ := args[0].(map[string]any)
+		connection := f.addConnection(settings)
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		f.calls = append(f.calls, "AddAndActivateConnection")
+		f.activate(connection)
+		return "oo", []any{connection, DBusObjectPath("/org/freedesktop/NetworkManager/ActiveConnection/1")}, nil
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	switch member {
+	case "GetDeviceByIpIface":
+		if args[0] == "wlan0" {
+			return "o", []any{fakeNMDevice}, nil
+		}
+		return "", nil, &DBusError{Name: "org.freedesktop.NetworkManager.UnknownDevice"}
+	case "GetAllAccessPoints":
+		var aps []DBusObjectPath
+		for i := range f.ssids {
+			aps = append(aps, f.apPath(i))
+		}
+		return "ao", []any{aps}, nil
+	case "Get", "GetAll":
+		properties := map[string]DBusVariant{"ActiveAccessPoint": {Signature: "o", Value: DBusObjectPath("/")}}
+		if f.active != "" {
+			properties["ActiveAccessPoint"] = DBusVariant{Signature: "o", Value: f.active}
+		}
+		for i, ssid := range f.ssids {
+			if f.apPath(i) == path {
+				properties = map[string]DBusVariant{
+					"Ssid":      {Signature: "ay", Value: []byte(ssid)},
+					"HwAddress": {Signature: "s", Value: fmt.Sprintf("AA:BB:CC:DD:EE:%02X", i+1)},
+				}
+			}
+		}
+		if member == "GetAll" {
+			return "a{sv}", []any{properties}, nil
+		}
+		return "v", []any{properties[args[1].(string)]}, nil
+	case "ListConnections":
+		var paths []DBusObjectPath
+		for id := 1; id <= f.nextID; id++ {
+			if _, ok := f.connections[f.connectionPath(id)]; ok {
+				paths = append(paths, f.connectionPath(id))
+			}
+		}
+		return "ao", []any{paths}, nil
+	case "GetSettings":
+		// 读取配置时不返回密码
+		settings := make(map[string]any)
+		for name, section := range f.connections[path] {
+			values := make(map[string]any)
+			for key, value := range section.(map[string]any) {
+				if key != "psk" && key != "password" {
+					values[key] = value
+				}
+			}
+			settings[name] = values
+		}
+		return "a{sa{sv}}", []any{fakeNMVariants(settings)}, nil
+	case "GetSecrets":
+		name := args[0].(string)
+		secrets := make(map[string]any)
+		if section, ok := f.connections[path][name].(map[string]any); ok {
+			for _, key := range []string{"psk", "password"} {
+				if value, ok := section[key]; ok {
+					secrets[key] = value
+				}
+			}
+		}
+		return "a{sa{sv}}", []any{fakeNMVariants(map[string]any{name: secrets})}, nil
+	case "Update2":
+		f.calls = append(f.calls, "Update2 "+string(path))
+		f.connections[path], _ = args[0].(map[string]any)
+		return "a{sv}", []any{map[string]DBusVariant{}}, nil
+	case "Delete":
+		f.calls = append(f.calls, "Delete "+string(path))
+		delete(f.connections, path)
+		return "", nil, nil
+	case "ActivateConnection":
+		connection, _ := args[0].(DBusObjectPath)
+		f.calls = append(f.calls, "ActivateConnection "+string(connection))
+		f.activate(connection)
+		return "o", []any{DBusObjectPath("/org/freedesktop/NetworkManager/ActiveConnection/1")}, nil
+	}
+	return "", nil, &DBusError{Name: "org.freedesktop.DBus.Error.UnknownMethod", Message: member}
+}
+
+// activate 连接到配置中SSID对应的接入点
+func (f *fakeNM) activate(connection DBusObjectPath) {
+	wireless, _ := f.connections[connection]["802-11-wireless"].(map[string]any)
+	ssid, _ := wireless["ssid"].([]byte)
+	for i, name := range f.ssids {
+		if name == string(ssid) {
+			f.active = f.apPath(i)
+		}
+	}
+}
+
+// fakeNMVariants 按值的类型推断签名，将从D-Bus读回形式的a{sa{sv}}设置转换为要发送的变体
+func fakeNMVariants(settings map[string]any) map[string]map[string]DBusVariant {
+	result := make(map[string]map[string]DBusVariant)
+	for name, section := range settings {
+		result[name] = fakeNMVariant(section).Value.(map[string]DBusVariant)
+	}
+	return result
+}
+
+func fakeNMVariant(value any) DBusVariant {
+	switch v := value.(type) {
+	case string:
+		return DBusVariant{Signature: "s", Value: v}
+	case bool:
+		return DBusVariant{Signature: "b", Value: v}
+	case int32:
+		return DBusVariant{Signature: "i", Value: v}
+	case uint32:
+		return DBusVariant{Signature: "u", Value: v}
+	case []byte:
+		return DBusVariant{Signature: "ay", Value: v}
+	case map[string]any:
+		values := make(map[string]DBusVariant)
+		for key, item := range v {
+			values[key] = fakeNMVariant(item)
+		}
+		return DBusVariant{Signature: "a{sv}", Value: values}
+	case []any:
+		// 测试数据中的数组都不为空，按第一个元素推断元素类型
+		elem := fakeNMVariant(v[0]).Signature
+		var items []any
+		for _, item := range v {
+			items = append(items, fakeNMVariant(item).Value)
+		}
+		return DBusVariant{Signature: "a" + elem, Value: items}
+	}
+	panic(fmt.Sprintf("不支持的设置值类型: %T", value))
+}
+
+// fakeNMConnection 生成从D-Bus读回形式的WiFi连接配置
+func fakeNMConnection(ssid, interfaceName string, security map[string]any) map[string]any {
+	settings := map[string]any{
+		"connection": map[string]any{
+			"id": ssid, "uuid": "uuid-" + ssid + "-" + interfaceName, "type": "802-11-wireless",
+			"interface-name": interfaceName, "autoconnect-priority": int32(10),
+		},
+		"802-11-wireless": map[string]any{"ssid": []byte(ssid), "mode": "infrastructure"},
+		"ipv4": map[string]any{
+			"method":       "manual",
+			"address-data": []any{map[string]any{"address": "192.168.1.20", "prefix": uint32(24)}},
+			"dns":          []any{uint32(0x0101a8c0)},
+		},
+	}
+	if security != nil {
+		settings["802-11-wireless-security"] = security
+	}
+	return settings
+}
+
+func TestNMConnectionSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		options  ConnectOptions
+		visible  bool
+		// security 期望的802-11-wireless-security设置，nil表示没有该设置
+		security map[string]DBusVariant
+		wantErr  bool
+	}{
+		{"开放网络", "", ConnectOptions{}, true, nil, false},
+		{"接入点可见时由NetworkManager判断密钥管理方式", "secret", ConnectOptions{}, true,
+			map[string]DBusVariant{"psk": {Signature: "s", Value: "secret"}}, false},
+		{"接入点不可见时按WPA2配置", "secret", ConnectOptions{}, false,
+			map[string]DBusVariant{"key-mgmt": {Signature: "s", Value: "wpa-psk"}, "psk": {Signature: "s", Value: "secret"}}, false},
+		{"WPA3", "secret", ConnectOptions{Security: SecurityWPA3SAE}, true,
+			map[string]DBusVariant{"key-mgmt": {Signature: "s", Value: "sae"}, "psk": {Signature: "s", Value: "secret"}}, false},
+		{"过渡模式启用可选的管理帧保护", "secret", ConnectOptions{Security: SecurityWPA2WPA3}, true,
+			map[string]DBusVariant{"key-mgmt": {Signature: "s", Value: "wpa-psk"}, "psk": {Signature: "s", Value: "secret"},
+				"pmf": {Signature: "i", Value: int32(2)}}, false},
+		{"指定为开放网络时忽略密码", "secret", ConnectOptions{Security: SecurityOpen}, true, nil, false},
+		{"缺少密码", "", ConnectOptions{Security: SecurityWPA2PSK}, true, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := nmConnectionSettings("wlan0", "Office", tt.password, tt.options, tt.visible)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误 = %v，期望出错: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			connection := settings["connection"]
+			if connection["id"].Value != "Office" || connection["type"].Value != "802-11-wireless" || connection["interface-name"].Value != "wlan0" {
+				t.Fatalf("connection设置 = %v", connection)
+			}
+			if ssid, _ := settings["802-11-wireless"]["ssid"].Value.([]byte); string(ssid) != "Office" {
+				t.Fatalf("ssid = %q，期望 Office", ssid)
+			}
+			security, ok := settings["802-11-wireless-security"]
+			if ok != (tt.security != nil) || (ok && !reflect.DeepEqual(security, tt.security)) {
+				t.Fatalf("安全设置 = %v\n期望 %v", security, tt.security)
+			}
+		})
+	}
+}
+
+func TestNMConnectionSettingsHiddenEnterprise(t *testing.T) {
+	options := ConnectOptions{
+		Hidden: true,
+		Enterprise: &EnterpriseCredentials{EAPMethod: EAPPEAP, Identity: "alice", AnonymousIdentity: "anonymous",
+			CACert: "/etc/ca.pem", ServerName: "radius.example.com"},
+	}
+	settings, err := nmConnectionSettings("wlan0", "Corp", "wifi-secret", options, false)
+	if err != nil {
+		t.Fatalf("生成连接配置失败: %v", err)
+	}
+	if hidden := settings["802-11-wireless"]["hidden"]; hidden.Value != true {
+		t.Fatalf("hidden = %v，期望 true", hidden)
+	}
+	if keyMgmt := settings["802-11-wireless-security"]["key-mgmt"]; keyMgmt.Value != "wpa-eap" {
+		t.Fatalf("key-mgmt = %v，期望 wpa-eap", keyMgmt)
+	}
+	want := map[string]DBusVariant{
+		"eap":                 {Signature: "as", Value: []string{"peap"}},
+		"identity":            {Signature: "s", Value: "alice"},
+		"anonymous-identity":  {Signature: "s", Value: "anonymous"},
+		"ca-cert":             {Signature: "ay", Value: append([]byte("file:///etc/ca.pem"), 0)},
+		"domain-suffix-match": {Signature: "s", Value: "radius.example.com"},
+		"phase2-auth":         {Signature: "s", Value: "mschapv2"},
+		"password":            {Signature: "s", Value: "wifi-secret"},
+	}
+	if got := settings["802-1x"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("802-1x设置 = %v\n期望 %v", got, want)
+	}
+}
+
+func TestNMSettingsMatch(t *testing.T) {
+	settings, err := nmConnectionSettings("wlan0", "Office", "secret", ConnectOptions{Security: SecurityWPA2WPA3}, true)
+	if err != nil {
+		t.Fatalf("生成连接配置失败: %v", err)
+	}
+	saved := func(security map[string]any) map[string]any {
+		result := map[string]any{
+			"connection":      map[string]any{"id": "Office", "autoconnect-priority": int32(10)},
+			"802-11-wireless": map[string]any{"ssid": []byte("Office"), "mode": "infrastructure"},
+			"ipv4":            map[string]any{"method": "manual"},
+		}
+		if security != nil {
+			result["802-11-wireless-security"] = security
+		}
+		return result
+	}
+	tests := []struct {
+		name  string
+		saved map[string]any
+		want  bool
+	}{
+		{"安全设置和密码相同", saved(map[string]any{"key-mgmt": "wpa-psk", "pmf": int32(2), "psk": "secret"}), true},
+		{"密码不同", saved(map[string]any{"key-mgmt": "wpa-psk", "pmf": int32(2), "psk": "old"}), false},
+		{"无法读取密码", saved(map[string]any{"key-mgmt": "wpa-psk", "pmf": int32(2)}), false},
+		{"密钥管理方式不同", saved(map[string]any{"key-mgmt": "sae", "pmf": int32(2), "psk": "secret"}), false},
+		{"已保存的是开放网络", saved(nil), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nmSettingsMatch(tt.saved, settings); got != tt.want {
+				t.Fatalf("nmSettingsMatch() = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	open, _ := nmConnectionSettings("wlan0", "Office", "", ConnectOptions{Security: SecurityOpen}, true)
+	if !nmSettingsMatch(saved(nil), open) {
+		t.Fatalf("开放网络的已保存配置应当匹配")
+	}
+	if nmSettingsMatch(saved(map[string]any{"key-mgmt": "wpa-psk"}), open) {
+		t.Fatalf("已保存的加密网络不应匹配开放网络")
+	}
+
+	hidden, _ := nmConnectionSettings("wlan0", "Office", "", ConnectOptions{Security: SecurityOpen, Hidden: true}, false)
+	if nmSettingsMatch(saved(nil), hidden) {
+		t.Fatalf("未设置隐藏标志的已保存配置不应匹配隐藏网络")
+	}
+
+	enterprise, _ := nmConnectionSettings("wlan0", "Office", "wifi-secret",
+		ConnectOptions{Enterprise: &EnterpriseCredentials{EAPMethod: EAPTTLS, Identity: "bob", Phase2: "pap"}}, true)
+	savedEnterprise := saved(map[string]any{"key-mgmt": "wpa-eap"})
+	savedEnterprise["802-1x"] = map[string]any{"eap": []any{"ttls"}, "identity": "bob", "phase2-auth": "pap", "password": "wifi-secret"}
+	if !nmSettingsMatch(savedEnterprise, enterprise) {
+		t.Fatalf("企业级网络的已保存配置应当匹配")
+	}
+}
+
+func TestNMConnect(t *testing.T) {
+	fake := startFakeNM(t, []string{"Office", "Cafe"},
+		fakeNMConnection("Office", "wlan1", map[string]any{"key-mgmt": "wpa-psk", "psk": "other"}),
+		fakeNMConnection("Office", "", map[string]any{"key-mgmt": "wpa-psk", "psk": "secret", "psk-flags": uint32(0)}),
+	)
+	other, office := fake.connectionPath(1), fake.connectionPath(2)
+	connector := NewNMConnector(&LinuxConnector{interfaceName: "wlan0"})
+
+	steps := []struct {
+		name     string
+		network  string
+		password string
+		options  ConnectOptions
+		want     []string
+	}{
+		{"密码相同时直接启用未绑定网卡的已保存配置", "Office", "secret", ConnectOptions{}, []string{"ActivateConnection " + string(office)}},
+		{"未提供密码时直接启用", "Office", "", ConnectOptions{}, []string{"ActivateConnection " + string(office)}},
+		{"密码不同时就地更新后启用", "Office", "changed", ConnectOptions{}, []string{"Update2 " + string(office), "ActivateConnection " + string(office)}},
+		{"更新后的配置与要求一致", "Office", "changed", ConnectOptions{}, []string{"ActivateConnection " + string(office)}},
+		{"没有已保存的配置时创建", "Cafe", "", ConnectOptions{}, []string{"AddAndActivateConnection"}},
+	}
+	for _, step := range steps {
+		if err := connector.Connect(step.network, step.password, step.options); err != nil {
+			t.Fatalf("%s: 连接失败: %v", step.name, err)
+		}
+		if got := fake.takeCalls(); !slices.Equal(got, step.want) {
+			t.Fatalf("%s: 调用 = %q\n期望 %q", step.name, got, step.want)
+		}
+	}
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	// 更新时只替换安全设置，保留IP、DNS和自动连接优先级
+	want := fakeNMConnection("Office", "", map[string]any{"key-mgmt": "wpa-psk", "psk": "changed"})
+	if got := fake.connections[office]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("更新后的配置 = %v\n期望 %v", got, want)
+	}
+	if psk := fake.connections[other]["802-11-wireless-security"].(map[string]any)["psk"]; psk != "other" {
+		t.Fatalf("绑定其他网卡的配置被修改: psk = %v", psk)
+	}
+	if len(fake.connections) != 3 {
+		t.Fatalf("连接配置数量 = %d，期望 3", len(fake.connections))
+	}
+	created := fake.connections[fake.connectionPath(3)]
+	if connection, _ := created["connection"].(map[string]any); connection["interface-name"] != "wlan0" || created["802-11-wireless-security"] != nil {
+		t.Fatalf("新建的配置 = %v", created)
+	}
+}
+
+func TestNMSecurity(t *testing.T) {
+	tests := []struct {
+		name                      string
+		flags, wpaFlags, rsnFlags uint32
+		want                      string
+	}{
+		{"开放网络", 0, 0, 0, "Open"},
+		{"WEP", nmAPFlagPrivacy, 0, 0, "WEP"},
+		{"WPA1", nmAPFlagPrivacy, nmKeyMgmtPSK, 0, "WPA1"},
+		{"WPA2", nmAPFlagPrivacy, 0, nmKeyMgmtPSK, "WPA2"},
+		{"WPA3", nmAPFlagPrivacy, 0, nmKeyMgmtSAE, "WPA3"},
+		{"WPA2/WPA3过渡模式", nmAPFlagPrivacy, 0, nmKeyMgmtPSK | nmKeyMgmtSAE, "WPA2 WPA3"},
+		{"企业级", nmAPFlagPrivacy, 0, nmKeyMgmt8021X, "802.1X"},
+		{"WPA1企业级", nmAPFlagPrivacy, nmKeyMgmt8021X, 0, "802.1X"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nmSecurity(tt.flags, tt.wpaFlags, tt.rsnFlags); got != tt.want {
+				t.Fatalf("nmSecurity() = %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNMApplyIP4Config(t *testing.T) {
+	info := &NetworkInfo{IPAddress: "10.0.0.2", Gateway: "10.0.0.1", DNSServers: []string{"10.0.0.1"}}
+	nmApplyIP4Config(info, map[string]any{
+		"AddressData": []any{
+			map[string]any{"address": "192.168.1.20", "prefix": uint32(24)},
+			map[string]any{"address": "192.168.1.21", "prefix": uint32(24)},
+		},
+		"Gateway":        "192.168.1.1",
+		"NameserverData": []any{map[string]any{"address": "192.168.1.1"}, map[string]any{"address": "8.8.8.8"}},
+	})
+	if info.IPAddress != "192.168.1.20" || info.PrefixLength != 24 || info.Gateway != "192.168.1.1" ||
+		!slices.Equal(info.DNSServers, []string{"192.168.1.1", "8.8.8.8"}) {
+		t.Fatalf("网络信息 = %+v", info)
+	}
+
+	// 属性为空时保留原有信息
+	info = &NetworkInfo{IPAddress: "10.0.0.2", PrefixLength: 8, Gateway: "10.0.0.1", DNSServers: []string{"10.0.0.1"}}
+	nmApplyIP4Config(info, map[string]any{"AddressData": []any{}, "Gateway": "", "NameserverData": []any{}})
+	if info.IPAddress != "10.0.0.2" || info.PrefixLength != 8 || info.Gateway != "10.0.0.1" ||
+		!slices.Equal(info.DNSServers, []string{"10.0.0.1"}) {
+		t.Fatalf("网络信息 = %+v", info)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// NetworkProfile 单个受管WiFi网络的配置
+type NetworkProfile struct {
+	// SSID WiFi网络名称
+	SSID string `json:"ssid"`
+	// Password WiFi密码，支持凭据引用（如 ${env:OFFICE_WIFI_PASSWORD}）
+	Password string `json:"password,omitempty"`
+	// Hidden 网络不广播SSID
+	Hidden bool `json:"hidden,omitempty"`
+	// Security 安全类型: auto、open、wpa2-psk、wpa3-sae、wpa2-wpa3，为空时由扫描结果判断
+	Security string `json:"security,omitempty"`
+	// Enterprise 企业级（802.1X）认证凭据
+	Enterprise *EnterpriseCredentials `json:"enterprise,omitempty"`
+	// IP IP地址和DNS配置，为空时保持系统默认（DHCP）
+	IP *IPConfig `json:"ip,omitempty"`
+	// Portal 认证页面自动登录配置
+	Portal *PortalRecipe `json:"portal,omitempty"`
+}
+
+// NetworkConfig 网络配置文件结构
+type NetworkConfig struct {
+	Networks []NetworkProfile `json:"networks"`
+}
+
+// loadNetworkProfiles 从JSON文件加载网络配置
+func loadNetworkProfiles(path string) ([]NetworkProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取网络配置文件失败: %v", err)
+	}
+
+	var config NetworkConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析网络配置文件失败: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range config.Networks {
+		profile := &config.Networks[i]
+		if profile.SSID == "" {
+			return nil, fmt.Errorf("网络配置第%d项缺少ssid", i+1)
+		}
+		if seen[profile.SSID] {
+			return nil, fmt.Errorf("网络配置中ssid重复: %s", profile.SSID)
+		}
+		seen[profile.SSID] = true
+		if _, err := ParseSecurityType(profile.Security); err != nil {
+			return nil, fmt.Errorf("网络 %s 的配置错误: %v", profile.SSID, err)
+		}
+		if profile.Enterprise != nil {
+			if err := profile.Enterprise.Validate(); err != nil {
+				return nil, fmt.Errorf("网络 %s 的企业级认证配置错误: %v", profile.SSID, err)
+			}
+		}
+		if profile.IP != nil {
+			if err := profile.IP.Validate(); err != nil {
+				return nil, fmt.Errorf("网络 %s 的IP配置错误: %v", profile.SSID, err)
+			}
+		}
+	}
+	return config.Networks, nil
+}
+
+// findNetworkProfile 查找指定网络的配置，未配置时返回nil
+func findNetworkProfile(ssid string) *NetworkProfile {
+	for i := range networkProfiles {
+		if networkProfiles[i].SSID == ssid {
+			return &networkProfiles[i]
+		}
+	}
+	return nil
+}
+
+// targetConnectOptions 返回连接指定目标网络时使用的选项，合并命令行参数和网络配置。
+// 命令行的 -hidden 和 -security 只作用于 -w 指定的网络
+func targetConnectOptions(ssid string) ConnectOptions {
+	options := ConnectOptions{Security: SecurityAuto}
+	if ssid == targetWiFi {
+		options.Hidden, options.Security = targetHidden, targetSecurity
+	}
+	if profile := findNetworkProfile(ssid); profile != nil {
+		options.Hidden = options.Hidden || profile.Hidden
+		if options.Security == SecurityAuto {
+			// 配置已在加载时校验
+			options.Security, _ = ParseSecurityType(profile.Security)
+		}
+		options.Enterprise = profile.Enterprise
+	}
+	if options.Enterprise != nil {
+		options.Security = SecurityEnterprise
+	}
+	return options
+}
+
+// targetIPConfig 返回指定目标网络的IP配置，未配置时返回nil
+func targetIPConfig(ssid string) *IPConfig {
+	if profile := findNetworkProfile(ssid); profile != nil {
+		return profile.IP
+	}
+	return nil
+}
+
+// networkPassword 返回连接指定网络使用的密码，命令行的 -p 只作用于 -w 指定的网络，
+// 其他网络使用网络配置中的密码（已在启动时解析凭据引用）
+func networkPassword(ssid string) string {
+	if ssid == targetWiFi && wifiPassword != "" {
+		return wifiPassword
+	}
+	if profile := findNetworkProfile(ssid); profile != nil {
+		return profile.Password
+	}
+	return ""
+}
+
+// resolveNetworkCredentials 解析目标网络配置中的密码和企业级认证凭据引用
+func resolveNetworkCredentials(targets []string) error {
+	for _, ssid := range targets {
+		profile := findNetworkProfile(ssid)
+		if profile == nil {
+			continue
+		}
+		// 命令行指定了密码时不使用配置文件中的密码
+		if profile.Password != "" && (ssid != targetWiFi || wifiPassword == "") {
+			password, err := resolveCredential(profile.Password)
+			if err != nil {
+				return fmt.Errorf("解析网络 %s 的密码失败: %v", ssid, err)
+			}
+			profile.Password = password
+		}
+		if profile.Enterprise != nil {
+			credentials, err := profile.Enterprise.Resolve()
+			if err != nil {
+				return fmt.Errorf("解析网络 %s 的企业级认证凭据失败: %v", ssid, err)
+			}
+			profile.Enterprise = credentials
+		}
+	}
+	return nil
+}
+
+// credentialRefPattern 凭据引用格式: ${env:变量名} 或 ${file:文件路径}
+var credentialRefPattern = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+
+// resolveCredential 展开配置值中的凭据引用，避免在配置文件中明文保存密码
+func resolveCredential(value string) (string, error) {
+	var resolveErr error
+	resolved := credentialRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		match := credentialRefPattern.FindStringSubmatch(ref)
+		switch match[1] {
+		case "env":
+			envValue, ok := os.LookupEnv(match[2])
+			if !ok && resolveErr == nil {
+				resolveErr = fmt.Errorf("环境变量未设置: %s", match[2])
+			}
+			return envValue
+		case "file":
+			data, err := os.ReadFile(match[2])
+			if err != nil && resolveErr == nil {
+				resolveErr = fmt.Errorf("读取凭据文件失败: %v", err)
+			}
+			return strings.TrimSpace(string(data))
+		}
+		return ref
+	})
+	return resolved, resolveErr
+}
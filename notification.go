@@ -11,15 +11,21 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
 
 // IPChangeDetector IP地址变化检测器
 type IPChangeDetector struct {
-	previousIP string
-	currentIP  string
-	mutex      sync.RWMutex
+	previousIP   string
+	currentIP    string
+	previousIPv6 []string
+	currentIPv6  []string
+	ipv6Checked  bool
+	previousInfo *NetworkInfo
+	currentInfo  *NetworkInfo
+	mutex        sync.RWMutex
 }
 
 // NewIPChangeDetector 创建新的IP变化检测器
@@ -63,6 +69,78 @@ func (d *IPChangeDetector) GetCurrentIP() string {
 	return d.currentIP
 }
 
+// CheckIPv6Change 检测IPv6地址列表是否发生变化，地址列表需已排序
+func (d *IPChangeDetector) CheckIPv6Change(newAddrs []string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	// 第一次设置
+	if !d.ipv6Checked {
+		d.currentIPv6 = newAddrs
+		d.ipv6Checked = true
+		return true
+	}
+
+	// IPv6地址列表发生变化
+	if strings.Join(d.currentIPv6, ",") != strings.Join(newAddrs, ",") {
+		d.previousIPv6 = d.currentIPv6
+		d.currentIPv6 = newAddrs
+		return true
+	}
+
+	// IPv6地址未变化
+	return false
+}
+
+// GetPreviousIPv6 获取之前的IPv6地址列表
+func (d *IPChangeDetector) GetPreviousIPv6() []string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.previousIPv6
+}
+
+// GetCurrentIPv6 获取当前的IPv6地址列表
+func (d *IPChangeDetector) GetCurrentIPv6() []string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.currentIPv6
+}
+
+// UpdateNetworkInfo 更新当前的详细网络信息，返回网关或BSSID是否发生变化
+func (d *IPChangeDetector) UpdateNetworkInfo(info *NetworkInfo) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.previousInfo = d.currentInfo
+	d.currentInfo = info
+	if d.previousInfo == nil || info == nil {
+		return false
+	}
+	return d.previousInfo.Gateway != info.Gateway || d.previousInfo.BSSID != info.BSSID
+}
+
+// GetPreviousNetworkInfo 获取之前的详细网络信息
+func (d *IPChangeDetector) GetPreviousNetworkInfo() *NetworkInfo {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.previousInfo
+}
+
+// GetCurrentNetworkInfo 获取当前的详细网络信息
+func (d *IPChangeDetector) GetCurrentNetworkInfo() *NetworkInfo {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.currentInfo
+}
+
+// formatIPv6List 格式化IPv6地址列表用于通知展示
+func formatIPv6List(addrs []string) string {
+	if len(addrs) == 0 {
+		return "无"
+	}
+	return strings.Join(addrs, ", ")
+}
+
 // FeishuMessage 飞书消息结构
 type FeishuMessage struct {
 	MsgType   string         `json:"msg_type"`
@@ -76,11 +154,23 @@ type MessageContent struct {
 	Text string `json:"text"`
 }
 
+// NotifierHealth 通知器健康状况
+type NotifierHealth struct {
+	Name         string    `json:"name"`
+	SuccessCount int       `json:"success_count"`
+	FailureCount int       `json:"failure_count"`
+	LastSuccess  time.Time `json:"last_success,omitzero"`
+	LastFailure  time.Time `json:"last_failure,omitzero"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
 // FeishuNotifier 飞书通知器
 type FeishuNotifier struct {
-	webhookURL string
-	secret     string
-	httpClient *http.Client
+	webhookURL  string
+	secret      string
+	httpClient  *http.Client
+	health      NotifierHealth
+	healthMutex sync.RWMutex
 }
 
 // NewFeishuNotifier 创建新的飞书通知器
@@ -99,6 +189,7 @@ func NewFeishuNotifier(webhookURL, secret string) *FeishuNotifier {
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		health: NotifierHealth{Name: "feishu"},
 	}
 }
 
@@ -119,9 +210,7 @@ func (f *FeishuNotifier) generateSignature(timestamp int64) string {
 }
 
 // buildMessage 构建飞书消息
-func (f *FeishuNotifier) buildMessage(oldIP, newIP, networkName string) *FeishuMessage {
-	timestamp := time.Now().Unix()
-
+func (f *FeishuNotifier) buildMessage(oldIP, newIP, networkName string, info *NetworkInfo) *FeishuMessage {
 	var messageText string
 	if oldIP == "" {
 		// 首次获取IP地址或WiFi重新连接
@@ -132,28 +221,41 @@ func (f *FeishuNotifier) buildMessage(oldIP, newIP, networkName string) *FeishuM
 		messageText = fmt.Sprintf("🌐 WiFi连接状态更新\n网络：%s\n🔄 IP地址变化：%s → %s\n时间：%s",
 			networkName, oldIP, newIP, time.Now().Format("2006-01-02 15:04:05"))
 	}
+	return f.buildTextMessage(appendNetworkInfo(messageText, info))
+}
 
-	return &FeishuMessage{
-		MsgType: "text",
-		Content: MessageContent{
-			Text: messageText,
-		},
-		Timestamp: timestamp,
-		Sign:      f.generateSignature(timestamp),
+// appendNetworkInfo 在消息末尾附加详细网络信息
+func appendNetworkInfo(messageText string, info *NetworkInfo) string {
+	if info == nil {
+		return messageText
 	}
+	if summary := info.Summary(); summary != "" {
+		messageText += "\n" + summary
+	}
+	return messageText
 }
 
 // buildWiFiReconnectMessage 构建WiFi重新连接消息
-func (f *FeishuNotifier) buildWiFiReconnectMessage(ip, networkName string) *FeishuMessage {
-	timestamp := time.Now().Unix()
-
+func (f *FeishuNotifier) buildWiFiReconnectMessage(ip, networkName string, info *NetworkInfo) *FeishuMessage {
 	messageText := fmt.Sprintf("🌐 WiFi重新连接通知\n网络：%s\n✅ 已重新连接，IP地址：%s\n时间：%s",
 		networkName, ip, time.Now().Format("2006-01-02 15:04:05"))
+	return f.buildTextMessage(appendNetworkInfo(messageText, info))
+}
+
+// buildIPv6ChangeMessage 构建IPv6地址变化消息
+func (f *FeishuNotifier) buildIPv6ChangeMessage(oldAddrs, newAddrs []string, ip, networkName string) *FeishuMessage {
+	messageText := fmt.Sprintf("🌐 WiFi连接状态更新\n网络：%s\nIP地址：%s\n🔄 IPv6地址变化：%s → %s\n时间：%s",
+		networkName, ip, formatIPv6List(oldAddrs), formatIPv6List(newAddrs), time.Now().Format("2006-01-02 15:04:05"))
+	return f.buildTextMessage(messageText)
+}
 
+// buildTextMessage 构建带签名的文本消息
+func (f *FeishuNotifier) buildTextMessage(text string) *FeishuMessage {
+	timestamp := time.Now().Unix()
 	return &FeishuMessage{
 		MsgType: "text",
 		Content: MessageContent{
-			Text: messageText,
+			Text: text,
 		},
 		Timestamp: timestamp,
 		Sign:      f.generateSignature(timestamp),
@@ -169,14 +271,19 @@ type FeishuResponse struct {
 	StatusMessage string                 `json:"StatusMessage"`
 }
 
-// SendIPChangeNotification 发送IP变化通知
-func (f *FeishuNotifier) SendIPChangeNotification(oldIP, newIP, networkName string) error {
+// sendMessage 发送消息到飞书并记录通知器健康状况
+func (f *FeishuNotifier) sendMessage(message *FeishuMessage) error {
+	err := f.postMessage(message)
+	f.recordResult(err)
+	return err
+}
+
+// postMessage 发送消息到飞书webhook并检查响应
+func (f *FeishuNotifier) postMessage(message *FeishuMessage) error {
 	if f.webhookURL == "" || f.secret == "" {
 		return fmt.Errorf("飞书通知配置不完整")
 	}
 
-	message := f.buildMessage(oldIP, newIP, networkName)
-
 	// 序列化消息
 	messageData, err := json.Marshal(message)
 	if err != nil {
@@ -197,6 +304,11 @@ func (f *FeishuNotifier) SendIPChangeNotification(oldIP, newIP, networkName stri
 		return fmt.Errorf("读取响应内容失败: %v", err)
 	}
 
+	// 检查响应状态
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP请求失败，状态码: %d, 响应内容: %s", resp.StatusCode, string(respBody))
+	}
+
 	// 解析响应内容
 	var feishuResp FeishuResponse
 	if err := json.Unmarshal(respBody, &feishuResp); err != nil {
@@ -204,109 +316,191 @@ func (f *FeishuNotifier) SendIPChangeNotification(oldIP, newIP, networkName stri
 		return fmt.Errorf("解析飞书响应失败: %v", err)
 	}
 
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP请求失败，状态码: %d", resp.StatusCode)
-	}
-
 	// 检查飞书响应码
 	if feishuResp.Code != 0 {
 		return fmt.Errorf("飞书通知发送失败，错误码: %d, 错误信息: %s", feishuResp.Code, feishuResp.Msg)
 	}
-
-	log.Printf("飞书通知发送成功: %s", networkName)
 	return nil
 }
 
-// SendIPChangeNotificationAsync 异步发送IP变化通知
-func (f *FeishuNotifier) SendIPChangeNotificationAsync(oldIP, newIP, networkName string) {
+// recordResult 记录一次发送结果
+func (f *FeishuNotifier) recordResult(err error) {
+	f.healthMutex.Lock()
+	defer f.healthMutex.Unlock()
+	if err == nil {
+		f.health.SuccessCount++
+		f.health.LastSuccess = time.Now()
+		return
+	}
+	f.health.FailureCount++
+	f.health.LastFailure = time.Now()
+	f.health.LastError = err.Error()
+}
+
+// Health 获取通知器健康状况
+func (f *FeishuNotifier) Health() NotifierHealth {
+	f.healthMutex.RLock()
+	defer f.healthMutex.RUnlock()
+	return f.health
+}
+
+// sendAsync 异步发送消息，失败时重试
+func (f *FeishuNotifier) sendAsync(description string, build func() *FeishuMessage) {
 	go func() {
 		// 实现重试机制
 		maxRetries := 3
 		for i := 0; i < maxRetries; i++ {
-			err := f.SendIPChangeNotification(oldIP, newIP, networkName)
+			// 每次重试重新构建消息，避免签名时间戳过期
+			err := f.sendMessage(build())
 			if err == nil {
+				log.Printf("飞书%s发送成功", description)
 				return
 			}
 
-			log.Printf("飞书通知发送失败 (第%d次重试): %v", i+1, err)
+			log.Printf("飞书%s发送失败 (第%d次重试): %v", description, i+1, err)
 			if i < maxRetries-1 {
 				// 指数退避重试
 				time.Sleep(time.Duration(i+1) * time.Second)
 			}
 		}
-		log.Printf("飞书通知发送最终失败，已重试%d次", maxRetries)
+		log.Printf("飞书%s发送最终失败，已重试%d次", description, maxRetries)
 	}()
 }
 
-// SendWiFiReconnectNotification 发送WiFi重新连接通知
-func (f *FeishuNotifier) SendWiFiReconnectNotification(ip, networkName string) error {
-	if f.webhookURL == "" || f.secret == "" {
-		return fmt.Errorf("飞书通知配置不完整")
+// SendIPChangeNotification 发送IP变化通知，info为nil时不展示详细网络信息
+func (f *FeishuNotifier) SendIPChangeNotification(oldIP, newIP, networkName string, info *NetworkInfo) error {
+	if err := f.sendMessage(f.buildMessage(oldIP, newIP, networkName, info)); err != nil {
+		return err
 	}
+	log.Printf("飞书通知发送成功: %s", networkName)
+	return nil
+}
 
-	message := f.buildWiFiReconnectMessage(ip, networkName)
+// SendIPChangeNotificationAsync 异步发送IP变化通知
+func (f *FeishuNotifier) SendIPChangeNotificationAsync(oldIP, newIP, networkName string, info *NetworkInfo) {
+	f.sendAsync("IP变化通知", func() *FeishuMessage {
+		return f.buildMessage(oldIP, newIP, networkName, info)
+	})
+}
 
-	// 序列化消息
-	messageData, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("序列化消息失败: %v", err)
+// SendWiFiReconnectNotification 发送WiFi重新连接通知，info为nil时不展示详细网络信息
+func (f *FeishuNotifier) SendWiFiReconnectNotification(ip, networkName string, info *NetworkInfo) error {
+	if err := f.sendMessage(f.buildWiFiReconnectMessage(ip, networkName, info)); err != nil {
+		return err
 	}
+	log.Printf("飞书WiFi重新连接通知发送成功: %s", networkName)
+	return nil
+}
 
-	// 发送HTTP请求
-	resp, err := f.httpClient.Post(f.webhookURL, "application/json", bytes.NewBuffer(messageData))
-	if err != nil {
-		return fmt.Errorf("发送请求失败: %v", err)
-	}
-	defer resp.Body.Close()
+// SendWiFiReconnectNotificationAsync 异步发送WiFi重新连接通知
+func (f *FeishuNotifier) SendWiFiReconnectNotificationAsync(ip, networkName string, info *NetworkInfo) {
+	f.sendAsync("WiFi重新连接通知", func() *FeishuMessage {
+		return f.buildWiFiReconnectMessage(ip, networkName, info)
+	})
+}
 
-	// 读取响应内容
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("读取响应内容失败: %v", err)
-		return fmt.Errorf("读取响应内容失败: %v", err)
-	}
+// SendIPv6ChangeNotificationAsync 异步发送IPv6地址变化通知
+func (f *FeishuNotifier) SendIPv6ChangeNotificationAsync(oldAddrs, newAddrs []string, ip, networkName string) {
+	f.sendAsync("IPv6变化通知", func() *FeishuMessage {
+		return f.buildIPv6ChangeMessage(oldAddrs, newAddrs, ip, networkName)
+	})
+}
 
-	log.Printf("飞书WiFi重新连接通知响应状态: %d, 响应内容: %s", resp.StatusCode, string(respBody))
+// SendWANIPChangeNotificationAsync 异步发送公网IP变化通知
+func (f *FeishuNotifier) SendWANIPChangeNotificationAsync(oldIP, newIP string) {
+	f.sendAsync("公网IP变化通知", func() *FeishuMessage {
+		hostname, _ := os.Hostname()
+		var messageText string
+		if oldIP == "" {
+			messageText = fmt.Sprintf("🌍 公网IP通知\n主机：%s\n✅ 当前公网IP：%s\n时间：%s",
+				hostname, newIP, time.Now().Format("2006-01-02 15:04:05"))
+		} else {
+			messageText = fmt.Sprintf("🌍 公网IP变化\n主机：%s\n🔄 公网IP变化：%s → %s\n时间：%s",
+				hostname, oldIP, newIP, time.Now().Format("2006-01-02 15:04:05"))
+		}
+		return f.buildTextMessage(messageText)
+	})
+}
 
-	// 解析响应内容
-	var feishuResp FeishuResponse
-	if err := json.Unmarshal(respBody, &feishuResp); err != nil {
-		log.Printf("解析飞书响应失败: %v", err)
-		return fmt.Errorf("解析飞书响应失败: %v", err)
-	}
+// SendConnectivityLostNotificationAsync 异步发送互联网连通性丢失通知
+func (f *FeishuNotifier) SendConnectivityLostNotificationAsync(networkName, ip, failures string) {
+	f.sendAsync("连通性丢失通知", func() *FeishuMessage {
+		messageText := fmt.Sprintf("⚠️ 互联网连接中断\n网络：%s\nIP地址：%s\nWiFi已连接，但互联网不可用\n失败的检测项：\n%s\n时间：%s",
+			networkName, ip, failures, time.Now().Format("2006-01-02 15:04:05"))
+		return f.buildTextMessage(messageText)
+	})
+}
 
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP请求失败，状态码: %d", resp.StatusCode)
-	}
+// SendConnectivityRestoredNotificationAsync 异步发送互联网连通性恢复通知
+func (f *FeishuNotifier) SendConnectivityRestoredNotificationAsync(networkName, ip string, downtime time.Duration) {
+	f.sendAsync("连通性恢复通知", func() *FeishuMessage {
+		messageText := fmt.Sprintf("✅ 互联网连接已恢复\n网络：%s\nIP地址：%s\n中断时长：%s\n时间：%s",
+			networkName, ip, downtime.Round(time.Second), time.Now().Format("2006-01-02 15:04:05"))
+		return f.buildTextMessage(messageText)
+	})
+}
 
-	// 检查飞书响应码
-	if feishuResp.Code != 0 && feishuResp.StatusCode != 0 {
-		return fmt.Errorf("飞书通知发送失败，错误码: %d, 错误信息: %s", feishuResp.Code, feishuResp.Msg)
-	}
+// SendLinkQualityDegradedNotificationAsync 异步发送链路质量下降通知
+func (f *FeishuNotifier) SendLinkQualityDegradedNotificationAsync(networkName, reason string, stats []LinkTargetStats) {
+	f.sendAsync("链路质量下降通知", func() *FeishuMessage {
+		messageText := fmt.Sprintf("📉 链路质量下降\n网络：%s\n原因：%s\n当前统计：\n%s\n时间：%s",
+			networkName, reason, formatLinkStats(stats), time.Now().Format("2006-01-02 15:04:05"))
+		return f.buildTextMessage(messageText)
+	})
+}
 
-	log.Printf("飞书WiFi重新连接通知发送成功: %s", networkName)
-	return nil
+// SendLinkQualityRestoredNotificationAsync 异步发送链路质量恢复通知
+func (f *FeishuNotifier) SendLinkQualityRestoredNotificationAsync(networkName string, duration time.Duration, stats []LinkTargetStats) {
+	f.sendAsync("链路质量恢复通知", func() *FeishuMessage {
+		messageText := fmt.Sprintf("📈 链路质量已恢复\n网络：%s\n持续时长：%s\n当前统计：\n%s\n时间：%s",
+			networkName, duration.Round(time.Second), formatLinkStats(stats), time.Now().Format("2006-01-02 15:04:05"))
+		return f.buildTextMessage(messageText)
+	})
 }
 
-// SendWiFiReconnectNotificationAsync 异步发送WiFi重新连接通知
-func (f *FeishuNotifier) SendWiFiReconnectNotificationAsync(ip, networkName string) {
-	go func() {
-		// 实现重试机制
-		maxRetries := 3
-		for i := 0; i < maxRetries; i++ {
-			err := f.SendWiFiReconnectNotification(ip, networkName)
-			if err == nil {
-				return
-			}
+// SendAdapterLostNotificationAsync 异步发送WiFi网卡丢失通知
+func (f *FeishuNotifier) SendAdapterLostNotificationAsync(interfaceName string) {
+	f.sendAsync("WiFi网卡丢失通知", func() *FeishuMessage {
+		hostname, _ := os.Hostname()
+		messageText := fmt.Sprintf("🔌 WiFi网卡已移除\n主机：%s\n接口：%s\n将在网卡重新插入后恢复连接\n时间：%s",
+			hostname, interfaceName, time.Now().Format("2006-01-02 15:04:05"))
+		return f.buildTextMessage(messageText)
+	})
+}
 
-			log.Printf("飞书WiFi重新连接通知发送失败 (第%d次重试): %v", i+1, err)
-			if i < maxRetries-1 {
-				// 指数退避重试
-				time.Sleep(time.Duration(i+1) * time.Second)
-			}
+// SendAdapterRestoredNotificationAsync 异步发送WiFi网卡恢复通知
+func (f *FeishuNotifier) SendAdapterRestoredNotificationAsync(interfaceName string, downtime time.Duration) {
+	f.sendAsync("WiFi网卡恢复通知", func() *FeishuMessage {
+		hostname, _ := os.Hostname()
+		messageText := fmt.Sprintf("✅ WiFi网卡已恢复\n主机：%s\n接口：%s\n离线时长：%s\n时间：%s",
+			hostname, interfaceName, downtime.Round(time.Second), time.Now().Format("2006-01-02 15:04:05"))
+		return f.buildTextMessage(messageText)
+	})
+}
+
+// SendWiredChangeNotificationAsync 异步发送有线网络连接变化通知，network为有线网络断开后恢复使用的WiFi网络
+func (f *FeishuNotifier) SendWiredChangeNotificationAsync(wired *WiredInterface, previous, defaultRoute, network string, policy WiredPolicy) {
+	f.sendAsync("有线网络变化通知", func() *FeishuMessage {
+		hostname, _ := os.Hostname()
+		if defaultRoute == "" {
+			defaultRoute = "无"
 		}
-		log.Printf("飞书WiFi重新连接通知发送最终失败，已重试%d次", maxRetries)
-	}()
+		var messageText string
+		if wired != nil {
+			messageText = fmt.Sprintf("🔌 有线网络已连接\n主机：%s\n接口：%s\nIP地址：%s\n默认路由接口：%s\nWiFi策略：%s\n时间：%s",
+				hostname, wired.Name, wired.IPAddress, defaultRoute, policy, time.Now().Format("2006-01-02 15:04:05"))
+		} else {
+			messageText = fmt.Sprintf("🔌 有线网络已断开\n主机：%s\n接口：%s\n默认路由接口：%s\n将恢复使用WiFi：%s\n时间：%s",
+				hostname, previous, defaultRoute, network, time.Now().Format("2006-01-02 15:04:05"))
+		}
+		return f.buildTextMessage(messageText)
+	})
+}
+
+// SendTestNotification 发送测试通知，用于确认通知配置是否可用
+func (f *FeishuNotifier) SendTestNotification(networkName, ip string) error {
+	hostname, _ := os.Hostname()
+	messageText := fmt.Sprintf("🔔 测试通知\n主机：%s\n网络：%s\nIP地址：%s\n时间：%s",
+		hostname, networkName, ip, time.Now().Format("2006-01-02 15:04:05"))
+	return f.sendMessage(f.buildTextMessage(messageText))
 }
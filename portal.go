@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PortalRecipe 认证页面（Captive Portal）自动登录配置
+type PortalRecipe struct {
+	// LoginURL 登录表单提交地址，为空时使用检测到的认证页面地址
+	LoginURL string `json:"login_url,omitempty"`
+	// Method 提交方式，POST（默认，表单编码）或 GET（查询参数）
+	Method string `json:"method,omitempty"`
+	// Fields 表单字段，值支持凭据引用（如 ${env:HOTEL_ROOM}、${file:/etc/connect/pin}）
+	Fields map[string]string `json:"fields,omitempty"`
+	// Cookies 提交前预先设置的Cookie
+	Cookies map[string]string `json:"cookies,omitempty"`
+	// Headers 附加的请求头
+	Headers map[string]string `json:"headers,omitempty"`
+	// VisitPortal 提交前是否先访问认证页面以获取会话Cookie
+	VisitPortal bool `json:"visit_portal,omitempty"`
+	// FollowRedirects 是否跟随登录响应的重定向
+	FollowRedirects bool `json:"follow_redirects,omitempty"`
+	// MaxRedirects 跟随重定向的最大次数，默认10
+	MaxRedirects int `json:"max_redirects,omitempty"`
+	// SuccessContains 登录响应中应包含的文本，为空时只检查状态码
+	SuccessContains string `json:"success_contains,omitempty"`
+}
+
+// portalLogin 按配置提交认证页面登录表单
+func portalLogin(recipe *PortalRecipe, portalURL string, timeout time.Duration) error {
+	loginURL := recipe.LoginURL
+	if loginURL == "" {
+		loginURL = portalURL
+	}
+	if loginURL == "" {
+		return fmt.Errorf("未配置登录地址且未检测到认证页面地址")
+	}
+	// 登录地址可以是相对于认证页面的路径
+	if portalURL != "" {
+		if base, err := url.Parse(portalURL); err == nil {
+			if ref, err := url.Parse(loginURL); err == nil {
+				loginURL = base.ResolveReference(ref).String()
+			}
+		}
+	}
+	target, err := url.Parse(loginURL)
+	if err != nil {
+		return fmt.Errorf("登录地址无效: %v", err)
+	}
+
+	jar, _ := cookiejar.New(nil)
+	var cookies []*http.Cookie
+	for name, value := range recipe.Cookies {
+		value, err := resolveCredential(value)
+		if err != nil {
+			return err
+		}
+		cookies = append(cookies, &http.Cookie{Name: name, Value: value})
+	}
+	jar.SetCookies(target, cookies)
+
+	maxRedirects := recipe.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = 10
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		Jar:     jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !recipe.FollowRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("重定向次数超过%d次", maxRedirects)
+			}
+			return nil
+		},
+	}
+
+	// 先访问认证页面，获取会话Cookie
+	if recipe.VisitPortal && portalURL != "" {
+		resp, err := client.Get(portalURL)
+		if err != nil {
+			return fmt.Errorf("访问认证页面失败: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	form := url.Values{}
+	for name, value := range recipe.Fields {
+		value, err := resolveCredential(value)
+		if err != nil {
+			return err
+		}
+		form.Set(name, value)
+	}
+
+	var req *http.Request
+	method := strings.ToUpper(recipe.Method)
+	switch method {
+	case "", http.MethodPost:
+		req, err = http.NewRequest(http.MethodPost, loginURL, strings.NewReader(form.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	case http.MethodGet:
+		query := target.Query()
+		for name, values := range form {
+			query[name] = values
+		}
+		target.RawQuery = query.Encode()
+		req, err = http.NewRequest(http.MethodGet, target.String(), nil)
+	default:
+		return fmt.Errorf("不支持的提交方式: %s", recipe.Method)
+	}
+	if err != nil {
+		return fmt.Errorf("创建登录请求失败: %v", err)
+	}
+	for name, value := range recipe.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("提交登录表单失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return fmt.Errorf("读取登录响应失败: %v", err)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("登录失败，状态码: %d", resp.StatusCode)
+	}
+	if recipe.SuccessContains != "" && !strings.Contains(string(body), recipe.SuccessContains) {
+		return fmt.Errorf("登录响应中未包含预期内容: %s", recipe.SuccessContains)
+	}
+	return nil
+}
+
+// handleCaptivePortal 检测到认证页面时按网络配置自动登录，返回登录后的连通性检测结果
+func (m *Monitor) handleCaptivePortal(interfaceName, gateway string, report *ConnectivityReport) *ConnectivityReport {
+	log.Printf("检测到认证页面: %s", report.PortalURL)
+
+	profile := findNetworkProfile(m.target)
+	if profile == nil || profile.Portal == nil {
+		log.Printf("网络 %s 未配置认证页面登录方式，无法自动登录", m.target)
+		return report
+	}
+
+	// 登录失败后按指数退避，避免认证页面持续存在时每次检查都提交登录导致账号被锁定
+	key := portalKey(report.PortalURL)
+	if m.portalAttempts == nil {
+		m.portalAttempts = make(map[string]*portalAttempt)
+	}
+	attempt := m.portalAttempts[key]
+	if attempt != nil && time.Now().Before(attempt.next) {
+		log.Printf("认证页面自动登录已连续失败%d次，%s 后再尝试", attempt.failures, time.Until(attempt.next).Round(time.Second))
+		return report
+	}
+
+	startTime := time.Now()
+	err := portalLogin(profile.Portal, report.PortalURL, connectivityChecker.timeout)
+	event := HistoryEvent{
+		Type:       HistoryEventPortal,
+		SSID:       m.target,
+		Interface:  interfaceName,
+		To:         report.PortalURL,
+		Success:    err == nil,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	m.record(event)
+
+	if err != nil {
+		log.Printf("认证页面自动登录失败: %v", err)
+		m.portalAttempts[key] = attempt.fail()
+		return report
+	}
+
+	log.Printf("认证页面自动登录已提交，重新检测连通性")
+	retry := connectivityChecker.Check(gateway)
+	if retry.CaptivePortal {
+		log.Printf("自动登录后仍被认证页面拦截")
+		m.portalAttempts[key] = attempt.fail()
+	} else {
+		delete(m.portalAttempts, key)
+	}
+	return retry
+}
+
+const (
+	// portalRetryMin 认证页面自动登录首次失败后的等待时间
+	portalRetryMin = 30 * time.Second
+	// portalRetryMax 认证页面自动登录连续失败后的最长等待时间
+	portalRetryMax = 30 * time.Minute
+)
+
+// portalAttempt 认证页面自动登录的连续失败次数和下次允许尝试的时间
+type portalAttempt struct {
+	failures int
+	next     time.Time
+}
+
+// fail 记录一次登录失败，等待时间从portalRetryMin开始每次翻倍，最长portalRetryMax
+func (a *portalAttempt) fail() *portalAttempt {
+	failures := 1
+	if a != nil {
+		failures = a.failures + 1
+	}
+	delay := portalRetryMax
+	if failures <= 16 {
+		delay = min(portalRetryMin<<(failures-1), portalRetryMax)
+	}
+	return &portalAttempt{failures: failures, next: time.Now().Add(delay)}
+}
+
+// portalKey 返回区分认证页面的键，忽略查询参数（其中常包含时间戳或会话参数）
+func portalKey(portalURL string) string {
+	u, err := url.Parse(portalURL)
+	if err != nil {
+		return portalURL
+	}
+	return u.Host + u.Path
+}
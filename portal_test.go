@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFakePortal 启动模拟的认证页面：访问 /portal 下发会话Cookie，
+// 向 /login 提交带会话Cookie、预设Cookie和正确房间号的表单后返回登录成功页面
+func newFakePortal(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/portal", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s-123", Path: "/"})
+		fmt.Fprint(w, "<form action=\"/login\"></form>")
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+			http.Error(w, "content type", http.StatusBadRequest)
+			return
+		}
+		if session, err := r.Cookie("session"); err != nil || session.Value != "s-123" {
+			http.Error(w, "no session", http.StatusForbidden)
+			return
+		}
+		if lang, err := r.Cookie("lang"); err != nil || lang.Value != "zh" {
+			http.Error(w, "no preset cookie", http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-Portal") != "connect" {
+			http.Error(w, "no header", http.StatusBadRequest)
+			return
+		}
+		if r.PostFormValue("room") != "1208" || r.PostFormValue("agree") != "1" {
+			fmt.Fprint(w, "房间号错误")
+			return
+		}
+		http.Redirect(w, r, "/welcome", http.StatusFound)
+	})
+	mux.HandleFunc("/welcome", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<h1>登录成功</h1>")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPortalLogin(t *testing.T) {
+	server := newFakePortal(t)
+	t.Setenv("CONNECT_TEST_ROOM", "1208")
+
+	recipe := func() *PortalRecipe {
+		return &PortalRecipe{
+			LoginURL:        "/login",
+			Fields:          map[string]string{"room": "${env:CONNECT_TEST_ROOM}", "agree": "1"},
+			Cookies:         map[string]string{"lang": "zh"},
+			Headers:         map[string]string{"X-Portal": "connect"},
+			VisitPortal:     true,
+			FollowRedirects: true,
+			SuccessContains: "登录成功",
+		}
+	}
+	portalURL := server.URL + "/portal?mac=aa:bb"
+
+	tests := []struct {
+		name    string
+		modify  func(*PortalRecipe)
+		wantErr string
+	}{
+		{"提交成功", func(*PortalRecipe) {}, ""},
+		{"未访问认证页面缺少会话Cookie", func(r *PortalRecipe) { r.VisitPortal = false }, "状态码: 403"},
+		{"表单字段错误", func(r *PortalRecipe) { r.Fields["room"] = "0000" }, "未包含预期内容"},
+		{"不跟随重定向", func(r *PortalRecipe) { r.FollowRedirects = false }, "未包含预期内容"},
+		{"凭据引用未设置", func(r *PortalRecipe) { r.Fields["room"] = "${env:CONNECT_TEST_MISSING}" }, "环境变量未设置"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := recipe()
+			tt.modify(r)
+			err := portalLogin(r, portalURL, 5*time.Second)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("portalLogin() 失败: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPortalAttemptBackoff(t *testing.T) {
+	var attempt *portalAttempt
+	var delays []time.Duration
+	for i := 0; i < 8; i++ {
+		attempt = attempt.fail()
+		delays = append(delays, time.Until(attempt.next).Round(time.Second))
+	}
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 30 * time.Minute, 30 * time.Minute}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Fatalf("第%d次失败后等待 %v，期望 %v", i+1, delays[i], want[i])
+		}
+	}
+	if portalKey("http://10.0.0.1/portal?t=1") != portalKey("http://10.0.0.1/portal?t=2") {
+		t.Fatalf("同一认证页面的不同查询参数应使用相同的退避记录")
+	}
+}
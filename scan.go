@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ScanResult 扫描到的一个WiFi接入点
+type ScanResult struct {
+	SSID          string `json:"ssid"`
+	BSSID         string `json:"bssid,omitempty"`
+	SignalDBm     int    `json:"signal_dbm,omitempty"`
+	SignalPercent int    `json:"signal_percent,omitempty"`
+	Channel       int    `json:"channel,omitempty"`
+	FrequencyMHz  int    `json:"frequency_mhz,omitempty"`
+	Band          string `json:"band,omitempty"`
+	Security      string `json:"security,omitempty"`
+}
+
+// SetSignalDBm 设置以dBm表示的信号强度，并换算百分比
+func (r *ScanResult) SetSignalDBm(dbm int) {
+	r.SignalDBm = dbm
+	r.SignalPercent = signalDBmToPercent(dbm)
+}
+
+// SetSignalPercent 设置以百分比表示的信号强度，并换算dBm
+func (r *ScanResult) SetSignalPercent(percent int) {
+	r.SignalPercent = percent
+	r.SignalDBm = signalPercentToDBm(percent)
+}
+
+// SetChannel 设置信道，并在频率未知时换算频率和频段
+func (r *ScanResult) SetChannel(channel int) {
+	r.Channel = channel
+	if r.FrequencyMHz == 0 {
+		r.FrequencyMHz = channelToFrequency(channel)
+	}
+	if r.Band == "" {
+		r.Band = frequencyToBand(r.FrequencyMHz)
+	}
+}
+
+// SetFrequency 设置频率，并换算信道和频段
+func (r *ScanResult) SetFrequency(freq int) {
+	r.FrequencyMHz = freq
+	r.Channel = frequencyToChannel(freq)
+	r.Band = frequencyToBand(freq)
+}
+
+// frequencyToBand 将WiFi频率（MHz）换算为频段名称
+func frequencyToBand(freq int) string {
+	switch {
+	case freq >= 2400 && freq < 2500:
+		return "2.4GHz"
+	case freq >= 5955 && freq <= 7125:
+		return "6GHz"
+	case freq >= 5000 && freq < 5955:
+		return "5GHz"
+	default:
+		return ""
+	}
+}
+
+// sortScanResults 按网络名称分组、组内按信号强度从强到弱排序
+func sortScanResults(results []ScanResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].SSID != results[j].SSID {
+			return results[i].SSID < results[j].SSID
+		}
+		return results[i].SignalDBm > results[j].SignalDBm
+	})
+}
+
+// filterScanResults 返回指定网络的全部接入点
+func filterScanResults(results []ScanResult, ssid string) []ScanResult {
+	var matched []ScanResult
+	for _, result := range results {
+		if result.SSID == ssid {
+			matched = append(matched, result)
+		}
+	}
+	return matched
+}
+
+// runScanCommand 执行scan子命令，列出附近可见的WiFi网络
+func runScanCommand(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	ssid := fs.String("ssid", "", "只显示指定名称的网络")
+	format := fs.String("format", "table", "输出格式: table 或 json")
+	fs.Parse(args)
+
+	connector, err := NewWiFiConnector()
+	if err != nil {
+		return err
+	}
+	results, err := connector.Scan()
+	if err != nil {
+		return err
+	}
+	if *ssid != "" {
+		results = filterScanResults(results, *ssid)
+	}
+	sortScanResults(results)
+
+	switch *format {
+	case "table":
+		return writeScanTable(os.Stdout, results)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if results == nil {
+			results = []ScanResult{}
+		}
+		return encoder.Encode(results)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", *format)
+	}
+}
+
+// writeScanTable 以表格形式输出扫描结果
+func writeScanTable(w io.Writer, results []ScanResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "网络\tBSSID\t信号\t信道\t频段\t安全类型\t系统报告")
+	for _, result := range results {
+		ssid := result.SSID
+		if ssid == "" {
+			ssid = "(隐藏网络)"
+		}
+		signal := ""
+		if result.SignalDBm != 0 {
+			signal = fmt.Sprintf("%d dBm (%d%%)", result.SignalDBm, result.SignalPercent)
+		}
+		channel := ""
+		if result.Channel > 0 {
+			channel = fmt.Sprint(result.Channel)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			ssid, result.BSSID, signal, channel, result.Band, result.SecurityType(), strings.TrimSpace(result.Security))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecurityType WiFi网络的安全类型
+type SecurityType string
+
+const (
+	// SecurityAuto 未指定，由扫描结果或系统自动判断
+	SecurityAuto SecurityType = ""
+	// SecurityOpen 开放网络，无密码
+	SecurityOpen SecurityType = "open"
+	// SecurityWPA2PSK WPA2个人版（预共享密钥）
+	SecurityWPA2PSK SecurityType = "wpa2-psk"
+	// SecurityWPA3SAE WPA3个人版（SAE）
+	SecurityWPA3SAE SecurityType = "wpa3-sae"
+	// SecurityWPA2WPA3 WPA2/WPA3过渡模式，同时接受PSK和SAE
+	SecurityWPA2WPA3 SecurityType = "wpa2-wpa3"
+	// SecurityEnterprise 企业级（802.1X），通过网络配置中的enterprise凭据指定
+	SecurityEnterprise SecurityType = "enterprise"
+)
+
+// ParseSecurityType 解析配置中的安全类型，auto或空表示自动判断
+func ParseSecurityType(value string) (SecurityType, error) {
+	switch security := SecurityType(strings.ToLower(strings.TrimSpace(value))); security {
+	case "auto":
+		return SecurityAuto, nil
+	case SecurityAuto, SecurityOpen, SecurityWPA2PSK, SecurityWPA3SAE, SecurityWPA2WPA3:
+		return security, nil
+	case SecurityEnterprise:
+		return SecurityAuto, fmt.Errorf("企业级网络请在网络配置中通过enterprise配置认证凭据")
+	default:
+		return SecurityAuto, fmt.Errorf("不支持的安全类型: %s（可选 auto、open、wpa2-psk、wpa3-sae、wpa2-wpa3）", value)
+	}
+}
+
+// RequiresPassword 是否需要WiFi密码
+func (s SecurityType) RequiresPassword() bool {
+	return s == SecurityWPA2PSK || s == SecurityWPA3SAE || s == SecurityWPA2WPA3
+}
+
+// SecurityType 根据扫描结果中系统报告的安全类型文本判断安全类型，兼容各平台的格式:
+// nmcli "WPA2 WPA3"、netsh "WPA3-Personal"、airport "WPA2(PSK/AES/AES)"、system_profiler "WPA2/WPA3 Personal"
+func (r *ScanResult) SecurityType() SecurityType {
+	security := strings.ToUpper(r.Security)
+	switch {
+	case security == "" || security == "OPEN" || security == "NONE" || security == "--" || security == "开放式":
+		return SecurityOpen
+	case strings.Contains(security, "802.1X") || strings.Contains(security, "ENTERPRISE") || strings.Contains(security, "企业"):
+		return SecurityEnterprise
+	}
+
+	hasSAE := strings.Contains(security, "WPA3") || strings.Contains(security, "SAE")
+	hasPSK := strings.Contains(security, "PSK") || strings.Contains(security, "WPA2") ||
+		strings.Contains(security, "WPA1") || strings.Contains(security, "WPA ") || security == "WPA" ||
+		strings.Contains(security, "WPA(") || strings.Contains(security, "WPA-")
+	switch {
+	case hasSAE && hasPSK:
+		return SecurityWPA2WPA3
+	case hasSAE:
+		return SecurityWPA3SAE
+	case hasPSK:
+		return SecurityWPA2PSK
+	default:
+		return SecurityAuto
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxStatusEvents 运行状态中保留的最近事件数量
+	maxStatusEvents = 200
+	// maxStatusSegments 运行状态中保留的时间线片段数量
+	maxStatusSegments = 500
+)
+
+// ConnectionState 连接状态
+type ConnectionState string
+
+const (
+	// StateDisconnected 未连接任何WiFi网络
+	StateDisconnected ConnectionState = "disconnected"
+	// StateConnected 已连接WiFi网络且互联网可用（或未启用连通性检测）
+	StateConnected ConnectionState = "connected"
+	// StateDegraded 已连接WiFi网络但互联网不可用
+	StateDegraded ConnectionState = "degraded"
+	// StateCaptivePortal 已连接WiFi网络但被认证页面拦截
+	StateCaptivePortal ConnectionState = "captive_portal"
+)
+
+// StatusSegment 时间线中的一个片段，表示一段时间内连接的网络和连接状态
+type StatusSegment struct {
+	Start   time.Time       `json:"start"`
+	End     time.Time       `json:"end"`
+	Network string          `json:"network"`
+	State   ConnectionState `json:"state"`
+}
+
+// StatusSnapshot 运行状态快照
+type StatusSnapshot struct {
+	StartTime       time.Time           `json:"start_time"`
+	Interface       string              `json:"interface"`
+	TargetNetwork   string              `json:"target_network"`
+	CurrentNetwork  string              `json:"current_network"`
+	State           ConnectionState     `json:"state"`
+	IPAddress       string              `json:"ip_address"`
+	IPv6Addresses   []string            `json:"ipv6_addresses"`
+	NetworkInfo     *NetworkInfo        `json:"network_info,omitempty"`
+	LastCheck       time.Time           `json:"last_check"`
+	LastError       string              `json:"last_error,omitempty"`
+	LastErrorTime   time.Time           `json:"last_error_time,omitzero"`
+	Connectivity    *ConnectivityReport `json:"connectivity,omitempty"`
+	Timeline        []StatusSegment     `json:"timeline"`
+	IPChanges       []HistoryEvent      `json:"ip_changes"`
+	ConnectAttempts []HistoryEvent      `json:"connect_attempts"`
+}
+
+// MonitorStatus 监控循环维护的运行状态，供状态面板读取
+type MonitorStatus struct {
+	startTime      time.Time
+	interfaceName  string
+	targetNetwork  string
+	currentNetwork string
+	state          ConnectionState
+	connectivity   *ConnectivityReport
+	ipAddress      string
+	ipv6Addresses  []string
+	networkInfo    *NetworkInfo
+	lastCheck      time.Time
+	lastError      string
+	lastErrorTime  time.Time
+	timeline       []StatusSegment
+	events         []HistoryEvent
+	mutex          sync.RWMutex
+}
+
+// NewMonitorStatus 创建新的运行状态
+func NewMonitorStatus(targetNetwork string) *MonitorStatus {
+	return &MonitorStatus{
+		startTime:     time.Now(),
+		targetNetwork: targetNetwork,
+		state:         StateDisconnected,
+	}
+}
+
+// SetTargetNetwork 更新当前目标网络
+func (s *MonitorStatus) SetTargetNetwork(targetNetwork string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.targetNetwork = targetNetwork
+}
+
+// UpdateNetwork 更新当前接口和网络，并维护时间线
+func (s *MonitorStatus) UpdateNetwork(interfaceName, network string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.interfaceName = interfaceName
+	if network == "" {
+		s.ipAddress = ""
+		s.ipv6Addresses = nil
+		s.networkInfo = nil
+		s.connectivity = nil
+		s.state = StateDisconnected
+	} else if s.state == StateDisconnected || network != s.currentNetwork {
+		// 新连接的网络在连通性检测前视为已连接
+		s.state = StateConnected
+	}
+	s.currentNetwork = network
+	s.updateTimeline()
+}
+
+// SetConnectivity 更新连通性检测结果，已连接但互联网不可用时进入降级状态
+func (s *MonitorStatus) SetConnectivity(report *ConnectivityReport) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.connectivity = report
+	if s.currentNetwork == "" {
+		return
+	}
+	switch {
+	case report.Online:
+		s.state = StateConnected
+	case report.CaptivePortal:
+		s.state = StateCaptivePortal
+	default:
+		s.state = StateDegraded
+	}
+	s.updateTimeline()
+}
+
+// updateTimeline 更新最近检查时间，网络或状态变化时开始新的时间线片段，调用方需持有锁
+func (s *MonitorStatus) updateTimeline() {
+	now := time.Now()
+	s.lastCheck = now
+
+	// 网络和状态都未变化时延长最后一个片段
+	n := len(s.timeline)
+	if n > 0 && s.timeline[n-1].Network == s.currentNetwork && s.timeline[n-1].State == s.state {
+		s.timeline[n-1].End = now
+		return
+	}
+
+	if n > 0 {
+		s.timeline[n-1].End = now
+	}
+	s.timeline = append(s.timeline, StatusSegment{Start: now, End: now, Network: s.currentNetwork, State: s.state})
+	if len(s.timeline) > maxStatusSegments {
+		s.timeline = s.timeline[len(s.timeline)-maxStatusSegments:]
+	}
+}
+
+// SetIPAddress 更新当前IP地址
+func (s *MonitorStatus) SetIPAddress(ip string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ipAddress = ip
+}
+
+// SetIPv6Addresses 更新当前IPv6地址列表
+func (s *MonitorStatus) SetIPv6Addresses(addrs []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ipv6Addresses = addrs
+}
+
+// SetNetworkInfo 更新当前的详细网络信息
+func (s *MonitorStatus) SetNetworkInfo(info *NetworkInfo) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.networkInfo = info
+}
+
+// SetError 记录最近一次错误
+func (s *MonitorStatus) SetError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastError = err.Error()
+	s.lastErrorTime = time.Now()
+	s.lastCheck = s.lastErrorTime
+}
+
+// ClearError 检查成功后清除最近一次错误
+func (s *MonitorStatus) ClearError() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastError = ""
+	s.lastErrorTime = time.Time{}
+}
+
+// AddEvent 添加一条最近事件
+func (s *MonitorStatus) AddEvent(event HistoryEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, event)
+	if len(s.events) > maxStatusEvents {
+		s.events = s.events[len(s.events)-maxStatusEvents:]
+	}
+}
+
+// Events 获取最近事件的副本
+func (s *MonitorStatus) Events() []HistoryEvent {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]HistoryEvent(nil), s.events...)
+}
+
+// Snapshot 获取当前运行状态的快照
+func (s *MonitorStatus) Snapshot() StatusSnapshot {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	snapshot := StatusSnapshot{
+		StartTime:       s.startTime,
+		Interface:       s.interfaceName,
+		TargetNetwork:   s.targetNetwork,
+		CurrentNetwork:  s.currentNetwork,
+		State:           s.state,
+		Connectivity:    s.connectivity,
+		IPAddress:       s.ipAddress,
+		IPv6Addresses:   append([]string{}, s.ipv6Addresses...),
+		NetworkInfo:     s.networkInfo,
+		LastCheck:       s.lastCheck,
+		LastError:       s.lastError,
+		LastErrorTime:   s.lastErrorTime,
+		Timeline:        append([]StatusSegment{}, s.timeline...),
+		IPChanges:       []HistoryEvent{},
+		ConnectAttempts: []HistoryEvent{},
+	}
+	for _, event := range s.events {
+		switch event.Type {
+		case HistoryEventIP, HistoryEventIPv6:
+			snapshot.IPChanges = append(snapshot.IPChanges, event)
+		case HistoryEventConnect:
+			snapshot.ConnectAttempts = append(snapshot.ConnectAttempts, event)
+		}
+	}
+	return snapshot
+}
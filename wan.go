@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultWANSources 默认的公网IP查询来源，http(s)地址为回显服务，stun:前缀为STUN服务器
+var defaultWANSources = []string{
+	"https://4.ipw.cn",
+	"https://api.ipify.org",
+	"https://ifconfig.me/ip",
+	"stun:stun.miwifi.com:3478",
+}
+
+// WANSourceResult 单个来源的查询结果
+type WANSourceResult struct {
+	Source string `json:"source"`
+	IP     string `json:"ip,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// WANStatus 公网IP检测状态
+type WANStatus struct {
+	IP         string            `json:"ip"`
+	PreviousIP string            `json:"previous_ip,omitempty"`
+	LastCheck  time.Time         `json:"last_check,omitzero"`
+	LastChange time.Time         `json:"last_change,omitzero"`
+	LastError  string            `json:"last_error,omitempty"`
+	Results    []WANSourceResult `json:"results"`
+}
+
+// WANIPDetector 公网IP检测器，向多个来源查询公网IP并交叉校验
+type WANIPDetector struct {
+	sources    []string
+	minAgree   int
+	httpClient *http.Client
+	timeout    time.Duration
+	ipDetector *IPChangeDetector
+	trigger    chan struct{}
+	status     WANStatus
+	mutex      sync.RWMutex
+}
+
+// NewWANIPDetector 创建新的公网IP检测器，minAgree为确认结果所需的一致来源数量
+func NewWANIPDetector(sources []string, minAgree int) *WANIPDetector {
+	if len(sources) == 0 {
+		sources = defaultWANSources
+	}
+	if minAgree < 1 {
+		minAgree = 1
+	}
+	if minAgree > len(sources) {
+		log.Printf("警告: 公网IP确认所需的一致来源数量(%d)超过来源数量(%d)，按%d处理", minAgree, len(sources), len(sources))
+		minAgree = len(sources)
+	}
+
+	timeout := 10 * time.Second
+	// 只查询IPv4公网地址
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp4", addr)
+		},
+	}
+
+	return &WANIPDetector{
+		sources:    sources,
+		minAgree:   minAgree,
+		httpClient: &http.Client{Timeout: timeout, Transport: transport},
+		timeout:    timeout,
+		ipDetector: NewIPChangeDetector(),
+		trigger:    make(chan struct{}, 1),
+	}
+}
+
+// Status 获取公网IP检测状态
+func (d *WANIPDetector) Status() WANStatus {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	status := d.status
+	status.Results = append([]WANSourceResult{}, d.status.Results...)
+	return status
+}
+
+// TriggerCheck 请求立即检测一次公网IP，已有待处理请求时忽略
+func (d *WANIPDetector) TriggerCheck() {
+	select {
+	case d.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run 按间隔周期性检测公网IP，收到触发请求时立即检测
+func (d *WANIPDetector) Run(interval time.Duration) {
+	d.check()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.trigger:
+		}
+		d.check()
+	}
+}
+
+// check 检测一次公网IP，变化时记录历史并发送通知
+func (d *WANIPDetector) check() {
+	ip, results, err := d.Detect()
+
+	d.mutex.Lock()
+	d.status.LastCheck = time.Now()
+	d.status.Results = results
+	if err != nil {
+		d.status.LastError = err.Error()
+		d.mutex.Unlock()
+		log.Printf("检测公网IP失败: %v", err)
+		return
+	}
+	d.status.LastError = ""
+	d.mutex.Unlock()
+
+	if ddnsUpdater != nil {
+		ddnsUpdater.UpdateAsync(DDNSSourceWAN, ip)
+	}
+
+	if !d.ipDetector.CheckIPChange(ip) {
+		log.Printf("公网IP未变化: %s", ip)
+		return
+	}
+
+	oldIP := d.ipDetector.GetPreviousIP()
+	d.mutex.Lock()
+	d.status.IP = ip
+	d.status.PreviousIP = oldIP
+	d.status.LastChange = time.Now()
+	d.mutex.Unlock()
+
+	log.Printf("公网IP变化: %s -> %s", oldIP, ip)
+	recordHistory(HistoryEvent{Type: HistoryEventWAN, From: oldIP, To: ip})
+
+	if enableNotification && feishuNotifier != nil {
+		log.Printf("发送飞书通知(因公网IP变化): %s -> %s", oldIP, ip)
+		feishuNotifier.SendWANIPChangeNotificationAsync(oldIP, ip)
+	}
+}
+
+// Detect 并发查询所有来源，返回达到一致数量要求的公网IP
+func (d *WANIPDetector) Detect() (string, []WANSourceResult, error) {
+	results := make([]WANSourceResult, len(d.sources))
+	var wg sync.WaitGroup
+	for i, source := range d.sources {
+		wg.Add(1)
+		go func(i int, source string) {
+			defer wg.Done()
+			results[i] = WANSourceResult{Source: source}
+			ip, err := d.querySource(source)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].IP = ip
+		}(i, source)
+	}
+	wg.Wait()
+
+	// 统计各IP的一致来源数量
+	votes := make(map[string]int)
+	for _, result := range results {
+		if result.IP != "" {
+			votes[result.IP]++
+		}
+	}
+	if len(votes) == 0 {
+		return "", results, fmt.Errorf("所有来源都查询失败")
+	}
+
+	candidates := make([]string, 0, len(votes))
+	for ip := range votes {
+		candidates = append(candidates, ip)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if votes[candidates[i]] != votes[candidates[j]] {
+			return votes[candidates[i]] > votes[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	best := candidates[0]
+	if votes[best] < d.minAgree {
+		return "", results, fmt.Errorf("公网IP交叉校验未通过: %s 仅有%d个来源一致，需要%d个", best, votes[best], d.minAgree)
+	}
+	if len(candidates) > 1 {
+		log.Printf("警告: 公网IP来源结果不一致: %v", votes)
+	}
+	return best, results, nil
+}
+
+// querySource 查询单个来源
+func (d *WANIPDetector) querySource(source string) (string, error) {
+	if strings.HasPrefix(source, "stun:") {
+		return queryStunIP(strings.TrimPrefix(source, "stun:"), d.timeout)
+	}
+	return d.queryHTTPIP(source)
+}
+
+// queryHTTPIP 通过HTTP回显服务查询公网IP
+func (d *WANIPDetector) queryHTTPIP(url string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %v", err)
+	}
+	// 部分回显服务根据User-Agent决定返回纯文本还是网页
+	req.Header.Set("User-Agent", "curl/8.0")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP请求失败，状态码: %d", resp.StatusCode)
+	}
+
+	ip := extractIPv4(string(body))
+	if ip == "" {
+		return "", fmt.Errorf("响应中未找到IP地址: %s", strings.TrimSpace(string(body)))
+	}
+	return ip, nil
+}
+
+// extractIPv4 从文本中提取第一个IPv4地址，兼容纯文本和带说明文字的响应
+func extractIPv4(text string) string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !(r == '.' || (r >= '0' && r <= '9'))
+	})
+	for _, field := range fields {
+		if ip := net.ParseIP(strings.Trim(field, ".")); ip != nil && ip.To4() != nil {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+const (
+	// stunMagicCookie STUN协议的固定魔术字（RFC 5389）
+	stunMagicCookie = 0x2112A442
+	// stunBindingRequest Binding请求消息类型
+	stunBindingRequest = 0x0001
+	// stunBindingSuccess Binding成功响应消息类型
+	stunBindingSuccess = 0x0101
+	// stunAttrMappedAddress MAPPED-ADDRESS属性
+	stunAttrMappedAddress = 0x0001
+	// stunAttrXorMappedAddress XOR-MAPPED-ADDRESS属性
+	stunAttrXorMappedAddress = 0x0020
+)
+
+// queryStunIP 向STUN服务器发送Binding请求，返回映射的公网IPv4地址
+func queryStunIP(server string, timeout time.Duration) (string, error) {
+	conn, err := net.DialTimeout("udp4", server, timeout)
+	if err != nil {
+		return "", fmt.Errorf("连接STUN服务器失败: %v", err)
+	}
+	defer conn.Close()
+
+	request := make([]byte, 20)
+	binary.BigEndian.PutUint16(request[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint16(request[2:4], 0)
+	binary.BigEndian.PutUint32(request[4:8], stunMagicCookie)
+	if _, err := rand.Read(request[8:20]); err != nil {
+		return "", fmt.Errorf("生成事务ID失败: %v", err)
+	}
+
+	// UDP可能丢包，超时时间内重发
+	buffer := make([]byte, 1500)
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if _, err := conn.Write(request); err != nil {
+			return "", fmt.Errorf("发送STUN请求失败: %v", err)
+		}
+		readDeadline := time.Now().Add(2 * time.Second)
+		if readDeadline.After(deadline) {
+			readDeadline = deadline
+		}
+		conn.SetReadDeadline(readDeadline)
+		for {
+			n, err := conn.Read(buffer)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					break
+				}
+				return "", fmt.Errorf("读取STUN响应失败: %v", err)
+			}
+			ip, err := parseStunResponse(buffer[:n], request[8:20])
+			if errors.Is(err, errStunTransactionMismatch) {
+				// 之前请求的迟到响应或无关的数据包，继续等待本次请求的响应
+				continue
+			}
+			return ip, err
+		}
+	}
+	return "", fmt.Errorf("STUN请求超时")
+}
+
+// errStunTransactionMismatch STUN响应的事务ID与请求不一致
+var errStunTransactionMismatch = errors.New("STUN响应事务ID不匹配")
+
+// parseStunResponse 解析STUN Binding响应中的映射地址
+func parseStunResponse(data, transactionID []byte) (string, error) {
+	if len(data) < 20 {
+		return "", fmt.Errorf("STUN响应过短")
+	}
+	if binary.BigEndian.Uint16(data[0:2]) != stunBindingSuccess {
+		return "", fmt.Errorf("STUN响应类型错误: 0x%04x", binary.BigEndian.Uint16(data[0:2]))
+	}
+	if binary.BigEndian.Uint32(data[4:8]) != stunMagicCookie || string(data[8:20]) != string(transactionID) {
+		return "", errStunTransactionMismatch
+	}
+
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if 20+length > len(data) {
+		return "", fmt.Errorf("STUN响应长度错误")
+	}
+
+	var mapped string
+	attributes := data[20 : 20+length]
+	for len(attributes) >= 4 {
+		attrType := binary.BigEndian.Uint16(attributes[0:2])
+		attrLength := int(binary.BigEndian.Uint16(attributes[2:4]))
+		if 4+attrLength > len(attributes) {
+			break
+		}
+		value := attributes[4 : 4+attrLength]
+
+		// 地址属性格式: 保留(1) 地址族(1) 端口(2) 地址(4)
+		if len(value) >= 8 && value[1] == 0x01 {
+			address := make(net.IP, 4)
+			copy(address, value[4:8])
+			switch attrType {
+			case stunAttrXorMappedAddress:
+				cookie := make([]byte, 4)
+				binary.BigEndian.PutUint32(cookie, stunMagicCookie)
+				for i := range address {
+					address[i] ^= cookie[i]
+				}
+				return address.String(), nil
+			case stunAttrMappedAddress:
+				mapped = address.String()
+			}
+		}
+
+		// 属性按4字节对齐
+		padded := (attrLength + 3) &^ 3
+		if 4+padded > len(attributes) {
+			break
+		}
+		attributes = attributes[4+padded:]
+	}
+
+	if mapped != "" {
+		return mapped, nil
+	}
+	return "", fmt.Errorf("STUN响应中未找到映射地址")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startEchoServer 启动返回固定内容的公网IP回显服务
+func startEchoServer(t *testing.T, body string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// stunResponse 构造STUN Binding成功响应，xor为true时使用XOR-MAPPED-ADDRESS属性
+func stunResponse(transactionID []byte, ip string, port uint16, xor bool) []byte {
+	value := make([]byte, 8)
+	value[1] = 0x01 // IPv4
+	copy(value[4:8], net.ParseIP(ip).To4())
+	binary.BigEndian.PutUint16(value[2:4], port)
+	attrType := uint16(stunAttrMappedAddress)
+	if xor {
+		attrType = stunAttrXorMappedAddress
+		binary.BigEndian.PutUint16(value[2:4], port^uint16(stunMagicCookie>>16))
+		cookie := make([]byte, 4)
+		binary.BigEndian.PutUint32(cookie, stunMagicCookie)
+		for i := 0; i < 4; i++ {
+			value[4+i] ^= cookie[i]
+		}
+	}
+
+	message := make([]byte, 20, 32)
+	binary.BigEndian.PutUint16(message[0:2], stunBindingSuccess)
+	binary.BigEndian.PutUint16(message[2:4], uint16(4+len(value)))
+	binary.BigEndian.PutUint32(message[4:8], stunMagicCookie)
+	copy(message[8:20], transactionID)
+	message = binary.BigEndian.AppendUint16(message, attrType)
+	message = binary.BigEndian.AppendUint16(message, uint16(len(value)))
+	return append(message, value...)
+}
+
+// startStunServer 启动本地STUN服务，每个请求先回复一个事务ID不匹配的响应，再回复正确的XOR-MAPPED-ADDRESS
+func startStunServer(t *testing.T, ip string) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动STUN服务失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buffer := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if n < 20 || binary.BigEndian.Uint16(buffer[0:2]) != stunBindingRequest {
+				continue
+			}
+			stale := make([]byte, 12)
+			copy(stale, "late-reply!!")
+			conn.WriteTo(stunResponse(stale, "192.0.2.99", 1, true), addr)
+			conn.WriteTo(stunResponse(buffer[8:20], ip, 54321, true), addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestParseStunResponse(t *testing.T) {
+	transactionID := []byte("0123456789ab")
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr error
+	}{
+		{"XOR-MAPPED-ADDRESS", stunResponse(transactionID, "203.0.113.7", 3478, true), "203.0.113.7", nil},
+		{"MAPPED-ADDRESS", stunResponse(transactionID, "198.51.100.20", 3478, false), "198.51.100.20", nil},
+		{"事务ID不匹配", stunResponse([]byte("ba9876543210"), "203.0.113.7", 3478, true), "", errStunTransactionMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStunResponse(tt.data, transactionID)
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Fatalf("错误 = %v，期望 %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("parseStunResponse() = %q, %v，期望 %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryStunIPIgnoresMismatchedTransaction(t *testing.T) {
+	server := startStunServer(t, "203.0.113.7")
+	ip, err := queryStunIP(server, 3*time.Second)
+	if err != nil {
+		t.Fatalf("queryStunIP() 失败: %v", err)
+	}
+	if ip != "203.0.113.7" {
+		t.Fatalf("queryStunIP() = %s，期望 203.0.113.7", ip)
+	}
+}
+
+func TestWANDetectVoting(t *testing.T) {
+	agreeing := []string{
+		startEchoServer(t, "203.0.113.7\n"),
+		startEchoServer(t, "当前IP：203.0.113.7 来自：测试"),
+		"stun:" + startStunServer(t, "203.0.113.7"),
+	}
+	dissenting := startEchoServer(t, "198.51.100.1")
+
+	tests := []struct {
+		name     string
+		sources  []string
+		minAgree int
+		want     string
+		wantErr  string
+	}{
+		{"多数一致", append([]string{dissenting}, agreeing...), 2, "203.0.113.7", ""},
+		{"一致数量不足", []string{agreeing[0], dissenting}, 2, "", "交叉校验未通过"},
+		{"全部失败", []string{startEchoServer(t, "no address")}, 1, "", "所有来源都查询失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewWANIPDetector(tt.sources, tt.minAgree)
+			detector.timeout = 3 * time.Second
+			ip, results, err := detector.Detect()
+			if len(results) != len(tt.sources) {
+				t.Fatalf("结果数量 = %d，期望 %d", len(results), len(tt.sources))
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || ip != tt.want {
+				t.Fatalf("Detect() = %q, %v，期望 %q", ip, err, tt.want)
+			}
+		})
+	}
+}
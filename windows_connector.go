@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/xml"
 	"fmt"
+	"log"
 	"os/exec"
 	"strings"
 	"time"
@@ -25,7 +27,7 @@ func NewWindowsConnector() (*WindowsConnector, error) {
 
 // executePowerShellCommand 执行PowerShell命令并返回输出结果
 func (w *WindowsConnector) executePowerShellCommand(command string) (string, error) {
-	fmt.Printf("[DEBUG] 执行PowerShell命令: %s\n", command)
+	debugf("执行PowerShell命令: %s", command)
 	// 尝试多种PowerShell调用方式以提高兼容性
 	var cmd *exec.Cmd
 
@@ -33,24 +35,24 @@ func (w *WindowsConnector) executePowerShellCommand(command string) (string, err
 	cmd = exec.Command("powershell.exe", "-NoProfile", "-ExecutionPolicy", "Bypass", "-Command", "[Console]::OutputEncoding = [System.Text.Encoding]::UTF8; "+command)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		fmt.Printf("[DEBUG] 方式1失败，尝试方式2\n")
+		debugf("方式1失败，尝试方式2")
 		// 方式2：不使用编码设置
 		cmd = exec.Command("powershell.exe", "-NoProfile", "-ExecutionPolicy", "Bypass", "-Command", command)
 		output, err = cmd.CombinedOutput()
 		if err != nil {
-			fmt.Printf("[DEBUG] 方式2失败，尝试方式3\n")
+			debugf("方式2失败，尝试方式3")
 			// 方式3：使用基本的powershell命令
 			cmd = exec.Command("powershell", "-Command", command)
 			output, err = cmd.CombinedOutput()
 			if err != nil {
-				fmt.Printf("[ERROR] 所有PowerShell调用方式都失败: %v\n", err)
-				fmt.Printf("[ERROR] 最后一次命令输出: %s\n", string(output))
+				log.Printf("所有PowerShell调用方式都失败: %v", err)
+				log.Printf("最后一次命令输出: %s", string(output))
 				return "", err
 			}
 		}
 	}
 	result := strings.TrimSpace(string(output))
-	fmt.Printf("[DEBUG] PowerShell命令输出: %s\n", result)
+	debugf("PowerShell命令输出: %s", result)
 	return result, nil
 }
 
@@ -58,7 +60,7 @@ func (w *WindowsConnector) executePowerShellCommand(command string) (string, err
 // 参数 output: PowerShell命令的原始输出（可能包含多行）
 // 返回值: 最佳的WiFi接口名称，如果没有找到则返回空字符串
 func (w *WindowsConnector) selectBestWiFiInterface(output string) string {
-	fmt.Printf("[DEBUG] 智能选择WiFi接口，原始输出: %s\n", output)
+	debugf("智能选择WiFi接口，原始输出: %s", output)
 	// 处理多行输出，提取所有有效接口
 	lines := strings.Split(strings.TrimSpace(output), "\n")
 	var validInterfaces []string
@@ -69,10 +71,10 @@ func (w *WindowsConnector) selectBestWiFiInterface(output string) string {
 		}
 	}
 
-	fmt.Printf("[DEBUG] 解析出的接口列表: %v\n", validInterfaces)
+	debugf("解析出的接口列表: %v", validInterfaces)
 	// 如果没有找到任何接口，返回空字符串
 	if len(validInterfaces) == 0 {
-		fmt.Printf("[DEBUG] 没有找到任何接口\n")
+		debugf("没有找到任何接口")
 		return ""
 	}
 
@@ -85,147 +87,163 @@ func (w *WindowsConnector) selectBestWiFiInterface(output string) string {
 	for _, priority := range wifiPriority {
 		for _, iface := range validInterfaces {
 			if strings.Contains(strings.ToLower(iface), strings.ToLower(priority)) {
-				fmt.Printf("[DEBUG] 通过优先级匹配找到接口: %s (匹配关键词: %s)\n", iface, priority)
+				debugf("通过优先级匹配找到接口: %s (匹配关键词: %s)", iface, priority)
 				return iface
 			}
 		}
 	}
 
 	// 如果没有找到优先级匹配的，排除明显的以太网接口
-	fmt.Printf("[DEBUG] 未找到优先级匹配，开始排除非WiFi接口\n")
+	debugf("未找到优先级匹配，开始排除非WiFi接口")
 	for _, iface := range validInterfaces {
 		lowerIface := strings.ToLower(iface)
 		// 排除以太网接口
 		if strings.Contains(lowerIface, "以太网") && !strings.Contains(lowerIface, "wi") && !strings.Contains(lowerIface, "wireless") {
-			fmt.Printf("[DEBUG] 排除以太网接口: %s\n", iface)
+			debugf("排除以太网接口: %s", iface)
 			continue
 		}
 		if strings.Contains(lowerIface, "ethernet") && !strings.Contains(lowerIface, "wi") && !strings.Contains(lowerIface, "wireless") {
-			fmt.Printf("[DEBUG] 排除以太网接口: %s\n", iface)
+			debugf("排除以太网接口: %s", iface)
 			continue
 		}
 		// 排除蓝牙接口
 		if strings.Contains(lowerIface, "蓝牙") || strings.Contains(lowerIface, "bluetooth") {
-			fmt.Printf("[DEBUG] 排除蓝牙接口: %s\n", iface)
+			debugf("排除蓝牙接口: %s", iface)
 			continue
 		}
-		fmt.Printf("[DEBUG] 选择接口: %s\n", iface)
+		debugf("选择接口: %s", iface)
 		return iface
 	}
 
 	// 如果都被排除了，返回第一个（作为最后的备用方案）
 	if len(validInterfaces) > 0 {
-		fmt.Printf("[DEBUG] 所有接口都被排除，使用第一个作为备用方案: %s\n", validInterfaces[0])
+		debugf("所有接口都被排除，使用第一个作为备用方案: %s", validInterfaces[0])
 		return validInterfaces[0]
 	}
-	fmt.Printf("[DEBUG] 没有可用的接口\n")
+	debugf("没有可用的接口")
 	return ""
 }
 
 // detectInterface 检测WiFi网络接口
 func (w *WindowsConnector) detectInterface() (string, error) {
-	fmt.Printf("开始检测WiFi接口...\n")
+	debugf("开始检测WiFi接口...")
 
 	// 方法1：获取所有网络适配器并显示调试信息
-	fmt.Printf("[INFO] 方法1: 获取所有网络适配器信息\n")
+	debugf("方法1: 获取所有网络适配器信息")
 	command := `Get-NetAdapter | Format-Table Name, InterfaceDescription, MediaType, Status -AutoSize`
 	allAdapters, err := w.executePowerShellCommand(command)
 	if err == nil {
-		fmt.Printf("所有网络适配器:\n%s\n", allAdapters)
+		debugf("所有网络适配器:\n%s", allAdapters)
 	} else {
-		fmt.Printf("[ERROR] 方法1失败: %v\n", err)
+		debugf("方法1失败: %v", err)
 	}
 
 	// 方法2：按名称匹配WiFi接口（扩展匹配模式）
-	fmt.Printf("[INFO] 方法2: 按名称匹配WiFi接口\n")
+	debugf("方法2: 按名称匹配WiFi接口")
 	command2 := `Get-NetAdapter | Where-Object {$_.Name -match 'Wi-Fi|无线|WLAN|WiFi|Wireless|以太网|Ethernet.*Wi|Wi.*Fi'} | Select-Object -ExpandProperty Name`
 	ifName, err2 := w.executePowerShellCommand(command2)
 	if err2 == nil && ifName != "" {
 		// 智能选择最佳WiFi接口（内部处理多行输出）
 		bestInterface := w.selectBestWiFiInterface(ifName)
 		if bestInterface != "" {
-			fmt.Printf("通过名称匹配找到 WiFi 接口: %s\n", bestInterface)
+			debugf("通过名称匹配找到 WiFi 接口: %s", bestInterface)
 			return bestInterface, nil
 		}
-		fmt.Printf("[WARN] 方法2找到接口但未通过智能选择: %s\n", ifName)
+		debugf("方法2找到接口但未通过智能选择: %s", ifName)
 	} else {
-		fmt.Printf("[ERROR] 方法2失败: %v\n", err2)
+		debugf("方法2失败: %v", err2)
 	}
 
 	// 方法3：通过媒体类型查找（不限制状态）
-	fmt.Printf("[INFO] 方法3: 通过媒体类型查找WiFi接口\n")
+	debugf("方法3: 通过媒体类型查找WiFi接口")
 	command3 := `Get-NetAdapter | Where-Object {$_.MediaType -eq 'Native 802.11'} | Select-Object -ExpandProperty Name`
 	ifName2, err3 := w.executePowerShellCommand(command3)
 	if err3 == nil && ifName2 != "" {
 		// 智能选择最佳WiFi接口（内部处理多行输出）
 		bestInterface := w.selectBestWiFiInterface(ifName2)
 		if bestInterface != "" {
-			fmt.Printf("通过媒体类型找到 WiFi 接口: %s\n", bestInterface)
+			debugf("通过媒体类型找到 WiFi 接口: %s", bestInterface)
 			return bestInterface, nil
 		}
-		fmt.Printf("[WARN] 方法3找到接口但未通过智能选择: %s\n", ifName2)
+		debugf("方法3找到接口但未通过智能选择: %s", ifName2)
 	} else {
-		fmt.Printf("[ERROR] 方法3失败: %v\n", err3)
+		debugf("方法3失败: %v", err3)
 	}
 
 	// 方法4：通过接口描述查找
-	fmt.Printf("[INFO] 方法4: 通过接口描述查找WiFi接口\n")
+	debugf("方法4: 通过接口描述查找WiFi接口")
 	command4 := `Get-NetAdapter | Where-Object {$_.InterfaceDescription -match 'Wireless|Wi-Fi|802.11|WiFi'} | Select-Object -ExpandProperty Name`
 	ifName3, err4 := w.executePowerShellCommand(command4)
 	if err4 == nil && ifName3 != "" {
 		// 智能选择最佳WiFi接口（内部处理多行输出）
 		bestInterface := w.selectBestWiFiInterface(ifName3)
 		if bestInterface != "" {
-			fmt.Printf("通过接口描述找到 WiFi 接口: %s\n", bestInterface)
+			debugf("通过接口描述找到 WiFi 接口: %s", bestInterface)
 			return bestInterface, nil
 		}
-		fmt.Printf("[WARN] 方法4找到接口但未通过智能选择: %s\n", ifName3)
+		debugf("方法4找到接口但未通过智能选择: %s", ifName3)
 	} else {
-		fmt.Printf("[ERROR] 方法4失败: %v\n", err4)
+		debugf("方法4失败: %v", err4)
 	}
 
 	// 方法5：使用WMI查询（更兼容的方式）
-	fmt.Printf("[INFO] 方法5: 使用WMI查询WiFi接口\n")
+	debugf("方法5: 使用WMI查询WiFi接口")
 	command5 := `Get-WmiObject -Class Win32_NetworkAdapter | Where-Object {$_.Name -match 'Wireless|Wi-Fi|无线|WLAN|802.11' -and $_.NetConnectionID -ne $null} | Select-Object -ExpandProperty NetConnectionID`
 	ifName4, err5 := w.executePowerShellCommand(command5)
 	if err5 == nil && ifName4 != "" {
 		// 智能选择最佳WiFi接口（内部处理多行输出）
 		bestInterface := w.selectBestWiFiInterface(ifName4)
 		if bestInterface != "" {
-			fmt.Printf("通过WMI找到 WiFi 接口: %s\n", bestInterface)
+			debugf("通过WMI找到 WiFi 接口: %s", bestInterface)
 			return bestInterface, nil
 		}
-		fmt.Printf("[WARN] 方法5找到接口但未通过智能选择: %s\n", ifName4)
+		debugf("方法5找到接口但未通过智能选择: %s", ifName4)
 	} else {
-		fmt.Printf("[ERROR] 方法5失败: %v\n", err5)
+		debugf("方法5失败: %v", err5)
 	}
 
 	// 方法6：获取第一个可用的网络适配器（最后的备用方案）
-	fmt.Printf("[INFO] 方法6: 获取可用的网络适配器\n")
+	debugf("方法6: 获取可用的网络适配器")
 	command6 := `Get-NetAdapter | Where-Object {$_.Status -eq 'Up'} | Select-Object -ExpandProperty Name`
 	ifName5, err6 := w.executePowerShellCommand(command6)
 	if err6 == nil && ifName5 != "" {
 		// 智能选择最佳WiFi接口（内部处理多行输出）
 		bestInterface := w.selectBestWiFiInterface(ifName5)
 		if bestInterface != "" {
-			fmt.Printf("使用最佳可用适配器作为 WiFi 接口: %s\n", bestInterface)
+			debugf("使用最佳可用适配器作为 WiFi 接口: %s", bestInterface)
 			return bestInterface, nil
 		}
-		fmt.Printf("[WARN] 方法6找到接口但未通过智能选择: %s\n", ifName5)
+		debugf("方法6找到接口但未通过智能选择: %s", ifName5)
 	} else {
-		fmt.Printf("[ERROR] 方法6失败: %v\n", err6)
+		debugf("方法6失败: %v", err6)
 	}
 
 	// 显示详细的错误信息
-	fmt.Printf("所有检测方法都失败了:\n")
-	fmt.Printf("- 方法1错误: %v\n", err)
-	fmt.Printf("- 方法2错误: %v\n", err2)
-	fmt.Printf("- 方法3错误: %v\n", err3)
-	fmt.Printf("- 方法4错误: %v\n", err4)
-	fmt.Printf("- 方法5错误: %v\n", err5)
-	fmt.Printf("- 方法6错误: %v\n", err6)
-
-	return "", fmt.Errorf("未找到 WiFi 网络接口。请确保:\n1. WiFi 适配器已安装并启用\n2. 以管理员权限运行程序\n3. PowerShell 命令可用\n4. 检查上述调试信息确认适配器状态")
+	debugf("所有检测方法都失败了:")
+	debugf("- 方法1错误: %v", err)
+	debugf("- 方法2错误: %v", err2)
+	debugf("- 方法3错误: %v", err3)
+	debugf("- 方法4错误: %v", err4)
+	debugf("- 方法5错误: %v", err5)
+	debugf("- 方法6错误: %v", err6)
+
+	return "", fmt.Errorf("未找到 WiFi 网络接口。请确保:\n1. WiFi 适配器已安装并启用\n2. 以管理员权限运行程序\n3. PowerShell 命令可用\n4. 使用 -verbose 查看各检测方法的调试信息")
+}
+
+// listWindowsWiFiInterfaces 按媒体类型列出全部WiFi接口
+func listWindowsWiFiInterfaces() ([]string, error) {
+	command := `Get-NetAdapter | Where-Object {$_.MediaType -eq 'Native 802.11' -or $_.NdisPhysicalMedium -eq 9} | Select-Object -ExpandProperty Name`
+	output, err := (&WindowsConnector{}).executePowerShellCommand(command)
+	if err != nil {
+		return nil, fmt.Errorf("获取网络接口列表失败: %v", err)
+	}
+	var interfaces []string
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			interfaces = append(interfaces, name)
+		}
+	}
+	return interfaces, nil
 }
 
 // GetInterface 实现WiFiConnector接口 - 获取WiFi接口名称
@@ -235,6 +253,20 @@ func (w *WindowsConnector) GetInterface() (string, error) {
 
 // GetCurrentNetwork 实现WiFiConnector接口 - 获取当前WiFi网络
 func (w *WindowsConnector) GetCurrentNetwork() (string, error) {
+	// 优先读取当前接口的信息块，有多个WiFi网卡时不会读到其他网卡连接的网络
+	if output, err := w.executePowerShellCommand(`netsh wlan show interfaces`); err == nil {
+		values := w.selectInterfaceBlock(output)
+		if firstValue(values, "Name", "名称") == w.interfaceName {
+			ssid := values["SSID"]
+			if strings.Contains(ssid, "正在识别") {
+				debugf("网络正在识别中: %s", ssid)
+				return "正在识别", nil
+			}
+			debugf("获取到接口 %s 当前网络: '%s'", w.interfaceName, ssid)
+			return ssid, nil
+		}
+	}
+
 	// 使用netsh命令获取当前连接的WiFi网络
 	command := `netsh wlan show interfaces | Select-String "SSID" | Where-Object { $_.Line -match "SSID" -and $_.Line -notmatch "BSSID" } | ForEach-Object { ($_ -split ":")[1].Trim() }`
 	ssid, err := w.executePowerShellCommand(command)
@@ -243,14 +275,14 @@ func (w *WindowsConnector) GetCurrentNetwork() (string, error) {
 		ssid = strings.TrimSpace(ssid)
 		// 如果SSID包含"正在识别"，则认为网络正在切换中
 		if strings.Contains(ssid, "正在识别") {
-			fmt.Printf("[DEBUG] 网络正在识别中: %s\n", ssid)
+			debugf("网络正在识别中: %s", ssid)
 			return "正在识别", nil
 		}
 		// 如果SSID以"正在识别"开头，则认为未连接
 		if strings.HasPrefix(ssid, "正在识别") {
 			return "正在识别", nil
 		}
-		fmt.Printf("[DEBUG] 获取到当前网络: %s\n", ssid)
+		debugf("获取到当前网络: %s", ssid)
 		return ssid, nil
 	}
 
@@ -260,10 +292,10 @@ func (w *WindowsConnector) GetCurrentNetwork() (string, error) {
 	if err2 == nil && ssid2 != "" {
 		ssid2 = strings.TrimSpace(ssid2)
 		if strings.Contains(ssid2, "正在识别") {
-			fmt.Printf("[DEBUG] 网络正在识别中（备用方法）: %s\n", ssid2)
+			debugf("网络正在识别中（备用方法）: %s", ssid2)
 			return "正在识别", nil
 		}
-		fmt.Printf("[DEBUG] 获取到当前网络（备用方法）: %s\n", ssid2)
+		debugf("获取到当前网络（备用方法）: %s", ssid2)
 		return ssid2, nil
 	}
 
@@ -275,14 +307,14 @@ func (w *WindowsConnector) GetCurrentNetwork() (string, error) {
 		ssid3 = strings.TrimSpace(ssid3)
 		// 如果SSID包含"正在识别"，则认为网络正在切换中
 		if strings.Contains(ssid3, "正在识别") {
-			fmt.Printf("[DEBUG] 网络正在识别中: %s\n", ssid3)
+			debugf("网络正在识别中: %s", ssid3)
 			return "正在识别", nil
 		}
 		// 如果SSID以"正在识别"开头，则认为未连接
 		if strings.HasPrefix(ssid3, "正在识别") {
 			return "正在识别", nil
 		}
-		fmt.Printf("[DEBUG] 获取到当前网络: %s\n", ssid3)
+		debugf("获取到当前网络: %s", ssid3)
 		return ssid3, nil
 	}
 
@@ -290,53 +322,41 @@ func (w *WindowsConnector) GetCurrentNetwork() (string, error) {
 	command4 := `(Get-WmiObject -Class Win32_NetworkAdapterConfiguration | Where-Object {$_.Description -match 'Wireless|Wi-Fi' -and $_.IPEnabled -eq $true}).Description`
 	result, err4 := w.executePowerShellCommand(command4)
 	if err4 != nil || result == "" {
-		fmt.Printf("[DEBUG] 未连接任何WiFi网络\n")
+		debugf("未连接任何WiFi网络")
 		return "", nil // 未连接任何WiFi
 	}
 
-	fmt.Printf("[DEBUG] 获取到当前网络（WMI方法）: %s\n", result)
+	debugf("获取到当前网络（WMI方法）: %s", result)
 	return result, nil
 }
 
 // Connect 实现WiFiConnector接口 - 连接WiFi网络
-func (w *WindowsConnector) Connect(networkName, password string) error {
+func (w *WindowsConnector) Connect(networkName, password string, options ConnectOptions) error {
+	profile, err := buildWLANProfile(networkName, password, options)
+	if err != nil {
+		return err
+	}
+
 	// 使用PowerShell连接WiFi
 	var command string
-	if password != "" {
-		// 有密码的网络
-		command = fmt.Sprintf(`$profile = netsh wlan show profiles name="%s" key=clear; if ($profile -match "Key Content") { netsh wlan connect name="%s" } else { $xml = @"
-<?xml version="1.0"?>
-<WLANProfile xmlns="http://www.microsoft.com/networking/WLAN/profile/v1">
-	<name>%s</name>
-	<SSIDConfig>
-		<SSID>
-			<name>%s</name>
-		</SSID>
-	</SSIDConfig>
-	<connectionType>ESS</connectionType>
-	<connectionMode>auto</connectionMode>
-	<MSM>
-		<security>
-			<authEncryption>
-				<authentication>WPA2PSK</authentication>
-				<encryption>AES</encryption>
-				<useOneX>false</useOneX>
-			</authEncryption>
-			<sharedKey>
-				<keyType>passPhrase</keyType>
-				<protected>false</protected>
-				<keyMaterial>%s</keyMaterial>
-			</sharedKey>
-		</security>
-	</MSM>
-</WLANProfile>
-"@; $xml | Out-File -FilePath "$env:TEMP\wifi_profile.xml" -Encoding UTF8; netsh wlan add profile filename="$env:TEMP\wifi_profile.xml"; netsh wlan connect name="%s" }`, networkName, networkName, networkName, networkName, password, networkName)
-	} else {
+	switch {
+	case options.Enterprise != nil:
+		// 企业级网络每次都重新写入配置文件和用户凭据
+		command = w.enterpriseSetupCommand(networkName, profile, password, options.Enterprise) +
+			fmt.Sprintf(`; netsh wlan connect name="%s" interface="%s"`, networkName, w.interfaceName)
+	case options.Hidden || options.Security != SecurityAuto:
+		// 隐藏网络需要在配置文件中声明nonBroadcast，指定了安全类型时需要确保配置文件与之一致，每次都重新写入配置文件
+		command = fmt.Sprintf(`%s; netsh wlan connect name="%s" interface="%s"`, addWLANProfileCommand(profile), networkName, w.interfaceName)
+	case password != "":
+		// 有密码的网络，已保存密码时直接连接，否则先写入配置文件
+		command = fmt.Sprintf(`$profile = netsh wlan show profiles name="%s" key=clear; if ($profile -match "Key Content") { netsh wlan connect name="%s" interface="%s" } else { %s; netsh wlan connect name="%s" interface="%s" }`,
+			networkName, networkName, w.interfaceName, addWLANProfileCommand(profile), networkName, w.interfaceName)
+	default:
 		// 无密码的网络
-		command = fmt.Sprintf(`netsh wlan connect name="%s"`, networkName)
+		command = fmt.Sprintf(`netsh wlan connect name="%s" interface="%s"`, networkName, w.interfaceName)
 	}
 
-	_, err := w.executePowerShellCommand(command)
+	_, err = w.executePowerShellCommand(command)
 	if err != nil {
 		return fmt.Errorf("连接WiFi失败: %v", err)
 	}
@@ -345,33 +365,36 @@ func (w *WindowsConnector) Connect(networkName, password string) error {
 	// 增加等待时间并改进验证逻辑
 	for i := 0; i < 20; i++ { // 增加到20秒以确保有足够时间完成连接
 		time.Sleep(1 * time.Second)
-		fmt.Printf("[DEBUG] 第%d次检查连接状态\n", i+1)
+		debugf("第%d次检查连接状态", i+1)
 
 		// 使用多种方法检查连接状态
 		currentNetwork, err := w.GetCurrentNetwork()
 		if err != nil {
-			fmt.Printf("[DEBUG] 获取当前网络失败: %v\n", err)
+			debugf("获取当前网络失败: %v", err)
 			continue
 		}
 
-		fmt.Printf("[DEBUG] 当前网络: '%s', 目标网络: '%s'\n", currentNetwork, networkName)
+		debugf("当前网络: '%s', 目标网络: '%s'", currentNetwork, networkName)
 
 		// 检查是否已经连接到目标网络
 		if currentNetwork == networkName {
-			fmt.Printf("[DEBUG] 成功连接到目标网络\n")
+			debugf("成功连接到目标网络")
 			return nil // 连接成功
 		}
 
 		// 检查特殊情况：网络正在识别中，继续等待
 		if strings.Contains(currentNetwork, "正在识别") {
-			fmt.Printf("[DEBUG] 网络正在识别中，继续等待\n")
+			debugf("网络正在识别中，继续等待")
 			continue
 		}
 
-		// 检查网络名称是否部分匹配（处理可能的空格或特殊字符差异）
+		// 检查网络名称是否部分匹配（处理可能的空格或特殊字符差异），未连接时为空不算匹配
+		if currentNetwork == "" {
+			continue
+		}
 		if strings.Contains(strings.TrimSpace(currentNetwork), strings.TrimSpace(networkName)) ||
 			strings.Contains(strings.TrimSpace(networkName), strings.TrimSpace(currentNetwork)) {
-			fmt.Printf("[DEBUG] 网络名称部分匹配，认为连接成功\n")
+			debugf("网络名称部分匹配，认为连接成功")
 			return nil
 		}
 	}
@@ -386,7 +409,7 @@ func (w *WindowsConnector) IsEnabled() bool {
 	currentNetwork, err := w.GetCurrentNetwork()
 	if err == nil && currentNetwork != "" {
 		// 如果能获取到当前网络名称，说明WiFi已启用且已连接
-		fmt.Printf("WiFi已连接到网络: %s，判断为已启用\n", currentNetwork)
+		debugf("WiFi已连接到网络: %s，判断为已启用", currentNetwork)
 		return true
 	}
 
@@ -394,21 +417,21 @@ func (w *WindowsConnector) IsEnabled() bool {
 	command := fmt.Sprintf(`(Get-NetAdapter -Name "%s").Status`, w.interfaceName)
 	status, err := w.executePowerShellCommand(command)
 	if err != nil {
-		fmt.Printf("检查WiFi状态失败: %v\n", err)
+		log.Printf("检查WiFi状态失败: %v", err)
 		return false
 	}
 
-	fmt.Printf("WiFi接口 %s 状态: %s\n", w.interfaceName, status)
+	debugf("WiFi接口 %s 状态: %s", w.interfaceName, status)
 
 	// 检查状态是否为Up（启用）
 	if strings.Contains(strings.ToLower(status), "up") {
-		fmt.Printf("WiFi接口状态为Up，判断为已启用\n")
+		debugf("WiFi接口状态为Up，判断为已启用")
 		return true
 	}
 
 	// 检查是否为禁用状态
 	if strings.Contains(strings.ToLower(status), "disabled") || strings.Contains(strings.ToLower(status), "down") {
-		fmt.Printf("WiFi接口状态为禁用\n")
+		debugf("WiFi接口状态为禁用")
 		return false
 	}
 
@@ -416,12 +439,12 @@ func (w *WindowsConnector) IsEnabled() bool {
 	command2 := `netsh wlan show profiles | Select-String "All User Profile"`
 	profiles, err2 := w.executePowerShellCommand(command2)
 	if err2 == nil && profiles != "" {
-		fmt.Printf("能够获取WiFi配置文件，判断为已启用\n")
+		debugf("能够获取WiFi配置文件，判断为已启用")
 		return true
 	}
 
 	// 默认认为是启用的
-	fmt.Printf("无法确定状态，默认判断为已启用\n")
+	debugf("无法确定状态，默认判断为已启用")
 	return true
 }
 
@@ -445,10 +468,9 @@ func (w *WindowsConnector) GetIPAddress() (string, error) {
 		return "", fmt.Errorf("获取IP地址失败: %v", err)
 	}
 
-	// 清理IP地址字符串
-	ipAddr = strings.TrimSpace(ipAddr)
-	if ipAddr != "" && ipAddr != "127.0.0.1" {
-		return ipAddr, nil
+	// 接口可能同时有DHCP地址和APIPA地址（169.254.x.x），每行一个，优先使用非链路本地地址
+	if address := selectIPv4Address(strings.Fields(ipAddr)); address != "" {
+		return address, nil
 	}
 
 	// 备用方法：使用WMI查询
@@ -463,3 +485,472 @@ func (w *WindowsConnector) GetIPAddress() (string, error) {
 
 	return "", fmt.Errorf("未找到IP地址")
 }
+
+// GetIPv6Addresses 实现WiFiConnector接口 - 获取当前WiFi接口的IPv6地址
+func (w *WindowsConnector) GetIPv6Addresses(options IPv6Options) ([]string, error) {
+	// netsh会标注地址类型（Public/Temporary/Other），中文系统为（公用/临时/其他）
+	command := fmt.Sprintf(`netsh interface ipv6 show addresses interface="%s"`, w.interfaceName)
+	output, err := w.executePowerShellCommand(command)
+	if err != nil {
+		return nil, fmt.Errorf("获取IPv6地址失败: %v", err)
+	}
+
+	// 解析格式: Temporary  Preferred  6d23h59m  23h59m59s  2001:db8::1234
+	var candidates []ipv6Candidate
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(strings.TrimSpace(line))
+		if len(fields) < 2 || !strings.Contains(fields[len(fields)-1], ":") {
+			continue
+		}
+		candidates = append(candidates, ipv6Candidate{
+			address:   fields[len(fields)-1],
+			temporary: fields[0] == "Temporary" || fields[0] == "临时",
+		})
+	}
+
+	return selectIPv6Addresses(candidates, options), nil
+}
+
+// GetNetworkInfo 实现WiFiConnector接口 - 获取当前WiFi连接的详细网络信息
+func (w *WindowsConnector) GetNetworkInfo() (*NetworkInfo, error) {
+	info := &NetworkInfo{Interface: w.interfaceName}
+
+	// 无线链路信息，兼容英文和中文系统的输出
+	output, err := w.executePowerShellCommand(`netsh wlan show interfaces`)
+	if err != nil {
+		return nil, fmt.Errorf("获取无线接口信息失败: %v", err)
+	}
+	values := w.selectInterfaceBlock(output)
+	info.SSID = values["SSID"]
+	info.BSSID = strings.ToLower(firstValue(values, "AP BSSID", "BSSID"))
+	info.MACAddress = strings.ToLower(firstValue(values, "Physical address", "物理地址"))
+	if channel := parseLeadingInt(firstValue(values, "Channel", "信道")); channel > 0 {
+		info.Channel = channel
+		info.FrequencyMHz = channelToFrequency(channel)
+	}
+	if signal := firstValue(values, "Signal", "信号"); signal != "" {
+		info.SetSignalPercent(parseLeadingInt(signal))
+	}
+
+	// IP配置，输出格式: 键=值
+	command := fmt.Sprintf(`$c = Get-NetIPConfiguration -InterfaceAlias "%s"; `+
+		`"ip=$($c.IPv4Address[0].IPAddress)"; "prefix=$($c.IPv4Address[0].PrefixLength)"; `+
+		`"gateway=$($c.IPv4DefaultGateway[0].NextHop)"; `+
+		`"dns=$(($c.DNSServer | Where-Object { $_.AddressFamily -eq 2 } | ForEach-Object { $_.ServerAddresses }) -join ',')"`, w.interfaceName)
+	if ipConfig, err := w.executePowerShellCommand(command); err == nil {
+		config := parseKeyValueLines(ipConfig, "=")
+		info.IPAddress = config["ip"]
+		info.PrefixLength = parseLeadingInt(config["prefix"])
+		info.Gateway = config["gateway"]
+		for _, server := range strings.Split(config["dns"], ",") {
+			if server = strings.TrimSpace(server); server != "" {
+				info.DNSServers = append(info.DNSServers, server)
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// selectInterfaceBlock 从 netsh wlan show interfaces 的输出中选择当前接口对应的信息块
+func (w *WindowsConnector) selectInterfaceBlock(output string) map[string]string {
+	var blocks []string
+	var current []string
+	for _, line := range strings.Split(output, "\n") {
+		key := line
+		if index := strings.Index(line, ":"); index != -1 {
+			key = line[:index]
+		}
+		key = strings.TrimSpace(key)
+		// 每个接口的信息块以名称行开始
+		if (key == "Name" || key == "名称") && len(current) > 0 {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			current = nil
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+
+	var first map[string]string
+	for _, block := range blocks {
+		values := parseKeyValueLines(block, ":")
+		name := firstValue(values, "Name", "名称")
+		if name == "" {
+			continue
+		}
+		if name == w.interfaceName {
+			return values
+		}
+		if first == nil {
+			first = values
+		}
+	}
+	if first == nil {
+		return parseKeyValueLines(output, ":")
+	}
+	return first
+}
+
+// Scan 实现WiFiConnector接口 - 扫描附近可见的WiFi网络
+func (w *WindowsConnector) Scan() ([]ScanResult, error) {
+	output, err := w.executePowerShellCommand(fmt.Sprintf(`netsh wlan show networks mode=bssid interface="%s"`, w.interfaceName))
+	if err != nil {
+		return nil, fmt.Errorf("扫描WiFi网络失败: %v", err)
+	}
+	return parseNetshNetworks(output), nil
+}
+
+// parseNetshNetworks 解析 netsh wlan show networks mode=bssid 的输出，兼容英文和中文系统，格式:
+// SSID 1 : MyWiFi
+//
+//	Authentication          : WPA2-Personal
+//	BSSID 1                 : aa:bb:cc:dd:ee:ff
+//	     Signal             : 90%
+//	     Band               : 5 GHz
+//	     Channel            : 149
+func parseNetshNetworks(output string) []ScanResult {
+	var results []ScanResult
+	var ssid, security string
+	var current *ScanResult
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		name, _, _ := strings.Cut(key, " ")
+
+		switch {
+		case name == "SSID":
+			ssid, security, current = value, "", nil
+		case key == "Authentication" || key == "身份验证":
+			security = value
+		case name == "BSSID":
+			results = append(results, ScanResult{SSID: ssid, BSSID: strings.ToLower(value), Security: security})
+			current = &results[len(results)-1]
+		case current == nil:
+		case key == "Signal" || key == "信号":
+			current.SetSignalPercent(parseLeadingInt(value))
+		case key == "Band" || key == "频带" || key == "波段":
+			current.Band = strings.ReplaceAll(value, " ", "")
+		case key == "Channel" || key == "信道" || key == "通道":
+			channel := parseLeadingInt(value)
+			if current.Band == "6GHz" {
+				current.FrequencyMHz = 5950 + channel*5
+			}
+			current.SetChannel(channel)
+		}
+	}
+	return results
+}
+
+// ConnectBSSID 实现WiFiConnector接口 - netsh不支持指定接入点，断开重连也由系统选择接入点，不予切换
+func (w *WindowsConnector) ConnectBSSID(networkName, bssid, password string) error {
+	return ErrBSSIDUnsupported
+}
+
+// buildWLANProfile 生成netsh wlan add profile使用的WLANProfile配置文件
+func buildWLANProfile(networkName, password string, options ConnectOptions) (string, error) {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?>
+<WLANProfile xmlns="http://www.microsoft.com/networking/WLAN/profile/v1">
+	<name>` + xmlEscape(networkName) + `</name>
+	<SSIDConfig>
+		<SSID>
+			<name>` + xmlEscape(networkName) + `</name>
+		</SSID>
+`)
+	if options.Hidden {
+		b.WriteString("\t\t<nonBroadcast>true</nonBroadcast>\n")
+	}
+	b.WriteString(`	</SSIDConfig>
+	<connectionType>ESS</connectionType>
+	<connectionMode>auto</connectionMode>
+	<MSM>
+		<security>
+			<authEncryption>
+`)
+	security := options.ProfileSecurity()
+	if options.Security == SecurityAuto && security == SecurityWPA2WPA3 {
+		// 扫描判断出的过渡模式按WPA2写入，transitionMode只在Windows 11及以上支持，仅在明确指定时使用
+		security = SecurityWPA2PSK
+	}
+	switch {
+	case options.Enterprise != nil:
+		eapConfig, err := buildEAPConfig(options.Enterprise)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(`				<authentication>WPA2</authentication>
+				<encryption>AES</encryption>
+				<useOneX>true</useOneX>
+			</authEncryption>
+			<OneX xmlns="http://www.microsoft.com/networking/OneX/v1">
+				<authMode>user</authMode>
+				<EAPConfig>` + eapConfig + `</EAPConfig>
+			</OneX>
+`)
+	case security == SecurityOpen || (security == SecurityAuto && password == ""):
+		b.WriteString(`				<authentication>open</authentication>
+				<encryption>none</encryption>
+				<useOneX>false</useOneX>
+			</authEncryption>
+`)
+	default:
+		if password == "" {
+			return "", fmt.Errorf("安全类型 %s 需要WiFi密码", security)
+		}
+		switch security {
+		case SecurityWPA3SAE, SecurityWPA2WPA3:
+			b.WriteString("\t\t\t\t<authentication>WPA3SAE</authentication>\n")
+		default:
+			b.WriteString("\t\t\t\t<authentication>WPA2PSK</authentication>\n")
+		}
+		b.WriteString(`				<encryption>AES</encryption>
+				<useOneX>false</useOneX>
+`)
+		if security == SecurityWPA2WPA3 {
+			// 过渡模式需要WLANProfile v4的transitionMode，Windows 11及以上支持
+			b.WriteString("\t\t\t\t<transitionMode xmlns=\"http://www.microsoft.com/networking/WLAN/profile/v4\">true</transitionMode>\n")
+		}
+		b.WriteString(`			</authEncryption>
+			<sharedKey>
+				<keyType>passPhrase</keyType>
+				<protected>false</protected>
+				<keyMaterial>` + xmlEscape(password) + `</keyMaterial>
+			</sharedKey>
+`)
+	}
+	b.WriteString(`		</security>
+	</MSM>
+</WLANProfile>`)
+	return b.String(), nil
+}
+
+// buildEAPConfig 生成WLANProfile中的EapHostConfig，支持PEAP-MSCHAPv2和EAP-TLS
+func buildEAPConfig(creds *EnterpriseCredentials) (string, error) {
+	// 服务器证书校验：配置了CA证书时只信任该CA
+	var trustedRoot string
+	if creds.CACert != "" {
+		der, err := readCertificateDER(creds.CACert)
+		if err != nil {
+			return "", err
+		}
+		trustedRoot = "<TrustedRootCA>" + certificateThumbprint(der) + "</TrustedRootCA>"
+	}
+	serverValidation := "<ServerValidation><DisableUserPromptForServerValidation>false</DisableUserPromptForServerValidation>" +
+		"<ServerNames>" + xmlEscape(creds.ServerName) + "</ServerNames>" + trustedRoot + "</ServerValidation>"
+
+	if err := validateWindowsEAP(creds); err != nil {
+		return "", err
+	}
+	var eapType int
+	var eap string
+	switch creds.EAPMethod {
+	case EAPPEAP:
+		eapType = 25
+		eap = `<Eap xmlns="http://www.microsoft.com/provisioning/BaseEapConnectionPropertiesV1"><Type>25</Type>` +
+			`<EapType xmlns="http://www.microsoft.com/provisioning/MsPeapConnectionPropertiesV1">` + serverValidation +
+			`<FastReconnect>true</FastReconnect><InnerEapOptional>false</InnerEapOptional>` +
+			`<Eap xmlns="http://www.microsoft.com/provisioning/BaseEapConnectionPropertiesV1"><Type>26</Type>` +
+			`<EapType xmlns="http://www.microsoft.com/provisioning/MsChapV2ConnectionPropertiesV1"><UseWinLogonCredentials>false</UseWinLogonCredentials></EapType></Eap>` +
+			`<EnableQuarantineChecks>false</EnableQuarantineChecks><RequireCryptoBinding>false</RequireCryptoBinding>` +
+			`<PeapExtensions><PerformServerValidation xmlns="http://www.microsoft.com/provisioning/MsPeapConnectionPropertiesV2">` +
+			fmt.Sprint(creds.CACert != "" || creds.ServerName != "") + `</PerformServerValidation>` +
+			`<AcceptServerName xmlns="http://www.microsoft.com/provisioning/MsPeapConnectionPropertiesV2">` +
+			fmt.Sprint(creds.ServerName != "") + `</AcceptServerName></PeapExtensions></EapType></Eap>`
+	case EAPTLS:
+		eapType = 13
+		eap = `<Eap xmlns="http://www.microsoft.com/provisioning/BaseEapConnectionPropertiesV1"><Type>13</Type>` +
+			`<EapType xmlns="http://www.microsoft.com/provisioning/EapTlsConnectionPropertiesV1">` +
+			`<CredentialsSource><CertificateStore><SimpleCertSelection>true</SimpleCertSelection></CertificateStore></CredentialsSource>` +
+			serverValidation + `<DifferentUsername>false</DifferentUsername></EapType></Eap>`
+	}
+
+	return `<EapHostConfig xmlns="http://www.microsoft.com/provisioning/EapHostConfig">` +
+		`<EapMethod><Type xmlns="http://www.microsoft.com/provisioning/EapCommon">` + fmt.Sprint(eapType) + `</Type>` +
+		`<VendorId xmlns="http://www.microsoft.com/provisioning/EapCommon">0</VendorId>` +
+		`<VendorType xmlns="http://www.microsoft.com/provisioning/EapCommon">0</VendorType>` +
+		`<AuthorId xmlns="http://www.microsoft.com/provisioning/EapCommon">0</AuthorId></EapMethod>` +
+		`<Config xmlns="http://www.microsoft.com/provisioning/EapHostConfig">` + eap + `</Config></EapHostConfig>`, nil
+}
+
+// validateWindowsEAP 检查Windows能否自动配置该企业级认证方式，加载网络配置时即检查，不等到连接时才失败
+func validateWindowsEAP(creds *EnterpriseCredentials) error {
+	switch creds.EAPMethod {
+	case EAPPEAP:
+		if creds.InnerMethod() != "mschapv2" {
+			return fmt.Errorf("Windows的PEAP只支持mschapv2内层认证，不支持: %s", creds.Phase2)
+		}
+	case EAPTLS:
+	default:
+		return fmt.Errorf("Windows暂不支持自动配置EAP认证方式: %s", creds.EAPMethod)
+	}
+	return nil
+}
+
+// buildEAPUserData 生成PEAP-MSCHAPv2的用户凭据XML，用于WlanSetProfileEapXmlUserData
+func buildEAPUserData(creds *EnterpriseCredentials, password string) string {
+	if creds.Password != "" {
+		password = creds.Password
+	}
+	return `<EapHostUserCredentials xmlns="http://www.microsoft.com/provisioning/EapHostUserCredentials" ` +
+		`xmlns:eapCommon="http://www.microsoft.com/provisioning/EapCommon" ` +
+		`xmlns:baseEap="http://www.microsoft.com/provisioning/BaseEapMethodUserCredentials">` +
+		`<EapMethod><eapCommon:Type>25</eapCommon:Type><eapCommon:AuthorId>0</eapCommon:AuthorId></EapMethod>` +
+		`<Credentials xmlns:eapUser="http://www.microsoft.com/provisioning/EapUserPropertiesV1" ` +
+		`xmlns:baseEap="http://www.microsoft.com/provisioning/BaseEapUserPropertiesV1" ` +
+		`xmlns:MsPeap="http://www.microsoft.com/provisioning/MsPeapUserPropertiesV1" ` +
+		`xmlns:MsChapV2="http://www.microsoft.com/provisioning/MsChapV2UserPropertiesV1">` +
+		`<baseEap:Eap><baseEap:Type>25</baseEap:Type><MsPeap:EapType>` +
+		`<MsPeap:RoutingIdentity>` + xmlEscape(creds.OuterIdentity()) + `</MsPeap:RoutingIdentity>` +
+		`<baseEap:Eap><baseEap:Type>26</baseEap:Type><MsChapV2:EapType>` +
+		`<MsChapV2:Username>` + xmlEscape(creds.Identity) + `</MsChapV2:Username>` +
+		`<MsChapV2:Password>` + xmlEscape(password) + `</MsChapV2:Password>` +
+		`<MsChapV2:LogonDomain></MsChapV2:LogonDomain></MsChapV2:EapType></baseEap:Eap>` +
+		`</MsPeap:EapType></baseEap:Eap></Credentials></EapHostUserCredentials>`
+}
+
+// wlanAPIDefinition 调用wlanapi.dll写入EAP用户凭据的P/Invoke声明，netsh不支持设置企业级网络的用户名密码
+const wlanAPIDefinition = `[DllImport("wlanapi.dll")] public static extern uint WlanOpenHandle(uint dwClientVersion, IntPtr pReserved, out uint pdwNegotiatedVersion, out IntPtr phClientHandle);
+[DllImport("wlanapi.dll")] public static extern uint WlanCloseHandle(IntPtr hClientHandle, IntPtr pReserved);
+[DllImport("wlanapi.dll", CharSet = CharSet.Unicode)] public static extern uint WlanSetProfileEapXmlUserData(IntPtr hClientHandle, ref Guid pInterfaceGuid, string strProfileName, uint dwFlags, string strEapXmlUserData, IntPtr pReserved);`
+
+// enterpriseSetupCommand 生成导入证书、写入配置文件和用户凭据的PowerShell命令
+func (w *WindowsConnector) enterpriseSetupCommand(networkName, profile, password string, creds *EnterpriseCredentials) string {
+	var commands []string
+	if creds.CACert != "" {
+		commands = append(commands, fmt.Sprintf(`Import-Certificate -FilePath '%s' -CertStoreLocation Cert:\LocalMachine\Root | Out-Null`,
+			powerShellQuote(creds.CACert)))
+	}
+	if creds.EAPMethod == EAPTLS {
+		commands = append(commands, fmt.Sprintf(`Import-PfxCertificate -FilePath '%s' -CertStoreLocation Cert:\CurrentUser\My -Password (ConvertTo-SecureString '%s' -AsPlainText -Force) | Out-Null`,
+			powerShellQuote(creds.ClientCert), powerShellQuote(creds.PrivateKeyPassword)))
+	}
+	commands = append(commands, addWLANProfileCommand(profile))
+
+	if creds.EAPMethod == EAPPEAP {
+		commands = append(commands,
+			"Add-Type -Namespace WlanApi -Name Native -MemberDefinition @'\n"+wlanAPIDefinition+"\n'@",
+			"$userData = @'\n"+buildEAPUserData(creds, password)+"\n'@",
+			fmt.Sprintf(`$guid = [Guid](Get-NetAdapter -Name '%s').InterfaceGuid`, powerShellQuote(w.interfaceName)),
+			`$version = 0; $handle = [IntPtr]::Zero; [WlanApi.Native]::WlanOpenHandle(2, [IntPtr]::Zero, [ref]$version, [ref]$handle) | Out-Null`,
+			fmt.Sprintf(`$result = [WlanApi.Native]::WlanSetProfileEapXmlUserData($handle, [ref]$guid, '%s', 1, $userData, [IntPtr]::Zero)`, powerShellQuote(networkName)),
+			`[WlanApi.Native]::WlanCloseHandle($handle, [IntPtr]::Zero) | Out-Null`,
+			`if ($result -ne 0) { throw "WlanSetProfileEapXmlUserData failed: $result" }`,
+		)
+	}
+	return strings.Join(commands, "; ")
+}
+
+// powerShellQuote 转义PowerShell单引号字符串中的单引号
+func powerShellQuote(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+// addWLANProfileCommand 生成写入并导入WLANProfile配置文件的PowerShell命令，使用单引号here-string避免内容被展开
+func addWLANProfileCommand(profile string) string {
+	return "$xml = @'\n" + profile + "\n'@; " +
+		`$xml | Out-File -FilePath "$env:TEMP\wifi_profile.xml" -Encoding UTF8; netsh wlan add profile filename="$env:TEMP\wifi_profile.xml"`
+}
+
+// xmlEscape 转义XML文本中的特殊字符
+func xmlEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// ApplyIPConfig 实现WiFiConnector接口 - 通过netsh设置WiFi接口的IP和DNS
+func (w *WindowsConnector) ApplyIPConfig(config *IPConfig) error {
+	if _, err := w.executePowerShellCommand(netshIPConfigCommand(w.interfaceName, config)); err != nil {
+		return fmt.Errorf("设置IP配置失败: %v", err)
+	}
+	return nil
+}
+
+// netshIPConfigCommand 生成设置接口IP、DNS和搜索域的PowerShell命令
+func netshIPConfigCommand(interfaceName string, config *IPConfig) string {
+	var commands []string
+	if config.IsStatic() {
+		address := fmt.Sprintf(`netsh interface ip set address name="%s" static %s %s`, interfaceName, config.IP(), config.SubnetMask())
+		if config.Gateway != "" {
+			address += " " + config.Gateway
+		}
+		commands = append(commands, address)
+	} else {
+		commands = append(commands, fmt.Sprintf(`netsh interface ip set address name="%s" dhcp`, interfaceName))
+	}
+
+	if len(config.DNS) == 0 {
+		commands = append(commands, fmt.Sprintf(`netsh interface ip set dnsservers name="%s" dhcp`, interfaceName))
+	}
+	for i, server := range config.DNS {
+		if i == 0 {
+			commands = append(commands, fmt.Sprintf(`netsh interface ip set dnsservers name="%s" static %s primary validate=no`, interfaceName, server))
+		} else {
+			commands = append(commands, fmt.Sprintf(`netsh interface ip add dnsservers name="%s" %s index=%d validate=no`, interfaceName, server, i+1))
+		}
+	}
+
+	// Windows每个接口只能设置一个连接专用后缀，多个搜索域时使用第一个
+	suffix := ""
+	if len(config.SearchDomains) > 0 {
+		suffix = config.SearchDomains[0]
+	}
+	commands = append(commands, fmt.Sprintf(`Set-DnsClient -InterfaceAlias '%s' -ConnectionSpecificSuffix '%s'`,
+		powerShellQuote(interfaceName), powerShellQuote(suffix)))
+	return strings.Join(commands, "; ")
+}
+
+// RenewDHCP 实现WiFiConnector接口 - 重新申请DHCP租约
+func (w *WindowsConnector) RenewDHCP() error {
+	command := fmt.Sprintf(`ipconfig /renew "%s"`, w.interfaceName)
+	if _, err := w.executePowerShellCommand(command); err != nil {
+		return fmt.Errorf("续租DHCP失败: %v", err)
+	}
+	return nil
+}
+
+// GetWiredInterfaces 实现WiFiConnector接口 - 查找已连接的物理以太网网卡
+func (w *WindowsConnector) GetWiredInterfaces() ([]WiredInterface, error) {
+	// NdisPhysicalMedium为14表示802.3以太网，输出格式: 名称|IP地址|网关
+	command := `Get-NetAdapter -Physical | Where-Object { $_.Status -eq 'Up' -and $_.NdisPhysicalMedium -eq 14 } | ForEach-Object { ` +
+		`$ip = (Get-NetIPAddress -InterfaceIndex $_.ifIndex -AddressFamily IPv4 -ErrorAction SilentlyContinue).IPAddress -join ','; ` +
+		`$gw = (Get-NetRoute -InterfaceIndex $_.ifIndex -DestinationPrefix '0.0.0.0/0' -ErrorAction SilentlyContinue | Select-Object -First 1).NextHop; ` +
+		`"$($_.Name)|$ip|$gw" }`
+	output, err := w.executePowerShellCommand(command)
+	if err != nil {
+		return nil, fmt.Errorf("获取有线网卡失败: %v", err)
+	}
+
+	var interfaces []WiredInterface
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "|")
+		if len(parts) != 3 || parts[0] == "" {
+			continue
+		}
+		address := selectIPv4Address(strings.Split(parts[1], ","))
+		if address == "" || isLinkLocalIPv4(address) {
+			continue
+		}
+		interfaces = append(interfaces, WiredInterface{Name: parts[0], IPAddress: address, Gateway: parts[2]})
+	}
+	return interfaces, nil
+}
+
+// GetDefaultRoute 实现WiFiConnector接口 - 获取路由度量与接口度量之和最小的默认路由
+func (w *WindowsConnector) GetDefaultRoute() (string, string, error) {
+	command := `Get-NetRoute -DestinationPrefix '0.0.0.0/0' -ErrorAction SilentlyContinue | ` +
+		`Sort-Object { $_.RouteMetric + (Get-NetIPInterface -InterfaceIndex $_.ifIndex -AddressFamily IPv4).InterfaceMetric } | ` +
+		`Select-Object -First 1 | ForEach-Object { "$($_.InterfaceAlias)|$($_.NextHop)" }`
+	output, err := w.executePowerShellCommand(command)
+	if err != nil {
+		return "", "", fmt.Errorf("获取默认路由失败: %v", err)
+	}
+	iface, gateway, _ := strings.Cut(strings.TrimSpace(output), "|")
+	return iface, gateway, nil
+}
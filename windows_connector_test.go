@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/pem"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestCACert 写入一个PEM格式的测试CA证书，返回文件路径和证书内容
+func writeTestCACert(t *testing.T) (string, []byte) {
+	t.Helper()
+	der := []byte("test ca certificate")
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("写入测试证书失败: %v", err)
+	}
+	return path, der
+}
+
+func TestBuildWLANProfileEnterprise(t *testing.T) {
+	caCert, der := writeTestCACert(t)
+	tests := []struct {
+		name     string
+		creds    EnterpriseCredentials
+		contains []string
+		wantErr  string
+	}{
+		{
+			name:  "PEAP-MSCHAPv2",
+			creds: EnterpriseCredentials{EAPMethod: EAPPEAP, Identity: "alice", CACert: caCert, ServerName: "radius.example.com"},
+			contains: []string{
+				"<useOneX>true</useOneX>",
+				`<Type xmlns="http://www.microsoft.com/provisioning/EapCommon">25</Type>`,
+				"<Type>26</Type>",
+				"<ServerNames>radius.example.com</ServerNames>",
+				"<TrustedRootCA>" + certificateThumbprint(der) + "</TrustedRootCA>",
+				`<PerformServerValidation xmlns="http://www.microsoft.com/provisioning/MsPeapConnectionPropertiesV2">true</PerformServerValidation>`,
+			},
+		},
+		{
+			name:  "EAP-TLS",
+			creds: EnterpriseCredentials{EAPMethod: EAPTLS, Identity: "alice", ClientCert: "alice.pfx"},
+			contains: []string{
+				`<Type xmlns="http://www.microsoft.com/provisioning/EapCommon">13</Type>`,
+				"<SimpleCertSelection>true</SimpleCertSelection>",
+				"<ServerNames></ServerNames>",
+			},
+		},
+		{
+			name:    "EAP-TTLS不支持",
+			creds:   EnterpriseCredentials{EAPMethod: EAPTTLS, Identity: "alice", Phase2: "pap"},
+			wantErr: "不支持自动配置EAP认证方式",
+		},
+		{
+			name:    "PEAP-GTC不支持",
+			creds:   EnterpriseCredentials{EAPMethod: EAPPEAP, Identity: "alice", Phase2: "gtc"},
+			wantErr: "只支持mschapv2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := buildWLANProfile("Corp", "", ConnectOptions{Enterprise: &tt.creds})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+				if err := validateWindowsEAP(&tt.creds); err == nil {
+					t.Fatalf("validateWindowsEAP() 应拒绝该配置")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildWLANProfile() 失败: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(profile, want) {
+					t.Errorf("配置文件中缺少 %s", want)
+				}
+			}
+			if err := xml.Unmarshal([]byte(profile), new(struct{})); err != nil {
+				t.Errorf("配置文件不是有效的XML: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildWLANProfileEscapesSSID(t *testing.T) {
+	ssid := `Tom & Jerry's <"5G">`
+	password := `p&ss<w>rd"`
+	profile, err := buildWLANProfile(ssid, password, ConnectOptions{Security: SecurityWPA2PSK, Hidden: true})
+	if err != nil {
+		t.Fatalf("buildWLANProfile() 失败: %v", err)
+	}
+	var parsed struct {
+		Name string `xml:"name"`
+		SSID struct {
+			Name         string `xml:"SSID>name"`
+			NonBroadcast bool   `xml:"nonBroadcast"`
+		} `xml:"SSIDConfig"`
+		Authentication string `xml:"MSM>security>authEncryption>authentication"`
+		KeyMaterial    string `xml:"MSM>security>sharedKey>keyMaterial"`
+	}
+	if err := xml.Unmarshal([]byte(profile), &parsed); err != nil {
+		t.Fatalf("配置文件不是有效的XML: %v\n%s", err, profile)
+	}
+	if parsed.Name != ssid || parsed.SSID.Name != ssid {
+		t.Errorf("SSID = %q / %q，期望 %q", parsed.Name, parsed.SSID.Name, ssid)
+	}
+	if parsed.KeyMaterial != password {
+		t.Errorf("密码 = %q，期望 %q", parsed.KeyMaterial, password)
+	}
+	if !parsed.SSID.NonBroadcast || parsed.Authentication != "WPA2PSK" {
+		t.Errorf("nonBroadcast = %v，authentication = %s", parsed.SSID.NonBroadcast, parsed.Authentication)
+	}
+	if got := xmlEscape(`&<>"`); got != "&amp;&lt;&gt;&#34;" {
+		t.Errorf("xmlEscape() = %s", got)
+	}
+}
+
+func TestBuildWLANProfileSecurity(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        ConnectOptions
+		authentication string
+		transitionMode bool
+	}{
+		{"扫描判断出过渡模式时按WPA2写入", ConnectOptions{Detected: SecurityWPA2WPA3}, "WPA2PSK", false},
+		{"扫描判断出WPA3", ConnectOptions{Detected: SecurityWPA3SAE}, "WPA3SAE", false},
+		{"明确指定过渡模式", ConnectOptions{Security: SecurityWPA2WPA3}, "WPA3SAE", true},
+		{"未判断出安全类型", ConnectOptions{}, "WPA2PSK", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := buildWLANProfile("Office", "secret", tt.options)
+			if err != nil {
+				t.Fatalf("buildWLANProfile() 失败: %v", err)
+			}
+			var parsed struct {
+				Authentication string `xml:"MSM>security>authEncryption>authentication"`
+			}
+			if err := xml.Unmarshal([]byte(profile), &parsed); err != nil {
+				t.Fatalf("配置文件不是有效的XML: %v\n%s", err, profile)
+			}
+			if parsed.Authentication != tt.authentication {
+				t.Errorf("authentication = %s，期望 %s", parsed.Authentication, tt.authentication)
+			}
+			if got := strings.Contains(profile, "transitionMode"); got != tt.transitionMode {
+				t.Errorf("包含transitionMode = %v，期望 %v", got, tt.transitionMode)
+			}
+		})
+	}
+}
+
+func TestBuildEAPUserDataEscapes(t *testing.T) {
+	creds := &EnterpriseCredentials{EAPMethod: EAPPEAP, Identity: `corp\a&b`, AnonymousIdentity: "anon<1>"}
+	data := buildEAPUserData(creds, `pa"ss`)
+	for _, want := range []string{
+		"<MsPeap:RoutingIdentity>anon&lt;1&gt;</MsPeap:RoutingIdentity>",
+		`<MsChapV2:Username>corp\a&amp;b</MsChapV2:Username>`,
+		"<MsChapV2:Password>pa&#34;ss</MsChapV2:Password>",
+	} {
+		if !strings.Contains(data, want) {
+			t.Errorf("用户凭据中缺少 %s", want)
+		}
+	}
+}
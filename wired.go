@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// WiredPolicy 有线网络已连接时对WiFi的处理策略
+type WiredPolicy string
+
+const (
+	// WiredPolicyOff 不检测有线网络，始终管理WiFi（默认）
+	WiredPolicyOff WiredPolicy = "off"
+	// WiredPolicyIgnore 有线网络已连接时不管理WiFi，也不上报地址
+	WiredPolicyIgnore WiredPolicy = "ignore"
+	// WiredPolicyReport 有线网络已连接时不管理WiFi，上报有线网络地址
+	WiredPolicyReport WiredPolicy = "report"
+	// WiredPolicyStandby 有线网络已连接时仍保持WiFi连接作为备用，上报有线网络地址
+	WiredPolicyStandby WiredPolicy = "standby"
+)
+
+// ParseWiredPolicy 解析有线网络策略
+func ParseWiredPolicy(value string) (WiredPolicy, error) {
+	switch policy := WiredPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "", WiredPolicyOff:
+		return WiredPolicyOff, nil
+	case WiredPolicyIgnore, WiredPolicyReport, WiredPolicyStandby:
+		return policy, nil
+	default:
+		return WiredPolicyOff, fmt.Errorf("不支持的有线网络策略: %s（可选 off、ignore、report、standby）", value)
+	}
+}
+
+// ReportsWired 有线网络已连接时是否上报有线网络地址
+func (p WiredPolicy) ReportsWired() bool {
+	return p == WiredPolicyReport || p == WiredPolicyStandby
+}
+
+// WiredInterface 一个已连接并获取到地址的有线网络接口
+type WiredInterface struct {
+	Name      string `json:"name"`
+	IPAddress string `json:"ip_address"`
+	// Gateway 该接口上的默认网关，没有默认路由时为空
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// NetworkInfo 返回有线接口的网络信息
+func (w *WiredInterface) NetworkInfo(defaultRoute string) *NetworkInfo {
+	return &NetworkInfo{Interface: w.Name, IPAddress: w.IPAddress, Gateway: w.Gateway, DefaultRoute: defaultRoute}
+}
+
+// selectWiredInterface 选择用于上报的有线接口，优先选择承载默认路由的接口
+func selectWiredInterface(interfaces []WiredInterface, defaultRoute string) *WiredInterface {
+	if len(interfaces) == 0 {
+		return nil
+	}
+	for i := range interfaces {
+		if interfaces[i].Name == defaultRoute {
+			return &interfaces[i]
+		}
+	}
+	return &interfaces[0]
+}
+
+// checkWired 检测有线网络连接状态，状态变化时记录历史并发送通知，返回当前使用的有线接口。
+// 管理多个WiFi网卡时只由主监控器检测
+func (m *Monitor) checkWired() *WiredInterface {
+	if wiredPolicy == WiredPolicyOff || !m.primary {
+		return nil
+	}
+	interfaces, err := m.connector.GetWiredInterfaces()
+	if err != nil {
+		log.Printf("检测有线网络失败: %v", err)
+		return m.wired
+	}
+	defaultRoute, _, err := m.connector.GetDefaultRoute()
+	if err != nil {
+		log.Printf("获取默认路由失败: %v", err)
+	}
+	m.defaultRoute = defaultRoute
+
+	wired := selectWiredInterface(interfaces, defaultRoute)
+	previous := ""
+	if m.wired != nil {
+		previous = m.wired.Name
+	}
+	current := ""
+	if wired != nil {
+		current = wired.Name
+	}
+	m.wired = wired
+	if previous == current {
+		return wired
+	}
+
+	m.record(HistoryEvent{Type: HistoryEventWired, Interface: current, From: previous, To: current})
+	if wired != nil {
+		log.Printf("有线网络已连接: %s (%s)，默认路由接口: %s", wired.Name, wired.IPAddress, defaultRoute)
+	} else {
+		log.Printf("有线网络已断开: %s，默认路由接口: %s", previous, defaultRoute)
+	}
+	if enableNotification && feishuNotifier != nil {
+		feishuNotifier.SendWiredChangeNotificationAsync(wired, previous, defaultRoute, m.target, wiredPolicy)
+	}
+	return wired
+}
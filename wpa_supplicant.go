@@ -0,0 +1,456 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultWPACtrlDirs wpa_supplicant控制套接字的常见目录（ctrl_interface）
+var defaultWPACtrlDirs = []string{"/run/wpa_supplicant", "/var/run/wpa_supplicant"}
+
+// wpaRequestCounter 生成本地套接字名称，同一进程的并发请求互不影响
+var wpaRequestCounter atomic.Uint64
+
+// WPAControl wpa_supplicant控制接口客户端，通过Unix数据报套接字发送命令
+type WPAControl struct {
+	// path 网卡对应的控制套接字路径，如 /run/wpa_supplicant/wlan0
+	path    string
+	timeout time.Duration
+}
+
+// NewWPAControl 创建wpa_supplicant控制接口客户端
+func NewWPAControl(path string) *WPAControl {
+	return &WPAControl{path: path, timeout: 5 * time.Second}
+}
+
+// wpaControlPath 返回网卡的控制套接字路径，指定了 -wpa-ctrl 时使用指定目录，否则在常见目录中查找
+func wpaControlPath(interfaceName string) string {
+	if wpaCtrlDir != "" {
+		return filepath.Join(wpaCtrlDir, interfaceName)
+	}
+	for _, dir := range defaultWPACtrlDirs {
+		path := filepath.Join(dir, interfaceName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(defaultWPACtrlDirs[0], interfaceName)
+}
+
+// Request 发送一条命令并返回去掉末尾换行的回复
+func (c *WPAControl) Request(command string) (string, error) {
+	// 与wpa_cli相同，客户端需要绑定本地地址才能收到回复
+	local := filepath.Join(os.TempDir(), fmt.Sprintf("connect_wpa_%d-%d", os.Getpid(), wpaRequestCounter.Add(1)))
+	os.Remove(local)
+	conn, err := net.DialUnix("unixgram",
+		&net.UnixAddr{Name: local, Net: "unixgram"},
+		&net.UnixAddr{Name: c.path, Net: "unixgram"})
+	if err != nil {
+		return "", fmt.Errorf("连接wpa_supplicant控制接口失败: %v", err)
+	}
+	defer os.Remove(local)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := conn.Write([]byte(command)); err != nil {
+		return "", fmt.Errorf("发送wpa_supplicant命令失败: %v", err)
+	}
+	buf := make([]byte, 65536)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return "", fmt.Errorf("读取wpa_supplicant回复失败: %v", err)
+		}
+		reply := string(buf[:n])
+		// 以<开头的是事件消息（如 <3>CTRL-EVENT-...），跳过
+		if strings.HasPrefix(reply, "<") {
+			continue
+		}
+		return strings.TrimRight(reply, "\n"), nil
+	}
+}
+
+// Command 发送一条只需返回OK的命令
+func (c *WPAControl) Command(command string) error {
+	reply, err := c.Request(command)
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		name, _, _ := strings.Cut(command, " ")
+		return fmt.Errorf("wpa_supplicant执行%s失败: %s", name, reply)
+	}
+	return nil
+}
+
+// Status 执行STATUS命令，返回 键=值 形式的状态
+func (c *WPAControl) Status() (map[string]string, error) {
+	reply, err := c.Request("STATUS")
+	if err != nil {
+		return nil, err
+	}
+	return parseWPAKeyValues(reply), nil
+}
+
+// parseWPAKeyValues 解析 键=值 形式的多行回复
+func parseWPAKeyValues(reply string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(reply, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// WPASupplicantConnector 直接通过wpa_supplicant控制接口连接WiFi的Linux连接器，适用于没有NetworkManager的系统。
+// 连接、扫描和切换接入点使用控制接口，地址、网络信息和有线网络检测与nmcli后端相同
+type WPASupplicantConnector struct {
+	*LinuxConnector
+	// applied 本进程设置过的网络参数（键为 网络ID/网络名称/参数名），用于比较无法读回的密码等参数
+	applied map[string]string
+}
+
+// NewWPASupplicantConnector 创建使用wpa_supplicant控制接口的Linux连接器
+func NewWPASupplicantConnector(connector *LinuxConnector) *WPASupplicantConnector {
+	return &WPASupplicantConnector{LinuxConnector: connector, applied: make(map[string]string)}
+}
+
+// control 返回当前网卡的控制接口客户端，网卡重新插入后控制套接字会重新创建
+func (w *WPASupplicantConnector) control() *WPAControl {
+	return NewWPAControl(wpaControlPath(w.interfaceName))
+}
+
+// GetCurrentNetwork 实现WiFiConnector接口 - 只有完成关联和认证时才返回网络名称
+func (w *WPASupplicantConnector) GetCurrentNetwork() (string, error) {
+	status, err := w.control().Status()
+	if err != nil {
+		return "", err
+	}
+	if status["wpa_state"] != "COMPLETED" {
+		return "", nil
+	}
+	return status["ssid"], nil
+}
+
+// GetNetworkInfo 实现WiFiConnector接口 - 网络名称从控制接口获取，不依赖iwgetid和nmcli
+func (w *WPASupplicantConnector) GetNetworkInfo() (*NetworkInfo, error) {
+	ssid, err := w.GetCurrentNetwork()
+	if err != nil {
+		return nil, err
+	}
+	return w.networkInfo(ssid), nil
+}
+
+// Connect 实现WiFiConnector接口 - 没有已保存的配置时添加网络配置，提供了密码或认证配置时只修改已保存配置中变化的参数
+func (w *WPASupplicantConnector) Connect(networkName, password string, options ConnectOptions) error {
+	ctrl := w.control()
+	id, err := w.findNetwork(ctrl, networkName)
+	if err != nil {
+		return err
+	}
+	changed := false
+	if id == "" || password != "" || options.Security != SecurityAuto || options.Enterprise != nil {
+		settings, err := wpaNetworkSettings(networkName, password, options)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			if id, err = w.addNetwork(ctrl, networkName, settings); err != nil {
+				return err
+			}
+			changed = true
+		} else if changed, err = w.updateNetwork(ctrl, id, networkName, settings); err != nil {
+			return err
+		}
+	}
+
+	if err := ctrl.Command("SELECT_NETWORK " + id); err != nil {
+		return fmt.Errorf("连接WiFi失败: %v", err)
+	}
+	// 配置有变化时才保存，配置文件未设置 update_config=1 时无法保存，不影响本次连接
+	if changed {
+		if err := ctrl.Command("SAVE_CONFIG"); err != nil {
+			log.Printf("保存wpa_supplicant配置失败（需要在配置文件中设置 update_config=1）: %v", err)
+		}
+	}
+
+	// 等待关联和认证完成，wpa_supplicant需要先扫描，比nmcli多等待一些时间
+	for i := 0; i < 15; i++ { // 最多等待15秒
+		time.Sleep(1 * time.Second)
+		status, err := ctrl.Status()
+		if err != nil {
+			continue
+		}
+		if status["wpa_state"] == "COMPLETED" && status["ssid"] == networkName {
+			return nil // 连接成功
+		}
+	}
+	return fmt.Errorf("连接超时：无法连接到WiFi网络 '%s'，可能网络不存在或密码错误", networkName)
+}
+
+// findNetwork 在已保存的网络配置中查找指定网络，返回网络ID，未找到时返回空字符串
+func (w *WPASupplicantConnector) findNetwork(ctrl *WPAControl, networkName string) (string, error) {
+	// 格式: network id / ssid / bssid / flags，之后每行为 0\tMyWiFi\tany\t[CURRENT]
+	reply, err := ctrl.Request("LIST_NETWORKS")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(reply, "\n")[1:] {
+		fields := strings.Split(line, "\t")
+		if len(fields) >= 2 && fields[1] == networkName {
+			return fields[0], nil
+		}
+	}
+	return "", nil
+}
+
+// addNetwork 添加网络配置并逐项设置参数，设置失败时删除添加的配置
+func (w *WPASupplicantConnector) addNetwork(ctrl *WPAControl, networkName string, settings [][2]string) (string, error) {
+	id, err := ctrl.Request("ADD_NETWORK")
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(id, "FAIL") {
+		return "", fmt.Errorf("wpa_supplicant添加网络配置失败: %s", id)
+	}
+	for _, setting := range settings {
+		if err := w.setNetwork(ctrl, id, networkName, setting[0], setting[1]); err != nil {
+			ctrl.Command("REMOVE_NETWORK " + id)
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// updateNetwork 只设置与已保存配置不同的参数，返回是否修改了配置。
+// wpa_supplicant不返回密码等参数（GET_NETWORK回复*），这些参数与本进程上次设置的值不同时才重新设置
+func (w *WPASupplicantConnector) updateNetwork(ctrl *WPAControl, id, networkName string, settings [][2]string) (bool, error) {
+	changed := false
+	for _, setting := range settings {
+		key, value := setting[0], setting[1]
+		current, err := ctrl.Request(fmt.Sprintf("GET_NETWORK %s %s", id, key))
+		if err != nil {
+			return changed, err
+		}
+		if current == "*" {
+			if w.applied[id+"/"+networkName+"/"+key] == value {
+				continue
+			}
+		} else if wpaValueEqual(key, current, value) {
+			continue
+		}
+		if err := w.setNetwork(ctrl, id, networkName, key, value); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// setNetwork 设置一项网络参数并记录设置的值
+func (w *WPASupplicantConnector) setNetwork(ctrl *WPAControl, id, networkName, key, value string) error {
+	if err := ctrl.Command(fmt.Sprintf("SET_NETWORK %s %s %s", id, key, value)); err != nil {
+		return fmt.Errorf("设置网络参数%s失败: %v", key, err)
+	}
+	w.applied[id+"/"+networkName+"/"+key] = value
+	return nil
+}
+
+// wpaValueEqual 比较GET_NETWORK读回的值与要设置的值，SSID可能以带引号的字符串返回，key_mgmt的顺序可能不同
+func wpaValueEqual(key, current, value string) bool {
+	if current == value {
+		return true
+	}
+	switch key {
+	case "ssid":
+		if unquoted, ok := strings.CutPrefix(current, `"`); ok {
+			return hex.EncodeToString([]byte(strings.TrimSuffix(unquoted, `"`))) == value
+		}
+	case "key_mgmt":
+		currentFields, valueFields := strings.Fields(current), strings.Fields(value)
+		slices.Sort(currentFields)
+		slices.Sort(valueFields)
+		return slices.Equal(currentFields, valueFields)
+	}
+	return false
+}
+
+// wpaNetworkSettings 生成网络配置的SET_NETWORK参数，字符串值需要加引号，SSID使用十六进制以支持任意字符
+func wpaNetworkSettings(networkName, password string, options ConnectOptions) ([][2]string, error) {
+	settings := [][2]string{{"ssid", hex.EncodeToString([]byte(networkName))}}
+	if options.Hidden {
+		// 隐藏网络不在扫描结果中，需要主动探测
+		settings = append(settings, [2]string{"scan_ssid", "1"})
+	}
+	if options.Enterprise != nil {
+		return append(settings, wpaEnterpriseSettings(password, options.Enterprise)...), nil
+	}
+
+	security := options.ProfileSecurity()
+	if security.RequiresPassword() && password == "" {
+		return nil, fmt.Errorf("安全类型 %s 需要WiFi密码", security)
+	}
+	switch security {
+	case SecurityAuto:
+		if password == "" {
+			settings = append(settings, [2]string{"key_mgmt", "NONE"})
+		} else {
+			settings = append(settings, [2]string{"key_mgmt", "WPA-PSK"}, [2]string{"psk", wpaQuote(password)})
+		}
+	case SecurityOpen:
+		settings = append(settings, [2]string{"key_mgmt", "NONE"})
+	case SecurityWPA2PSK:
+		settings = append(settings, [2]string{"key_mgmt", "WPA-PSK"}, [2]string{"psk", wpaQuote(password)})
+	case SecurityWPA3SAE:
+		// WPA3要求管理帧保护
+		settings = append(settings, [2]string{"key_mgmt", "SAE"}, [2]string{"psk", wpaQuote(password)}, [2]string{"ieee80211w", "2"})
+	case SecurityWPA2WPA3:
+		settings = append(settings, [2]string{"key_mgmt", "WPA-PSK SAE"}, [2]string{"psk", wpaQuote(password)}, [2]string{"ieee80211w", "1"})
+	default:
+		return nil, fmt.Errorf("不支持的安全类型: %s", security)
+	}
+	return settings, nil
+}
+
+// wpaEnterpriseSettings 生成企业级（802.1X）网络配置的SET_NETWORK参数
+func wpaEnterpriseSettings(password string, creds *EnterpriseCredentials) [][2]string {
+	settings := [][2]string{
+		{"key_mgmt", "WPA-EAP"},
+		{"eap", strings.ToUpper(string(creds.EAPMethod))},
+		{"identity", wpaQuote(creds.Identity)},
+	}
+	if creds.AnonymousIdentity != "" {
+		settings = append(settings, [2]string{"anonymous_identity", wpaQuote(creds.AnonymousIdentity)})
+	}
+	if creds.CACert != "" {
+		settings = append(settings, [2]string{"ca_cert", wpaQuote(creds.CACert)})
+	}
+	if creds.ServerName != "" {
+		settings = append(settings, [2]string{"domain_suffix_match", wpaQuote(creds.ServerName)})
+	}
+
+	if creds.EAPMethod == EAPTLS {
+		// 未单独配置私钥时客户端证书文件中包含私钥
+		if creds.PrivateKey != "" {
+			settings = append(settings, [2]string{"client_cert", wpaQuote(creds.ClientCert)}, [2]string{"private_key", wpaQuote(creds.PrivateKey)})
+		} else {
+			settings = append(settings, [2]string{"private_key", wpaQuote(creds.ClientCert)})
+		}
+		if creds.PrivateKeyPassword != "" {
+			settings = append(settings, [2]string{"private_key_passwd", wpaQuote(creds.PrivateKeyPassword)})
+		}
+		return settings
+	}
+
+	if creds.Password != "" {
+		password = creds.Password
+	}
+	settings = append(settings, [2]string{"phase2", wpaQuote("auth=" + strings.ToUpper(creds.InnerMethod()))})
+	if password != "" {
+		settings = append(settings, [2]string{"password", wpaQuote(password)})
+	}
+	return settings
+}
+
+// wpaQuote 为字符串参数加引号，wpa_supplicant以最后一个引号为结束，值中的引号无需转义
+func wpaQuote(value string) string {
+	return `"` + value + `"`
+}
+
+// Scan 实现WiFiConnector接口 - 触发扫描后读取扫描结果
+func (w *WPASupplicantConnector) Scan() ([]ScanResult, error) {
+	ctrl := w.control()
+	reply, err := ctrl.Request("SCAN")
+	if err != nil {
+		return nil, fmt.Errorf("扫描WiFi网络失败: %v", err)
+	}
+	// FAIL-BUSY 表示正在扫描，直接读取结果
+	if reply == "OK" {
+		time.Sleep(3 * time.Second)
+	}
+
+	// 格式: bssid / frequency / signal level / flags / ssid，之后每行为
+	// aa:bb:cc:dd:ee:ff\t5745\t-52\t[WPA2-PSK-CCMP][ESS]\tMyWiFi
+	reply, err = ctrl.Request("SCAN_RESULTS")
+	if err != nil {
+		return nil, fmt.Errorf("扫描WiFi网络失败: %v", err)
+	}
+	var results []ScanResult
+	for _, line := range strings.Split(reply, "\n")[1:] {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 {
+			continue
+		}
+		result := ScanResult{
+			BSSID:    strings.ToLower(fields[0]),
+			Security: wpaFlagsSecurity(fields[3]),
+		}
+		if len(fields) >= 5 {
+			result.SSID = fields[4]
+		}
+		result.SetSignalDBm(parseLeadingInt(fields[2]))
+		result.SetFrequency(parseLeadingInt(fields[1]))
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// wpaFlagsSecurity 将扫描结果的标志（如 [WPA2-PSK+SAE-CCMP][ESS]）转换为安全类型说明
+func wpaFlagsSecurity(flags string) string {
+	switch {
+	case strings.Contains(flags, "EAP"):
+		return "802.1X"
+	case strings.Contains(flags, "PSK") && strings.Contains(flags, "SAE"):
+		return "WPA2 WPA3"
+	case strings.Contains(flags, "SAE"):
+		return "WPA3"
+	case strings.Contains(flags, "PSK"):
+		return "WPA2"
+	case strings.Contains(flags, "WEP"):
+		return "WEP"
+	default:
+		return "Open"
+	}
+}
+
+// ConnectBSSID 实现WiFiConnector接口 - 已连接到目标网络时通过ROAM切换到指定接入点
+func (w *WPASupplicantConnector) ConnectBSSID(networkName, bssid, password string) error {
+	ctrl := w.control()
+	status, err := ctrl.Status()
+	if err != nil {
+		return err
+	}
+	if status["ssid"] != networkName {
+		return fmt.Errorf("未连接到网络 %s，无法切换接入点", networkName)
+	}
+	if err := ctrl.Command("ROAM " + bssid); err != nil {
+		return fmt.Errorf("连接接入点失败: %v", err)
+	}
+
+	// 等待连接完成并验证已关联到目标接入点
+	for i := 0; i < 10; i++ { // 最多等待10秒
+		time.Sleep(1 * time.Second)
+		status, err := ctrl.Status()
+		if err != nil {
+			continue
+		}
+		if status["wpa_state"] == "COMPLETED" && strings.EqualFold(status["bssid"], bssid) {
+			return nil
+		}
+	}
+	return fmt.Errorf("连接超时：无法连接到接入点 %s", bssid)
+}
+
+// ApplyIPConfig 实现WiFiConnector接口 - wpa_supplicant只负责关联和认证，IP地址由dhcpcd或systemd-networkd管理
+func (w *WPASupplicantConnector) ApplyIPConfig(config *IPConfig) error {
+	return fmt.Errorf("wpa_supplicant后端不支持修改IP配置，请在dhcpcd或systemd-networkd中配置")
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeWPASupplicant 模拟wpa_supplicant控制接口，记录收到的命令
+type fakeWPASupplicant struct {
+	mutex    sync.Mutex
+	commands []string
+	// networks 网络ID到参数的映射，参数值与SET_NETWORK中的写法相同
+	networks map[int]map[string]string
+	nextID   int
+	current  int
+}
+
+// startFakeWPASupplicant 在临时目录中创建wlan0的控制套接字，并将 -wpa-ctrl 指向该目录
+func startFakeWPASupplicant(t *testing.T) *fakeWPASupplicant {
+	t.Helper()
+	dir := t.TempDir()
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: filepath.Join(dir, "wlan0"), Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("创建控制套接字失败: %v", err)
+	}
+	previous := wpaCtrlDir
+	wpaCtrlDir = dir
+	t.Cleanup(func() {
+		wpaCtrlDir = previous
+		conn.Close()
+	})
+
+	fake := &fakeWPASupplicant{networks: make(map[int]map[string]string), current: -1}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, from, err := conn.ReadFromUnix(buf)
+			if err != nil {
+				return
+			}
+			// 每条回复之前先发送一条事件消息，客户端需要跳过
+			conn.WriteToUnix([]byte("<3>CTRL-EVENT-SCAN-STARTED "), from)
+			conn.WriteToUnix([]byte(fake.handle(string(buf[:n]))+"\n"), from)
+		}
+	}()
+	return fake
+}
+
+// addNetwork 预置一个已保存的网络配置
+func (f *fakeWPASupplicant) addNetwork(settings map[string]string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	id := f.nextID
+	f.nextID++
+	f.networks[id] = settings
+	return id
+}
+
+// takeCommands 返回并清空收到的命令，STATUS和LIST_NETWORKS等查询命令除外
+func (f *fakeWPASupplicant) takeCommands() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var commands []string
+	for _, command := range f.commands {
+		if command != "STATUS" && command != "LIST_NETWORKS" && !strings.HasPrefix(command, "GET_NETWORK") {
+			commands = append(commands, command)
+		}
+	}
+	f.commands = nil
+	return commands
+}
+
+func (f *fakeWPASupplicant) handle(command string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.commands = append(f.commands, command)
+	fields := strings.SplitN(command, " ", 4)
+	network := func() map[string]string {
+		if len(fields) < 2 {
+			return nil
+		}
+		id, _ := strconv.Atoi(fields[1])
+		return f.networks[id]
+	}
+
+	switch fields[0] {
+	case "LIST_NETWORKS":
+		lines := []string{"network id / ssid / bssid / flags"}
+		for id := 0; id < f.nextID; id++ {
+			if settings, ok := f.networks[id]; ok {
+				lines = append(lines, fmt.Sprintf("%d\t%s\tany\t", id, fakeWPASSID(settings["ssid"])))
+			}
+		}
+		return strings.Join(lines, "\n")
+	case "ADD_NETWORK":
+		id := f.nextID
+		f.nextID++
+		f.networks[id] = make(map[string]string)
+		return strconv.Itoa(id)
+	case "SET_NETWORK":
+		settings := network()
+		if settings == nil || len(fields) < 4 {
+			return "FAIL"
+		}
+		settings[fields[2]] = fields[3]
+		return "OK"
+	case "GET_NETWORK":
+		settings := network()
+		value, ok := settings[fields[2]]
+		switch {
+		case !ok:
+			return "FAIL"
+		case fields[2] == "psk" || fields[2] == "password":
+			return "*"
+		case fields[2] == "ssid":
+			return `"` + fakeWPASSID(value) + `"`
+		}
+		return value
+	case "SELECT_NETWORK":
+		if network() == nil {
+			return "FAIL"
+		}
+		f.current, _ = strconv.Atoi(fields[1])
+		return "OK"
+	case "STATUS":
+		if settings, ok := f.networks[f.current]; ok {
+			return "wpa_state=COMPLETED\nssid=" + fakeWPASSID(settings["ssid"])
+		}
+		return "wpa_state=DISCONNECTED"
+	case "SAVE_CONFIG", "REMOVE_NETWORK":
+		return "OK"
+	case "SCAN":
+		return "FAIL-BUSY"
+	case "SCAN_RESULTS":
+		return "bssid / frequency / signal level / flags / ssid\n" +
+			"AA:BB:CC:DD:EE:01\t5745\t-52\t[WPA2-PSK+SAE-CCMP][ESS]\tOffice\n" +
+			"aa:bb:cc:dd:ee:02\t2412\t-70\t[ESS]\t\n" +
+			"aa:bb:cc:dd:ee:03\t2437\t-80\t[WPA2-EAP-CCMP][ESS]\tCorp"
+	}
+	return "UNKNOWN COMMAND"
+}
+
+// fakeWPASSID 将十六进制或带引号的SSID转换为网络名称
+func fakeWPASSID(value string) string {
+	if unquoted, ok := strings.CutPrefix(value, `"`); ok {
+		return strings.TrimSuffix(unquoted, `"`)
+	}
+	name, _ := hex.DecodeString(value)
+	return string(name)
+}
+
+func TestWPASupplicantConnect(t *testing.T) {
+	fake := startFakeWPASupplicant(t)
+	connector := NewWPASupplicantConnector(&LinuxConnector{interfaceName: "wlan0"})
+	ssid := hex.EncodeToString([]byte("Office"))
+
+	steps := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"添加新网络", "secret", []string{
+			"ADD_NETWORK",
+			"SET_NETWORK 0 ssid " + ssid,
+			"SET_NETWORK 0 key_mgmt WPA-PSK",
+			`SET_NETWORK 0 psk "secret"`,
+			"SELECT_NETWORK 0",
+			"SAVE_CONFIG",
+		}},
+		{"配置未变化时只选择网络", "secret", []string{"SELECT_NETWORK 0"}},
+		{"密码变化时只修改密码", "changed", []string{
+			`SET_NETWORK 0 psk "changed"`,
+			"SELECT_NETWORK 0",
+			"SAVE_CONFIG",
+		}},
+		{"未提供密码时使用已保存的配置", "", []string{"SELECT_NETWORK 0"}},
+	}
+	for _, step := range steps {
+		if err := connector.Connect("Office", step.password, ConnectOptions{}); err != nil {
+			t.Fatalf("%s: 连接失败: %v", step.name, err)
+		}
+		if got := fake.takeCommands(); !slices.Equal(got, step.want) {
+			t.Fatalf("%s: 命令 = %q\n期望 %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestWPASupplicantConnectExistingNetwork(t *testing.T) {
+	fake := startFakeWPASupplicant(t)
+	fake.addNetwork(map[string]string{"ssid": `"Home"`, "key_mgmt": "WPA-PSK"})
+	id := fake.addNetwork(map[string]string{"ssid": `"Office"`, "key_mgmt": "SAE WPA-PSK", "psk": `"secret"`, "ieee80211w": "1"})
+	connector := NewWPASupplicantConnector(&LinuxConnector{interfaceName: "wlan0"})
+
+	// 已保存配置中读不回密码，本进程第一次连接时设置一次，其余参数与已保存的相同
+	err := connector.Connect("Office", "secret", ConnectOptions{Security: SecurityWPA2WPA3})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	want := []string{fmt.Sprintf(`SET_NETWORK %d psk "secret"`, id), fmt.Sprintf("SELECT_NETWORK %d", id), "SAVE_CONFIG"}
+	if got := fake.takeCommands(); !slices.Equal(got, want) {
+		t.Fatalf("命令 = %q\n期望 %q", got, want)
+	}
+}
+
+func TestWPASupplicantScan(t *testing.T) {
+	startFakeWPASupplicant(t)
+	connector := NewWPASupplicantConnector(&LinuxConnector{interfaceName: "wlan0"})
+	results, err := connector.Scan()
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	want := []struct {
+		ssid, bssid, security string
+		signal, frequency     int
+	}{
+		{"Office", "aa:bb:cc:dd:ee:01", "WPA2 WPA3", -52, 5745},
+		{"", "aa:bb:cc:dd:ee:02", "Open", -70, 2412},
+		{"Corp", "aa:bb:cc:dd:ee:03", "802.1X", -80, 2437},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("扫描结果 = %+v，期望%d条", results, len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.SSID != w.ssid || r.BSSID != w.bssid || r.Security != w.security || r.SignalDBm != w.signal || r.FrequencyMHz != w.frequency {
+			t.Fatalf("第%d条扫描结果 = %+v，期望 %+v", i+1, r, w)
+		}
+	}
+}